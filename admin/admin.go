@@ -0,0 +1,355 @@
+// Package admin expose an HTTP API in order to inspect and operate the sagas
+// of a SEC.
+//
+// The Handler serves JSON endpoints and is usually mounted under a prefix:
+//
+//	http.Handle("/admin/", http.StripPrefix("/admin", admin.NewHandler(sec)))
+//
+// The endpoints are:
+//
+//	GET  /sagas                        list the sagas
+//	GET  /sagas/{id}                   show the saga summary
+//	GET  /sagas/{id}/events            show the saga eventlogs timeline
+//	POST /sagas/{id}/retry             retry the failing Compensation of a stuck saga
+//	POST /sagas/{id}/skip-compensation consider the failing Compensation as applied manually
+//	POST /sagas/{id}/cancel            cancel a running saga and trigger its compensation
+//
+// The sagas are listed with the "status", "type", "version", "createdAfter",
+// "createdBefore" (RFC 3339), "cursor" and "limit" query parameters.
+//
+// The retry and skip-compensation endpoints schedule the saga with the SEC and
+// return 202 Accepted without waiting its end, see gosaga.SEC.SubmitResume
+// and gosaga.SEC.SubmitSkipCompensation. The saga progress is followed with
+// the saga summary.
+//
+// A manual compensation of a running saga is triggered with the cancel
+// endpoint, see gosaga.SEC.Cancel. It accepts an optional CancelRequest body
+// and doesn't wait the saga compensation either.
+//
+// The Handler doesn't do any authentication, it must be protected by the
+// caller.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/model"
+)
+
+// defaultLimit is the number of sagas listed when no limit is given.
+const defaultLimit = 100
+
+// Handler is an http.Handler serving the admin API of a SEC.
+type Handler struct {
+	sec *gosaga.SEC
+}
+
+// NewHandler instantiate a new Handler for the given SEC.
+//
+// Recover must have been called on the SEC in order to operate the sagas
+// stuck before a restart.
+func NewHandler(sec *gosaga.SEC) *Handler {
+	return &Handler{sec: sec}
+}
+
+// Saga is the JSON representation of a gosaga.SagaSummary.
+type Saga struct {
+	ID           string    `json:"id"`
+	Status       string    `json:"status"`
+	Outcome      string    `json:"outcome,omitempty"`
+	SagaType     string    `json:"sagaType,omitempty"`
+	SagaVersion  int       `json:"sagaVersion,omitempty"`
+	CurrentStep  string    `json:"currentStep,omitempty"`
+	CurrentState string    `json:"currentState,omitempty"`
+	Attempts     int       `json:"attempts"`
+	Reason       string    `json:"reason,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+// SagaList is the response of the list endpoint. Cursor is empty for the last
+// page.
+type SagaList struct {
+	Sagas  []Saga `json:"sagas"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// Event is the JSON representation of a model.EventLog.
+type Event struct {
+	Step        string            `json:"step"`
+	Branch      string            `json:"branch,omitempty"`
+	State       string            `json:"state"`
+	Context     json.RawMessage   `json:"context,omitempty"`
+	Reason      string            `json:"reason,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	SagaType    string            `json:"sagaType,omitempty"`
+	SagaVersion int               `json:"sagaVersion,omitempty"`
+	CreatedAt   time.Time         `json:"createdAt"`
+}
+
+// EventList is the response of the events endpoint.
+type EventList struct {
+	Events []Event `json:"events"`
+}
+
+// CancelRequest is the body of the cancel endpoint.
+type CancelRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Error is the response of the failed requests.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (t *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "sagas" || len(parts) > 3 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		route(w, r, http.MethodGet, t.listSagas)
+	case len(parts) == 2:
+		route(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { t.getSaga(w, r, parts[1]) })
+	case parts[2] == "events":
+		route(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { t.getEvents(w, r, parts[1]) })
+	case parts[2] == "retry":
+		route(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			t.operateStuckSaga(w, r, parts[1], t.sec.SubmitResume)
+		})
+	case parts[2] == "skip-compensation":
+		route(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			t.operateStuckSaga(w, r, parts[1], t.sec.SubmitSkipCompensation)
+		})
+	case parts[2] == "cancel":
+		route(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) { t.cancelSaga(w, r, parts[1]) })
+	default:
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	}
+}
+
+// route call the given handler if the request uses the expected method.
+func route(w http.ResponseWriter, r *http.Request, method string, handler http.HandlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	handler(w, r)
+}
+
+func (t *Handler) listSagas(w http.ResponseWriter, r *http.Request) {
+	query, err := parseQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	summaries, cursor, err := t.sec.ListSagas(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	res := SagaList{Sagas: make([]Saga, 0, len(summaries)), Cursor: cursor}
+	for i := range summaries {
+		res.Sagas = append(res.Sagas, newSaga(&summaries[i]))
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (t *Handler) getSaga(w http.ResponseWriter, r *http.Request, sagaID string) {
+	summary, err := t.sec.GetSagaSummary(r.Context(), sagaID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newSaga(summary))
+}
+
+func (t *Handler) getEvents(w http.ResponseWriter, r *http.Request, sagaID string) {
+	eventLogs, err := t.sec.GetSagaHistory(r.Context(), sagaID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	res := EventList{Events: make([]Event, 0, len(eventLogs))}
+	for _, eventLog := range eventLogs {
+		res.Events = append(res.Events, Event{
+			Step:        eventLog.Step,
+			Branch:      eventLog.Branch,
+			State:       eventLog.State,
+			Context:     eventLog.Context,
+			Reason:      eventLog.Reason,
+			Metadata:    eventLog.Metadata,
+			SagaType:    eventLog.SagaType,
+			SagaVersion: eventLog.SagaVersion,
+			CreatedAt:   eventLog.CreatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// operateStuckSaga apply the given operation on a stuck saga and return its
+// new summary. The saga is run in background by the SEC.
+func (t *Handler) operateStuckSaga(w http.ResponseWriter, r *http.Request, sagaID string, operation func(context.Context, string) error) {
+	summary, err := t.sec.GetSagaSummary(r.Context(), sagaID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if summary.Status != "stuck" {
+		writeError(w, http.StatusConflict, fmt.Errorf("expected saga %q to be \"stuck\", have %q", sagaID, summary.Status))
+		return
+	}
+
+	err = operation(r.Context(), sagaID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	summary, err = t.sec.GetSagaSummary(r.Context(), sagaID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newSaga(summary))
+}
+
+// cancelSaga request the cancellation of a running saga and return its
+// summary. The saga is compensated in background.
+func (t *Handler) cancelSaga(w http.ResponseWriter, r *http.Request, sagaID string) {
+	// The body is optional.
+	var req CancelRequest
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && err != io.EOF {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid body: %s", err))
+		return
+	}
+
+	summary, err := t.sec.GetSagaSummary(r.Context(), sagaID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	if summary.Status != "running" {
+		writeError(w, http.StatusConflict, fmt.Errorf("expected saga %q to be \"running\", have %q", sagaID, summary.Status))
+		return
+	}
+
+	err = t.sec.Cancel(r.Context(), sagaID, req.Reason)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	summary, err = t.sec.GetSagaSummary(r.Context(), sagaID)
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newSaga(summary))
+}
+
+func parseQuery(values url.Values) (model.SagaQuery, error) {
+	query := model.SagaQuery{
+		Status:   values.Get("status"),
+		SagaType: values.Get("type"),
+		Cursor:   values.Get("cursor"),
+		Limit:    defaultLimit,
+	}
+
+	var err error
+	if raw := values.Get("version"); raw != "" {
+		query.SagaVersion, err = strconv.Atoi(raw)
+		if err != nil {
+			return query, fmt.Errorf("invalid \"version\" parameter: %s", err)
+		}
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		query.Limit, err = strconv.Atoi(raw)
+		if err != nil || query.Limit <= 0 {
+			return query, fmt.Errorf("invalid \"limit\" parameter: expected a positive integer, have %q", raw)
+		}
+	}
+
+	if raw := values.Get("createdAfter"); raw != "" {
+		query.CreatedAfter, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return query, fmt.Errorf("invalid \"createdAfter\" parameter: %s", err)
+		}
+	}
+
+	if raw := values.Get("createdBefore"); raw != "" {
+		query.CreatedBefore, err = time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return query, fmt.Errorf("invalid \"createdBefore\" parameter: %s", err)
+		}
+	}
+
+	return query, nil
+}
+
+func newSaga(summary *gosaga.SagaSummary) Saga {
+	return Saga{
+		ID:           summary.ID,
+		Status:       summary.Status,
+		Outcome:      summary.Outcome,
+		SagaType:     summary.SagaType,
+		SagaVersion:  summary.SagaVersion,
+		CurrentStep:  summary.CurrentStep,
+		CurrentState: summary.CurrentState,
+		Attempts:     summary.Attempts,
+		Reason:       summary.Reason,
+		CreatedAt:    summary.CreatedAt,
+		UpdatedAt:    summary.UpdatedAt,
+	}
+}
+
+func errorStatus(err error) int {
+	if errors.Is(err, gosaga.ErrSagaNotFound) {
+		return http.StatusNotFound
+	}
+
+	if errors.Is(err, gosaga.ErrNotCancellable) || errors.Is(err, gosaga.ErrSagaNotStuck) {
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,377 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSEC return a SEC with a saga committed and a saga stuck on the
+// "step1" Compensation. The Compensation succeed once fixed is true.
+func newTestSEC(t *testing.T, fixed *bool) (*gosaga.SEC, string, string) {
+	sec := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Success(sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			if !*fixed {
+				return gosaga.Failure(errors.New("some-compensation-error"), sagaCtx)
+			}
+
+			return gosaga.Success(sagaCtx)
+		}, gosaga.WithCompensationRetry(gosaga.RetryPolicy{MaxAttempts: 1})).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			if string(sagaCtx) == `{"fail": true}` {
+				return gosaga.Failure(errors.New("some-error"), sagaCtx)
+			}
+
+			return gosaga.Success(sagaCtx)
+		}, nil)
+
+	committed, err := sec.Submit(context.Background(), json.RawMessage(`{"fail": false}`))
+	require.NoError(t, err)
+
+	_, err = sec.Wait(context.Background(), committed)
+	require.NoError(t, err)
+
+	stuck, err := sec.Submit(context.Background(), json.RawMessage(`{"fail": true}`))
+	require.NoError(t, err)
+
+	_, err = sec.Wait(context.Background(), stuck)
+	require.Error(t, err)
+
+	return sec, committed, stuck
+}
+
+func serve(t *testing.T, handler http.Handler, method string, target string, res any) int {
+	r := httptest.NewRequest(method, target, nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), res))
+
+	return w.Code
+}
+
+func Test_Handler_list_sagas(t *testing.T) {
+	fixed := false
+	sec, committed, stuck := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	var res SagaList
+	code := serve(t, handler, http.MethodGet, "/sagas", &res)
+	require.Equal(t, http.StatusOK, code)
+
+	ids := []string{}
+	for _, saga := range res.Sagas {
+		ids = append(ids, saga.ID)
+	}
+	assert.ElementsMatch(t, []string{committed, stuck}, ids)
+	assert.Empty(t, res.Cursor)
+
+	res = SagaList{}
+	code = serve(t, handler, http.MethodGet, "/sagas?status=stuck", &res)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, res.Sagas, 1)
+
+	assert.Equal(t, stuck, res.Sagas[0].ID)
+	assert.Equal(t, "stuck", res.Sagas[0].Status)
+	assert.Equal(t, "step1", res.Sagas[0].CurrentStep)
+	assert.Equal(t, "some-error", res.Sagas[0].Reason)
+}
+
+func Test_Handler_list_sagas_with_pagination(t *testing.T) {
+	fixed := false
+	sec, _, _ := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	var res SagaList
+	code := serve(t, handler, http.MethodGet, "/sagas?limit=1", &res)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, res.Sagas, 1)
+	require.NotEmpty(t, res.Cursor)
+
+	first, cursor := res.Sagas[0].ID, res.Cursor
+
+	res = SagaList{}
+	code = serve(t, handler, http.MethodGet, "/sagas?limit=1&cursor="+url.QueryEscape(cursor), &res)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, res.Sagas, 1)
+	assert.Empty(t, res.Cursor)
+	assert.NotEqual(t, first, res.Sagas[0].ID)
+}
+
+func Test_Handler_list_sagas_with_an_invalid_parameter(t *testing.T) {
+	handler := NewHandler(gosaga.NewSagaExecutionCoordinator(storage.NewMemory()))
+
+	var res Error
+	code := serve(t, handler, http.MethodGet, "/sagas?limit=-1", &res)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, `invalid "limit" parameter: expected a positive integer, have "-1"`, res.Error)
+
+	code = serve(t, handler, http.MethodGet, "/sagas?createdAfter=yesterday", &res)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.True(t, strings.HasPrefix(res.Error, `invalid "createdAfter" parameter`))
+}
+
+func Test_Handler_show_saga(t *testing.T) {
+	fixed := false
+	sec, committed, _ := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	var res Saga
+	code := serve(t, handler, http.MethodGet, "/sagas/"+committed, &res)
+	require.Equal(t, http.StatusOK, code)
+
+	assert.Equal(t, committed, res.ID)
+	assert.Equal(t, "done", res.Status)
+	assert.Equal(t, "committed", res.Outcome)
+	assert.Equal(t, "step2", res.CurrentStep)
+	assert.Equal(t, "done", res.CurrentState)
+	assert.Equal(t, 1, res.Attempts)
+}
+
+func Test_Handler_show_saga_with_an_unknown_saga(t *testing.T) {
+	handler := NewHandler(gosaga.NewSagaExecutionCoordinator(storage.NewMemory()))
+
+	var res Error
+	code := serve(t, handler, http.MethodGet, "/sagas/some-unknown-id", &res)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, `saga not found: "some-unknown-id"`, res.Error)
+}
+
+func Test_Handler_show_events(t *testing.T) {
+	fixed := false
+	sec, committed, _ := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	var res EventList
+	code := serve(t, handler, http.MethodGet, "/sagas/"+committed+"/events", &res)
+	require.Equal(t, http.StatusOK, code)
+
+	timeline := []string{}
+	for _, event := range res.Events {
+		timeline = append(timeline, event.Step+":"+event.State)
+		assert.False(t, event.CreatedAt.IsZero())
+	}
+
+	assert.Equal(t, []string{"_init:done", "step1:running", "step1:done", "step2:running", "step2:done", "_finish:done"}, timeline)
+	assert.JSONEq(t, `{"fail": false}`, string(res.Events[0].Context))
+}
+
+func Test_Handler_retry_a_stuck_saga(t *testing.T) {
+	fixed := false
+	sec, _, stuck := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	// Still failing.
+	var res Saga
+	code := serve(t, handler, http.MethodPost, "/sagas/"+stuck+"/retry", &res)
+	require.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "step1", res.CurrentStep)
+
+	_, err := sec.Wait(context.Background(), stuck)
+	require.Error(t, err)
+
+	res = Saga{}
+	code = serve(t, handler, http.MethodGet, "/sagas/"+stuck, &res)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "stuck", res.Status)
+
+	fixed = true
+	res = Saga{}
+	code = serve(t, handler, http.MethodPost, "/sagas/"+stuck+"/retry", &res)
+	require.Equal(t, http.StatusAccepted, code)
+
+	_, err = sec.Wait(context.Background(), stuck)
+	require.Error(t, err)
+
+	res = Saga{}
+	code = serve(t, handler, http.MethodGet, "/sagas/"+stuck, &res)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "done", res.Status)
+	assert.Equal(t, "compensated", res.Outcome)
+	assert.Empty(t, sec.ListStuckSagas())
+}
+
+func Test_Handler_skip_compensation(t *testing.T) {
+	fixed := false
+	sec, _, stuck := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	var res Saga
+	code := serve(t, handler, http.MethodPost, "/sagas/"+stuck+"/skip-compensation", &res)
+	require.Equal(t, http.StatusAccepted, code)
+
+	outcome, err := sec.Wait(context.Background(), stuck)
+	require.Error(t, err)
+	assert.Equal(t, "compensated", outcome.Status)
+
+	res = Saga{}
+	code = serve(t, handler, http.MethodGet, "/sagas/"+stuck, &res)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "done", res.Status)
+	assert.Equal(t, "compensated", res.Outcome)
+	assert.Equal(t, "skipped", res.CurrentState)
+}
+
+func Test_Handler_retry_a_saga_not_recovered(t *testing.T) {
+	memory := storage.NewMemory()
+	for _, eventLog := range []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", CreatedAt: time.Now()},
+		{SagaID: "some-saga-id", Step: "step1", State: "running", CreatedAt: time.Now()},
+		{SagaID: "some-saga-id", Step: "step1", State: "stuck", CreatedAt: time.Now()},
+	} {
+		require.NoError(t, memory.SaveEventLog(context.Background(), &eventLog))
+	}
+
+	// Recover is not called.
+	handler := NewHandler(gosaga.NewSagaExecutionCoordinator(memory))
+
+	for _, operation := range []string{"retry", "skip-compensation"} {
+		var res Error
+		code := serve(t, handler, http.MethodPost, "/sagas/some-saga-id/"+operation, &res)
+		assert.Equal(t, http.StatusConflict, code)
+		assert.Equal(t, `saga not stuck: expected saga "some-saga-id" to be "stuck", have ""`, res.Error)
+	}
+}
+
+func Test_Handler_retry_a_saga_not_stuck(t *testing.T) {
+	fixed := false
+	sec, committed, _ := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	var res Error
+	code := serve(t, handler, http.MethodPost, "/sagas/"+committed+"/retry", &res)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, `expected saga "`+committed+`" to be "stuck", have "done"`, res.Error)
+}
+
+func Test_Handler_cancel_a_running_saga(t *testing.T) {
+	started := make(chan struct{})
+	sec := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			close(started)
+			<-ctx.Done()
+			return gosaga.Failure(ctx.Err(), sagaCtx)
+		}, nil)
+	handler := NewHandler(sec)
+
+	sagaID, err := sec.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	<-started
+
+	r := httptest.NewRequest(http.MethodPost, "/sagas/"+sagaID+"/cancel", strings.NewReader(`{"reason": "some-reason"}`))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var res Saga
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, sagaID, res.ID)
+
+	_, err = sec.Wait(context.Background(), sagaID)
+	assert.ErrorIs(t, err, gosaga.ErrCancelled)
+	assert.ErrorContains(t, err, "cancelled: some-reason")
+}
+
+func Test_Handler_cancel_a_running_saga_without_body(t *testing.T) {
+	started := make(chan struct{})
+	sec := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			close(started)
+			<-ctx.Done()
+			return gosaga.Failure(ctx.Err(), sagaCtx)
+		}, nil)
+	handler := NewHandler(sec)
+
+	sagaID, err := sec.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	<-started
+
+	// An empty chunked body have an unknown length.
+	r := httptest.NewRequest(http.MethodPost, "/sagas/"+sagaID+"/cancel", struct{ io.Reader }{strings.NewReader("")})
+	require.Equal(t, int64(-1), r.ContentLength)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	_, err = sec.Wait(context.Background(), sagaID)
+	assert.ErrorIs(t, err, gosaga.ErrCancelled)
+}
+
+func Test_Handler_cancel_a_saga_not_running(t *testing.T) {
+	fixed := false
+	sec, committed, _ := newTestSEC(t, &fixed)
+	handler := NewHandler(sec)
+
+	var res Error
+	code := serve(t, handler, http.MethodPost, "/sagas/"+committed+"/cancel", &res)
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Equal(t, `expected saga "`+committed+`" to be "running", have "done"`, res.Error)
+
+	code = serve(t, handler, http.MethodPost, "/sagas/some-unknown-id/cancel", &res)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func Test_Handler_with_an_invalid_method(t *testing.T) {
+	handler := NewHandler(gosaga.NewSagaExecutionCoordinator(storage.NewMemory()))
+
+	r := httptest.NewRequest(http.MethodGet, "/sagas/some-id/retry", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+}
+
+func Test_Handler_with_an_unknown_path(t *testing.T) {
+	handler := NewHandler(gosaga.NewSagaExecutionCoordinator(storage.NewMemory()))
+
+	for _, path := range []string{"/", "/foo", "/sagas/some-id/foo", "/sagas/some-id/events/foo"} {
+		var res Error
+		code := serve(t, handler, http.MethodGet, path, &res)
+		assert.Equal(t, http.StatusNotFound, code, path)
+	}
+}
+
+func Test_Handler_with_a_server(t *testing.T) {
+	fixed := false
+	sec, committed, _ := newTestSEC(t, &fixed)
+
+	mux := http.NewServeMux()
+	mux.Handle("/admin/", http.StripPrefix("/admin", NewHandler(sec)))
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/admin/sagas/" + committed)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res Saga
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, committed, res.ID)
+}
@@ -0,0 +1,136 @@
+package gosaga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrCancelled is wrapped by the errors of the Actions stopped by a saga
+// cancellation, see SEC.Cancel.
+var ErrCancelled = errors.New("cancelled")
+
+// ErrNotCancellable is returned by Cancel for the sagas not running or past
+// their pivot.
+var ErrNotCancellable = errors.New("saga not cancellable")
+
+// sagaCancel is the cancellation state of a saga run or requested into the
+// SEC.
+type sagaCancel struct {
+	// cause is the cancellation cause, nil until the cancellation is
+	// requested.
+	cause error
+
+	// cancel stop the Actions of the running saga, nil until the saga is run.
+	cancel context.CancelCauseFunc
+}
+
+// Cancel request the cancellation of the given running saga.
+//
+// The request is saved into the journal with its reason then the Action
+// currently running is interrupted via its context. The Action is waited, it
+// can finish its work or return early. Once stopped the saga is aborted with
+// an error wrapping ErrCancelled and the reason, and it is compensated as for
+// any failing Action.
+//
+// Cancel doesn't wait the compensation. A saga started with Submit can be
+// waited with Wait. A cancellation requested before a restart is applied by
+// Recover.
+//
+// The sagas past their pivot can't be cancelled as they must be finished, an
+// error wrapping ErrNotCancellable is returned for them and for the sagas not
+// running. A saga whose last Action succeeds despite the cancellation is
+// committed.
+func (t *SEC) Cancel(ctx context.Context, sagaID string, reason string) error {
+	status := t.journal.GetSagaStatus(sagaID)
+	if status != "running" {
+		return fmt.Errorf("%w: expected saga %q to be \"running\", have %q", ErrNotCancellable, sagaID, status)
+	}
+
+	def, err := t.getDefinition(sagaID)
+	if err != nil {
+		return err
+	}
+
+	if t.isForward(sagaID, def.subRequestDefs) {
+		return fmt.Errorf("%w: the saga %q is past its pivot", ErrNotCancellable, sagaID)
+	}
+
+	err = t.journal.RequestSagaCancel(ctx, sagaID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to request the cancellation of the saga %q: %s", sagaID, err)
+	}
+
+	t.cancelSaga(sagaID, reason)
+
+	return nil
+}
+
+// cancelSaga stop the Actions of the given saga if it is running, else the
+// cancellation is applied once it is run. Nothing is done for a saga finished
+// in the meantime.
+func (t *SEC) cancelSaga(sagaID string, reason string) {
+	cause := ErrCancelled
+	if reason != "" {
+		cause = fmt.Errorf("%w: %s", ErrCancelled, reason)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// The state of a run saga is deleted once it is finished, it must not be
+	// created again.
+	_, ok := t.cancels[sagaID]
+	if !ok && t.journal.GetSagaStatus(sagaID) != "running" {
+		return
+	}
+
+	state := t.getSagaCancel(sagaID)
+	if state.cause != nil {
+		return
+	}
+
+	state.cause = cause
+	if state.cancel != nil {
+		state.cancel(cause)
+	}
+}
+
+// withCancel return the context given to the Actions of the given saga,
+// canceled once the saga cancellation is requested.
+func (t *SEC) withCancel(ctx context.Context, sagaID string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state := t.getSagaCancel(sagaID)
+	state.cancel = cancel
+	if state.cause != nil {
+		cancel(state.cause)
+	}
+
+	return ctx, func() {
+		t.mutex.Lock()
+		delete(t.cancels, sagaID)
+		t.mutex.Unlock()
+
+		cancel(nil)
+	}
+}
+
+// getSagaCancel return the cancellation state of the given saga. The mutex
+// must be held.
+func (t *SEC) getSagaCancel(sagaID string) *sagaCancel {
+	if t.cancels == nil {
+		t.cancels = map[string]*sagaCancel{}
+	}
+
+	state, ok := t.cancels[sagaID]
+	if !ok {
+		state = &sagaCancel{}
+		t.cancels[sagaID] = state
+	}
+
+	return state
+}
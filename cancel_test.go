@@ -0,0 +1,210 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SEC_Cancel_should_interrupt_the_running_action(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+
+	started := make(chan struct{})
+	attempts := new(atomic.Int32)
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{}`))), calls.record("undo-step1", Success(nil))).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			attempts.Add(1)
+			close(started)
+			<-ctx.Done()
+			return Failure(ctx.Err(), sagaCtx)
+		}, nil, WithActionRetry(RetryPolicy{MaxAttempts: 3})).
+		AppendNewSubRequest("step3", calls.record("step3", Success(nil)), nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	<-started
+	err = scheduler.Cancel(context.Background(), sagaID, "some-reason")
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Equal(t, "compensated", outcome.Status)
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "step2", abortedErr.SubRequestID)
+	assert.ErrorIs(t, err, ErrCancelled)
+	assert.EqualError(t, abortedErr.Err, "cancelled: some-reason")
+
+	// The cancelled Action is not retried.
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.Equal(t, []string{"step1", "undo-step1"}, calls.sorted())
+
+	events, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	reasons := []string{}
+	for _, event := range events {
+		if event.Reason != "" {
+			reasons = append(reasons, event.Step+":"+event.State+":"+event.Reason)
+		}
+	}
+	assert.Equal(t, []string{"_cancel:requested:some-reason", "step2:aborted:cancelled: some-reason"}, reasons)
+}
+
+func Test_SEC_Cancel_should_let_the_running_action_finish(t *testing.T) {
+	calls := newCalls()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			close(started)
+			<-release
+			return Success(sagaCtx)
+		}, calls.record("undo-step1", Success(nil))).
+		AppendNewSubRequest("step2", calls.record("step2", Success(nil)), calls.record("undo-step2", Success(nil)))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	<-started
+	err = scheduler.Cancel(context.Background(), sagaID, "some-reason")
+	require.NoError(t, err)
+	close(release)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Equal(t, "compensated", outcome.Status)
+	assert.ErrorIs(t, err, ErrCancelled)
+
+	// The succeeded Action is compensated, the next one is never run.
+	assert.Equal(t, []string{"undo-step1", "undo-step2"}, calls.sorted())
+}
+
+func Test_SEC_Cancel_with_a_saga_not_started_yet(t *testing.T) {
+	calls := newCalls()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithWorkers(1).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			if string(sagaCtx) == `{"block": true}` {
+				close(started)
+				<-release
+				return Success(sagaCtx)
+			}
+
+			return calls.record("step1", Success(sagaCtx))(ctx, sagaCtx)
+		}, nil)
+
+	// The first saga hold the single worker.
+	blocking, err := scheduler.Submit(context.Background(), json.RawMessage(`{"block": true}`))
+	require.NoError(t, err)
+
+	<-started
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	err = scheduler.Cancel(context.Background(), sagaID, "some-reason")
+	require.NoError(t, err)
+	close(release)
+
+	_, err = scheduler.Wait(context.Background(), blocking)
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Equal(t, "compensated", outcome.Status)
+	assert.ErrorIs(t, err, ErrCancelled)
+	assert.Empty(t, calls.sorted())
+}
+
+func Test_SEC_Cancel_with_a_saga_not_running(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", newCalls().record("step1", Success(nil)), nil)
+
+	err := scheduler.Cancel(context.Background(), "some-unknown-id", "some-reason")
+	assert.ErrorIs(t, err, ErrNotCancellable)
+	assert.EqualError(t, err, `saga not cancellable: expected saga "some-unknown-id" to be "running", have ""`)
+}
+
+func Test_SEC_cancelSaga_with_a_saga_finished_in_the_meantime(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", newCalls().record("step1", Success(nil)), nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	// Cancel have checked the saga status before its end.
+	scheduler.cancelSaga(sagaID, "some-reason")
+
+	assert.Empty(t, scheduler.cancels)
+}
+
+func Test_SEC_Cancel_with_a_saga_past_its_pivot(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("pivot", newCalls().record("pivot", Success(json.RawMessage(`{}`))), nil, AsPivot()).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			close(started)
+			<-release
+			return Success(sagaCtx)
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	<-started
+	err = scheduler.Cancel(context.Background(), sagaID, "some-reason")
+	assert.EqualError(t, err, `saga not cancellable: the saga "`+sagaID+`" is past its pivot`)
+	close(release)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+}
+
+func Test_SEC_Recover_with_a_cancel_request(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	for _, eventLog := range []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "running", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "done", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "_cancel", State: "requested", Reason: "some-reason"},
+	} {
+		eventLog.CreatedAt = time.Now()
+		require.NoError(t, memory.SaveEventLog(ctx, &eventLog))
+	}
+
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", calls.record("step1", Success(nil)), calls.record("undo-step1", Success(nil))).
+		AppendNewSubRequest("step2", calls.record("step2", Success(nil)), nil)
+
+	err := scheduler.Recover(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"undo-step1"}, calls.sorted())
+
+	events, err := memory.GetSagaEventLogs(ctx, "some-saga-id")
+	require.NoError(t, err)
+
+	last := events[len(events)-1]
+	assert.Equal(t, "_finish", last.Step)
+}
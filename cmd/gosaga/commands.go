@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/model"
+)
+
+func listCmd(ctx context.Context, store journal.Storage, args []string, out io.Writer) error {
+	var query model.SagaQuery
+
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.StringVar(&query.Status, "status", "", "keep only the sagas with the given status: running, aborted, stuck or done")
+	flags.StringVar(&query.SagaType, "type", "", "keep only the sagas with the given definition")
+	flags.IntVar(&query.SagaVersion, "version", 0, "keep only the sagas with the given definition version")
+	flags.StringVar(&query.Cursor, "cursor", "", "cursor of the page to list")
+	flags.IntVar(&query.Limit, "limit", 50, "maximum number of sagas listed, 0 for all")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	summaries, cursor, err := gosaga.NewSagaExecutionCoordinator(store).ListSagas(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSTATUS\tOUTCOME\tDEFINITION\tSTEP\tSTATE\tATTEMPTS\tCREATED")
+	for _, summary := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			summary.ID, summary.Status, orDash(summary.Outcome), definition(summary.SagaType, summary.SagaVersion),
+			summary.CurrentStep, summary.CurrentState, summary.Attempts, formatDate(summary.CreatedAt))
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if cursor != "" {
+		fmt.Fprintf(out, "\nnext page: -cursor %s\n", cursor)
+	}
+
+	return nil
+}
+
+func showCmd(ctx context.Context, store journal.Storage, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: gosaga show <sagaID>")
+	}
+
+	sec := gosaga.NewSagaExecutionCoordinator(store)
+
+	summary, err := sec.GetSagaSummary(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	eventLogs, err := sec.GetSagaHistory(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", summary.ID)
+	fmt.Fprintf(w, "Status:\t%s\n", summary.Status)
+	fmt.Fprintf(w, "Outcome:\t%s\n", orDash(summary.Outcome))
+	fmt.Fprintf(w, "Definition:\t%s\n", definition(summary.SagaType, summary.SagaVersion))
+	fmt.Fprintf(w, "Current step:\t%s %s (%d attempts)\n", summary.CurrentStep, summary.CurrentState, summary.Attempts)
+	fmt.Fprintf(w, "Reason:\t%s\n", orDash(summary.Reason))
+	fmt.Fprintf(w, "Created:\t%s\n", formatDate(summary.CreatedAt))
+	fmt.Fprintf(w, "Updated:\t%s\n", formatDate(summary.UpdatedAt))
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out)
+
+	w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tSTEP\tSTATE\tREASON\tCONTEXT")
+	for _, eventLog := range eventLogs {
+		step := eventLog.Step
+		if eventLog.Branch != "" {
+			step += "/" + eventLog.Branch
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", formatDate(eventLog.CreatedAt), step, eventLog.State, orDash(eventLog.Reason), orDash(string(eventLog.Context)))
+	}
+
+	return w.Flush()
+}
+
+func statsCmd(ctx context.Context, store journal.Storage, args []string, out io.Writer) error {
+	if len(args) != 0 {
+		return errors.New("usage: gosaga stats")
+	}
+
+	summaries, _, err := gosaga.NewSagaExecutionCoordinator(store).ListSagas(ctx, model.SagaQuery{})
+	if err != nil {
+		return err
+	}
+
+	statuses := map[string]int{}
+	outcomes := map[string]int{}
+	definitions := map[string]int{}
+	for _, summary := range summaries {
+		statuses[summary.Status]++
+		definitions[definition(summary.SagaType, summary.SagaVersion)]++
+		if summary.Outcome != "" {
+			outcomes[summary.Outcome]++
+		}
+	}
+
+	fmt.Fprintf(out, "Sagas: %d\n", len(summaries))
+
+	for _, section := range []struct {
+		title  string
+		counts map[string]int
+	}{
+		{"STATUS", statuses},
+		{"OUTCOME", outcomes},
+		{"DEFINITION", definitions},
+	} {
+		fmt.Fprintln(out)
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%s\tCOUNT\n", section.title)
+		for _, key := range sortedKeys(section.counts) {
+			fmt.Fprintf(w, "%s\t%d\n", key, section.counts[key])
+		}
+
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportCmd(ctx context.Context, store journal.Storage, args []string, out io.Writer) error {
+	var query model.SagaQuery
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.StringVar(&query.Status, "status", "", "export only the sagas with the given status: running, aborted, stuck or done")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	sagas, _, err := store.ListSagas(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to list the sagas: %s", err)
+	}
+
+	encoder := json.NewEncoder(out)
+	for _, saga := range sagas {
+		for _, eventLog := range saga.EventLogs {
+			err = encoder.Encode(eventLog)
+			if err != nil {
+				return fmt.Errorf("failed to export the saga %q: %s", saga.ID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func compactCmd(ctx context.Context, store journal.Storage, args []string, out io.Writer) error {
+	if len(args) != 0 {
+		return errors.New("usage: gosaga compact")
+	}
+
+	compacter, ok := store.(interface {
+		Compact(ctx context.Context) error
+	})
+	if !ok {
+		return errors.New("the store doesn't support the compaction")
+	}
+
+	err := compacter.Compact(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, "the finished sagas have been removed")
+
+	return nil
+}
+
+func resumeCmd(ctx context.Context, store journal.Storage, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: gosaga resume <sagaID>")
+	}
+
+	j, step, arg, err := restoreStuckSaga(ctx, store, args[0])
+	if err != nil {
+		return err
+	}
+
+	err = j.MarkSubRequestAsResumed(ctx, args[0], step, arg)
+	if err != nil {
+		return fmt.Errorf("failed to mark the subrequest %q as resumed: %s", step, err)
+	}
+
+	fmt.Fprintf(out, "saga %q resumed, the %q compensation will be retried at the next application restart\n", args[0], step)
+
+	return nil
+}
+
+func abortCmd(ctx context.Context, store journal.Storage, args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return errors.New("usage: gosaga abort <sagaID>")
+	}
+
+	j, _, _, err := restoreStuckSaga(ctx, store, args[0])
+	if err != nil {
+		return err
+	}
+
+	err = j.MarkSagaAsForcedDone(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("failed to force the saga as done: %s", err)
+	}
+
+	fmt.Fprintf(out, "saga %q marked as done, its remaining compensations will not be run\n", args[0])
+
+	return nil
+}
+
+// restoreStuckSaga load the unfinished sagas into a journal and return the
+// Sub-Request blocking the given stuck saga with its arguments.
+func restoreStuckSaga(ctx context.Context, store journal.Storage, sagaID string) (*journal.Journal, string, json.RawMessage, error) {
+	j := journal.New(store)
+
+	_, err := j.Restore(ctx)
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	status := j.GetSagaStatus(sagaID)
+	if status == "" {
+		return nil, "", nil, fmt.Errorf("%w: %q is not an unfinished saga", gosaga.ErrSagaNotFound, sagaID)
+	}
+
+	if status != "stuck" {
+		return nil, "", nil, fmt.Errorf("expected saga %q to be \"stuck\", have %q", sagaID, status)
+	}
+
+	step, _, arg := j.GetSagaLastEventLog(sagaID)
+
+	return j, step, arg, nil
+}
+
+func definition(sagaType string, sagaVersion int) string {
+	if sagaType == "" {
+		return "-"
+	}
+
+	return sagaType + " v" + strconv.Itoa(sagaVersion)
+}
+
+func formatDate(date time.Time) string {
+	if date.IsZero() {
+		return "-"
+	}
+
+	return date.Format(time.RFC3339Nano)
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+func sortedKeys(counts map[string]int) []string {
+	res := make([]string, 0, len(counts))
+	for key := range counts {
+		res = append(res, key)
+	}
+
+	sort.Strings(res)
+
+	return res
+}
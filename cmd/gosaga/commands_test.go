@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSEC return a SEC with a "payment" definition. Its "step1"
+// Compensation succeed only if fixed is true.
+func newTestSEC(store *storage.File, fixed bool) *gosaga.SEC {
+	success := func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result { return gosaga.Success(sagaCtx) }
+
+	return gosaga.NewSagaExecutionCoordinator(store).
+		RegisterSaga(gosaga.NewSagaDefinition("payment", 2).
+			AppendNewSubRequest("step1", success, func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+				if !fixed {
+					return gosaga.Failure(errors.New("some-compensation-error"), sagaCtx)
+				}
+
+				return gosaga.Success(sagaCtx)
+			}, gosaga.WithCompensationRetry(gosaga.RetryPolicy{MaxAttempts: 1})).
+			AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+				if string(sagaCtx) == `{"fail":true}` {
+					return gosaga.Failure(errors.New("some-error"), sagaCtx)
+				}
+
+				return gosaga.Success(sagaCtx)
+			}, nil))
+}
+
+// newTestStore create a file store with a committed saga and a saga stuck on
+// the "step1" Compensation.
+func newTestStore(t *testing.T) (string, string, string) {
+	path := filepath.Join(t.TempDir(), "saga.log")
+
+	store, err := storage.NewFile(path, storage.SyncAlways)
+	require.NoError(t, err)
+	defer store.Close()
+
+	sec := newTestSEC(store, false)
+
+	committed, err := sec.SubmitNamed(context.Background(), "payment", json.RawMessage(`{"fail":false}`))
+	require.NoError(t, err)
+
+	_, err = sec.Wait(context.Background(), committed)
+	require.NoError(t, err)
+
+	stuck, err := sec.SubmitNamed(context.Background(), "payment", json.RawMessage(`{"fail":true}`))
+	require.NoError(t, err)
+
+	_, err = sec.Wait(context.Background(), stuck)
+	require.Error(t, err)
+
+	return path, committed, stuck
+}
+
+func Test_list(t *testing.T) {
+	path, committed, stuck := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "list")
+	require.Equal(t, 0, code, stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"ID", "STATUS", "OUTCOME", "DEFINITION", "STEP", "STATE", "ATTEMPTS", "CREATED"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{committed, "done", "committed", "payment", "v2", "step2", "done", "1"}, strings.Fields(lines[1])[:8])
+	assert.Equal(t, []string{stuck, "stuck", "-", "payment", "v2", "step1", "stuck", "1"}, strings.Fields(lines[2])[:8])
+}
+
+func Test_list_with_filters_and_pagination(t *testing.T) {
+	path, _, stuck := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "list", "-status", "stuck")
+	require.Equal(t, 0, code, stderr)
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[1], stuck))
+
+	code, stdout, stderr = runGosaga("-file", path, "list", "-limit", "1")
+	require.Equal(t, 0, code, stderr)
+
+	_, cursor, found := strings.Cut(stdout, "next page: -cursor ")
+	require.True(t, found)
+
+	code, stdout, stderr = runGosaga("-file", path, "list", "-limit", "1", "-cursor", strings.TrimSpace(cursor))
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, stuck)
+	assert.NotContains(t, stdout, "next page")
+}
+
+func Test_show(t *testing.T) {
+	path, _, stuck := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "show", stuck)
+	require.Equal(t, 0, code, stderr)
+
+	assert.Contains(t, stdout, "Status:        stuck\n")
+	assert.Contains(t, stdout, "Definition:    payment v2\n")
+	assert.Contains(t, stdout, "Current step:  step1 stuck (1 attempts)\n")
+	assert.Contains(t, stdout, "Reason:        some-error\n")
+
+	timeline := []string{}
+	_, events, _ := strings.Cut(stdout, "\n\n")
+	for _, line := range strings.Split(strings.TrimSpace(events), "\n")[1:] {
+		fields := strings.Fields(line)
+		timeline = append(timeline, fields[1]+":"+fields[2])
+	}
+
+	assert.Equal(t, []string{
+		"_init:done", "step1:running", "step1:done", "step2:running", "step2:aborted",
+		"step2:running", "step2:done", "step1:running", "step1:stuck",
+	}, timeline)
+}
+
+func Test_show_with_an_unknown_saga(t *testing.T) {
+	path, _, _ := newTestStore(t)
+
+	code, _, stderr := runGosaga("-file", path, "show", "some-unknown-id")
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "gosaga: saga not found: \"some-unknown-id\"\n", stderr)
+}
+
+func Test_stats(t *testing.T) {
+	path, _, _ := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "stats")
+	require.Equal(t, 0, code, stderr)
+
+	assert.Equal(t, `Sagas: 2
+
+STATUS  COUNT
+done    1
+stuck   1
+
+OUTCOME    COUNT
+committed  1
+
+DEFINITION  COUNT
+payment v2  2
+`, stdout)
+}
+
+func Test_export(t *testing.T) {
+	path, committed, stuck := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "export")
+	require.Equal(t, 0, code, stderr)
+
+	counts := map[string]int{}
+	scanner := bufio.NewScanner(strings.NewReader(stdout))
+	for scanner.Scan() {
+		var eventLog model.EventLog
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &eventLog))
+
+		counts[eventLog.SagaID]++
+	}
+
+	assert.Equal(t, map[string]int{committed: 6, stuck: 9}, counts)
+
+	code, stdout, stderr = runGosaga("-file", path, "export", "-status", "done")
+	require.Equal(t, 0, code, stderr)
+	assert.Len(t, strings.Split(strings.TrimSpace(stdout), "\n"), 6)
+}
+
+func Test_compact(t *testing.T) {
+	path, committed, stuck := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "compact")
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "the finished sagas have been removed\n", stdout)
+
+	code, stdout, stderr = runGosaga("-file", path, "list")
+	require.Equal(t, 0, code, stderr)
+	assert.NotContains(t, stdout, committed)
+	assert.Contains(t, stdout, stuck)
+}
+
+func Test_resume(t *testing.T) {
+	path, _, stuck := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "resume", stuck)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "saga \""+stuck+"\" resumed, the \"step1\" compensation will be retried at the next application restart\n", stdout)
+
+	// The application retries the compensation at its restart.
+	store, err := storage.NewFile(path, storage.SyncAlways)
+	require.NoError(t, err)
+	defer store.Close()
+
+	sec := newTestSEC(store, true)
+	require.NoError(t, sec.Recover(context.Background()))
+
+	summary, err := sec.GetSagaSummary(context.Background(), stuck)
+	require.NoError(t, err)
+	assert.Equal(t, "compensated", summary.Outcome)
+}
+
+func Test_abort(t *testing.T) {
+	path, _, stuck := newTestStore(t)
+
+	code, stdout, stderr := runGosaga("-file", path, "abort", stuck)
+	require.Equal(t, 0, code, stderr)
+	assert.Equal(t, "saga \""+stuck+"\" marked as done, its remaining compensations will not be run\n", stdout)
+
+	code, stdout, stderr = runGosaga("-file", path, "show", stuck)
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Outcome:       forced\n")
+}
+
+func Test_resume_with_a_saga_not_stuck(t *testing.T) {
+	path, committed, _ := newTestStore(t)
+
+	code, _, stderr := runGosaga("-file", path, "resume", committed)
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "gosaga: saga not found: \""+committed+"\" is not an unfinished saga\n", stderr)
+}
@@ -0,0 +1,214 @@
+// Command gosaga inspect and repair a saga store.
+//
+// Usage:
+//
+//	gosaga -file saga.log list -status stuck
+//	gosaga -bolt saga.db show <sagaID>
+//	gosaga -sql sqlite3 -dsn saga.sqlite stats
+//
+// The commands are:
+//
+//	list     list the sagas with their summary
+//	show     show a saga summary and its eventlogs timeline
+//	stats    count the sagas by status, outcome and definition
+//	export   export all the eventlogs as JSON lines
+//	compact  remove the eventlogs of the finished sagas
+//	resume   retry the failing Compensation of a stuck saga
+//	abort    mark a stuck saga as done without running its remaining Compensations
+//
+// The resume and abort commands only write into the store, the sagas are run
+// by the application at its next restart. The file stores are locked by the
+// application: the compact, resume and abort commands are refused while it is
+// running, the other commands read the file without modifying it. The bolt
+// stores are locked by the application too but bbolt doesn't allow to read
+// them in the same time, all the commands are refused while it is running.
+// The list, show, stats and export commands open them in read-only mode.
+//
+// The SQL stores can't be locked: the resume and abort commands are refused
+// for them, the stuck sagas must be operated by the application SEC. They must
+// have been migrated by the application. Only the "sqlite3" driver is built
+// in.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/storage"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// command is a gosaga sub-command. It writes its output into out.
+type command func(ctx context.Context, store journal.Storage, args []string, out io.Writer) error
+
+var commands = map[string]command{
+	"list":    listCmd,
+	"show":    showCmd,
+	"stats":   statsCmd,
+	"export":  exportCmd,
+	"compact": compactCmd,
+	"resume":  resumeCmd,
+	"abort":   abortCmd,
+}
+
+// readOnlyCommands are the commands which doesn't write into the store.
+var readOnlyCommands = map[string]bool{
+	"list":   true,
+	"show":   true,
+	"stats":  true,
+	"export": true,
+}
+
+// unlockedCommands are the commands changing the sagas state, refused on the
+// stores which can't be locked against a running application.
+var unlockedCommands = map[string]bool{
+	"resume": true,
+	"abort":  true,
+}
+
+// run execute the command given into args and return the exit code.
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("gosaga", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: gosaga (-file path | -bolt path | -sql driver -dsn dsn) <list|show|stats|export|compact|resume|abort> [args]")
+		flags.PrintDefaults()
+	}
+
+	var opts storeOptions
+	flags.StringVar(&opts.file, "file", "", "path of an append-only file store")
+	flags.StringVar(&opts.bolt, "bolt", "", "path of a bbolt store")
+	flags.StringVar(&opts.driver, "sql", "", "database/sql driver of a SQL store")
+	flags.StringVar(&opts.dsn, "dsn", "", "data source name of the SQL store")
+	flags.StringVar(&opts.dialect, "dialect", "sqlite", "SQL dialect: sqlite or postgres")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "gosaga: unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	// The application keeps the stuck sagas into its memory, it would never
+	// see the changes.
+	if opts.driver != "" && unlockedCommands[flags.Arg(0)] {
+		fmt.Fprintf(stderr, "gosaga: the %s command is refused on the SQL stores as they can be used by a running application, use its SEC or its admin API\n", flags.Arg(0))
+		return 1
+	}
+
+	store, closeStore, err := openStore(opts, readOnlyCommands[flags.Arg(0)])
+	if err != nil {
+		fmt.Fprintf(stderr, "gosaga: %s\n", err)
+		return 1
+	}
+	defer closeStore()
+
+	err = cmd(ctx, store, flags.Args()[1:], stdout)
+	if errors.Is(err, flag.ErrHelp) {
+		return 2
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "gosaga: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+type storeOptions struct {
+	file    string
+	bolt    string
+	driver  string
+	dsn     string
+	dialect string
+}
+
+// openStore open the store selected by the options and return the function
+// closing it. A file store is opened without lock nor modification if
+// readOnly is true.
+func openStore(opts storeOptions, readOnly bool) (journal.Storage, func() error, error) {
+	selected := 0
+	for _, opt := range []string{opts.file, opts.bolt, opts.driver} {
+		if opt != "" {
+			selected++
+		}
+	}
+
+	if selected != 1 {
+		return nil, nil, errors.New("exactly one of -file, -bolt and -sql is required")
+	}
+
+	switch {
+	case opts.file != "" && readOnly:
+		file, err := storage.NewReadOnlyFile(opts.file)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return file, file.Close, nil
+	case opts.file != "":
+		file, err := storage.NewFile(opts.file, storage.SyncAlways)
+		if errors.Is(err, storage.ErrFileLocked) {
+			return nil, nil, fmt.Errorf("the file %q is used by a running application, stop it first", opts.file)
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return file, file.Close, nil
+	case opts.bolt != "":
+		open := storage.NewBolt
+		if readOnly {
+			open = storage.NewReadOnlyBolt
+		}
+
+		bolt, err := open(opts.bolt)
+		if errors.Is(err, storage.ErrFileLocked) {
+			return nil, nil, fmt.Errorf("the file %q is used by a running application, stop it first", opts.bolt)
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return bolt, bolt.Close, nil
+	default:
+		dialect := storage.SQLite
+		switch opts.dialect {
+		case "sqlite":
+		case "postgres":
+			dialect = storage.Postgres
+		default:
+			return nil, nil, fmt.Errorf("unknown SQL dialect %q", opts.dialect)
+		}
+
+		db, err := sql.Open(opts.driver, opts.dsn)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open the database: %s", err)
+		}
+
+		return storage.NewSQL(db, dialect), db.Close, nil
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runGosaga run the command line and return its exit code, stdout and stderr.
+func runGosaga(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+
+	code := run(context.Background(), args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func Test_run_without_command(t *testing.T) {
+	code, _, stderr := runGosaga("-file", filepath.Join(t.TempDir(), "saga.log"))
+
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, "usage: gosaga")
+}
+
+func Test_run_with_an_unknown_command(t *testing.T) {
+	code, _, stderr := runGosaga("-file", filepath.Join(t.TempDir(), "saga.log"), "foo")
+
+	assert.Equal(t, 2, code)
+	assert.Contains(t, stderr, `gosaga: unknown command "foo"`)
+}
+
+func Test_run_without_store(t *testing.T) {
+	code, _, stderr := runGosaga("list")
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "gosaga: exactly one of -file, -bolt and -sql is required\n", stderr)
+}
+
+func Test_run_with_several_stores(t *testing.T) {
+	code, _, stderr := runGosaga("-file", "saga.log", "-bolt", "saga.db", "list")
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "gosaga: exactly one of -file, -bolt and -sql is required\n", stderr)
+}
+
+func Test_run_with_an_unknown_dialect(t *testing.T) {
+	code, _, stderr := runGosaga("-sql", "sqlite3", "-dsn", ":memory:", "-dialect", "oracle", "list")
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "gosaga: unknown SQL dialect \"oracle\"\n", stderr)
+}
+
+func Test_run_with_a_command_error(t *testing.T) {
+	path, _, _ := newTestStore(t)
+
+	code, _, stderr := runGosaga("-file", path, "show")
+
+	assert.Equal(t, 1, code)
+	assert.Equal(t, "gosaga: usage: gosaga show <sagaID>\n", stderr)
+}
+
+func Test_run_with_a_sql_store(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saga.sqlite")
+
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	require.NoError(t, storage.NewSQL(db, storage.SQLite).Migrate(context.Background()))
+	require.NoError(t, db.Close())
+
+	code, stdout, stderr := runGosaga("-sql", "sqlite3", "-dsn", path, "stats")
+
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Sagas: 0")
+}
+
+func Test_run_with_a_bolt_store_used_by_an_application(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saga.db")
+
+	store, err := storage.NewBolt(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	for _, cmd := range []string{"list", "compact"} {
+		code, _, stderr := runGosaga("-bolt", path, cmd)
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "gosaga: the file \""+path+"\" is used by a running application, stop it first\n", stderr)
+	}
+}
+
+func Test_run_with_a_bolt_store(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saga.db")
+
+	store, err := storage.NewBolt(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Close())
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	code, stdout, stderr := runGosaga("-bolt", path, "stats")
+	assert.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, "Sagas: 0")
+
+	// The database is not modified.
+	res, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, raw, res)
+}
+
+func Test_run_with_a_sql_store_and_a_saga_command(t *testing.T) {
+	for _, cmd := range []string{"resume", "abort"} {
+		code, _, stderr := runGosaga("-sql", "sqlite3", "-dsn", ":memory:", cmd, "some-saga-id")
+
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "gosaga: the "+cmd+" command is refused on the SQL stores as they can be used by a running application, use its SEC or its admin API\n", stderr)
+	}
+}
+
+func Test_run_with_a_missing_bolt_store(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saga.db")
+
+	code, _, stderr := runGosaga("-bolt", path, "stats")
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "gosaga: failed to open the database: ")
+
+	// The read-only commands don't create the database.
+	_, err := os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func Test_run_with_a_missing_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saga.log")
+
+	code, _, stderr := runGosaga("-file", path, "list")
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "gosaga: failed to open the file: ")
+}
+
+func Test_run_with_a_file_used_by_an_application(t *testing.T) {
+	path, _, stuck := newTestStore(t)
+
+	store, err := storage.NewFile(path, storage.SyncAlways)
+	require.NoError(t, err)
+	defer store.Close()
+
+	code, stdout, stderr := runGosaga("-file", path, "list")
+	require.Equal(t, 0, code, stderr)
+	assert.Contains(t, stdout, stuck)
+
+	for _, cmd := range []string{"compact", "resume", "abort"} {
+		code, _, stderr = runGosaga("-file", path, cmd, stuck)
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "gosaga: the file \""+path+"\" is used by a running application, stop it first\n", stderr)
+	}
+}
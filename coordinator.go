@@ -5,22 +5,47 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"sync"
+	"time"
 
 	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/model"
 )
 
 // Journal is an interface used to save all the SEC actions.
 //
 // It allow to restore its state in case of failure.
 type Journal interface {
-	CreateNewSaga(ctx context.Context, sagaCtx json.RawMessage) (string, error)
+	Restore(ctx context.Context) ([]string, error)
+	CreateNewSaga(ctx context.Context, sagaType string, sagaVersion int, sagaCtx json.RawMessage, metadata map[string]string) (string, error)
+	CreateSaga(ctx context.Context, sagaID string, sagaType string, sagaVersion int, sagaCtx json.RawMessage, metadata map[string]string) error
 	MarkSagaAsDone(ctx context.Context, sagaID string) error
 	DeleteSaga(ctx context.Context, sagaID string)
 	MarkSubRequestAsRunning(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error
 	MarkSubRequestAsDone(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) error
-	MarkSubRequestAsAborted(ctx context.Context, sagaID string, subRequestID string, reason json.RawMessage) error
+	MarkSubRequestAsPivoted(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) error
+	MarkSubRequestAsFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error
+	MarkSubRequestAsAborted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, reason string) error
+	MarkSubRequestAsStuck(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error
+	MarkSubRequestAsResumed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error
+	MarkSubRequestAsSkipped(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error
+	MarkSagaAsForcedDone(ctx context.Context, sagaID string) error
+	RequestSagaCancel(ctx context.Context, sagaID string, reason string) error
+	GetSagaCancelRequest(sagaID string) (string, bool)
 	GetSagaStatus(sagaID string) string
+	GetSagasByStatus(status string) []string
 	GetSagaLastEventLog(sagaID string) (string, string, json.RawMessage)
+	GetSagaMetadata(sagaID string) map[string]string
+	GetSagaDefinition(sagaID string) (string, int)
+	GetSagaCreatedAt(sagaID string) time.Time
+	GetSagaRecovery(sagaID string) string
+	GetSagaOutputs(sagaID string) (json.RawMessage, map[string]json.RawMessage)
+	GetSubRequestAttempts(sagaID string) (int, time.Time)
+	MarkBranchState(ctx context.Context, sagaID string, stageID string, branchID string, state string, sagaCtx json.RawMessage) error
+	GetBranchStates(sagaID string, stageID string) map[string]model.EventLog
+	GetSagaHistory(ctx context.Context, sagaID string) ([]model.EventLog, error)
+	ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error)
 }
 
 // SEC means Saga Execution Coordinator.
@@ -29,84 +54,335 @@ type Journal interface {
 // - Interpret and write the Saga Journal.
 // - Apply the Saga Sub-Requests
 // - Apply the Saga Compensating Sub-Requests when necessary
+//
+// Once all the Sub-Requests are appended, it is safe for concurrent use.
 type SEC struct {
 	subRequestDefs subRequestDefs
+	sagaTimeout    time.Duration
+	definitions    map[definitionKey]*SagaDefinition
 	journal        Journal
+
+	// workers limit the number of submitted sagas run concurrently.
+	workers chan struct{}
+
+	// outcomeRetention is the duration the Outcomes not waited are kept.
+	outcomeRetention time.Duration
+
+	logger    *slog.Logger
+	observers observers
+	tracer    Tracer
+
+	// stopGracePeriod is the delay after which an Action still running once
+	// its context is canceled is reported in the logs.
+	stopGracePeriod time.Duration
+
+	mutex    sync.Mutex
+	outcomes map[string]*pendingOutcome
+	running  map[string]*pendingOutcome
+	failures map[string]*SagaAbortedError
+	cancels  map[string]*sagaCancel
 }
 
 // NewSagaExecutionCoordinator instantiate a new Saga Execution Coordinator (SEC).
 func NewSagaExecutionCoordinator(storage journal.Storage) *SEC {
 	return &SEC{
-		subRequestDefs: []subRequestDef{},
-		journal:        journal.New(storage),
+		subRequestDefs:   []subRequestDef{},
+		journal:          journal.New(storage),
+		workers:          make(chan struct{}, defaultWorkers),
+		logger:           slog.New(noopHandler{}),
+		stopGracePeriod:  defaultStopGracePeriod,
+		outcomeRetention: defaultOutcomeRetention,
 	}
 }
 
 // AppendNewSubRequest append a new SubRequest to the Saga.
-func (t *SEC) AppendNewSubRequest(name string, action Action, compensation Action) *SEC {
-	t.subRequestDefs = append(t.subRequestDefs, subRequestDef{
-		SubRequestID: name,
-		Action:       action,
-		Compensation: compensation,
-	})
+//
+// It panics if the Sub-Request follows the pivot with a Compensation, see
+// AsPivot.
+func (t *SEC) AppendNewSubRequest(name string, action Action, compensation Action, opts ...SubRequestOption) *SEC {
+	t.subRequestDefs = t.subRequestDefs.append(newSubRequestDef(name, action, compensation, opts))
 
 	return t
 }
 
 // StartSaga create a new Saga saga with the given sagaCtx and run it.
+//
+// If the saga is aborted, a *SagaAbortedError is returned once the saga is
+// compensated.
 func (t *SEC) StartSaga(ctx context.Context, sagaCtx json.RawMessage) error {
-	sagaID, err := t.journal.CreateNewSaga(ctx, sagaCtx)
+	_, err := t.startNewSaga(ctx, "", "", 0, sagaCtx)
+
+	return err
+}
+
+// startNewSaga create a new saga and run it. The saga ID is generated if
+// sagaID is empty.
+func (t *SEC) startNewSaga(ctx context.Context, sagaID string, sagaType string, sagaVersion int, sagaCtx json.RawMessage) (*Outcome, error) {
+	metadata := map[string]string{}
+	ctx, end := t.startSaga(ctx, "", metadata)
+
+	sagaID, err := t.createSaga(ctx, sagaID, sagaType, sagaVersion, sagaCtx, metadata)
+	if err != nil {
+		end(nil, err)
+		return nil, err
+	}
+
+	outcome, err := t.runSaga(ctx, sagaID)
+	end(outcome, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return outcome, outcome.Err
+}
+
+// createSaga save a new saga into the journal and return its ID. The saga ID
+// is generated if sagaID is empty.
+func (t *SEC) createSaga(ctx context.Context, sagaID string, sagaType string, sagaVersion int, sagaCtx json.RawMessage, metadata map[string]string) (string, error) {
+	var err error
+	if sagaID == "" {
+		sagaID, err = t.journal.CreateNewSaga(ctx, sagaType, sagaVersion, sagaCtx, metadata)
+	} else {
+		err = t.journal.CreateSaga(ctx, sagaID, sagaType, sagaVersion, sagaCtx, metadata)
+	}
+
+	if errors.Is(err, ErrSagaAlreadyExists) {
+		return "", err
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to create a new saga: %s", err)
+	}
+
+	t.observers.SagaCreated(ctx, sagaID, sagaCtx)
+
+	return sagaID, nil
+}
+
+// Recover reload all the unfinished sagas from the storage and run them until
+// their end.
+//
+// It should be called once at startup, before starting any new saga. A
+// sub-request action interrupted by the crash is considered as aborted and the
+// saga is compensated.
+//
+// The sagas are run concurrently into the worker pool, see WithWorkers. It
+// returns once all of them are finished or stuck, with the errors of the sagas
+// failing to recover joined. If ctx is done before, the ctx error is returned
+// and the sagas still running continue in background.
+func (t *SEC) Recover(ctx context.Context) error {
+	sagaIDs, err := t.journal.Restore(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to restore the journal: %s", err)
+	}
+
+	// The sagas must outlive the Recover call.
+	runCtx := context.WithoutCancel(ctx)
+
+	errs := make([]error, len(sagaIDs))
+	wg := new(sync.WaitGroup)
+	for i, sagaID := range sagaIDs {
+		wg.Add(1)
+		go func(i int, sagaID string) {
+			defer wg.Done()
+
+			t.workers <- struct{}{}
+			defer func() { <-t.workers }()
+
+			err := t.recoverSaga(runCtx, sagaID)
+			if err != nil {
+				t.logSaga(runCtx, slog.LevelError, "failed to recover the saga", sagaID, "error", err)
+				errs[i] = fmt.Errorf("failed to recover the saga %q: %s", sagaID, err)
+			}
+		}(i, sagaID)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("interrupted while waiting the recovered sagas: %s", ctx.Err())
+	}
+
+	return errors.Join(errs...)
+}
+
+func (t *SEC) recoverSaga(ctx context.Context, sagaID string) error {
+	def, err := t.getDefinition(sagaID)
 	if err != nil {
-		return fmt.Errorf("failed to create a new saga: %s", err)
+		return err
 	}
 
-	return t.runSaga(ctx, sagaID)
+	step, state, arg := t.journal.GetSagaLastEventLog(sagaID)
+
+	// There is no way to know if an interrupted action have been applied or
+	// not so it is aborted and compensated, or retried once the pivot is
+	// done. Interrupted compensations are simply run again as they are
+	// idempotent.
+	switch {
+	case t.journal.GetSagaStatus(sagaID) != "running":
+	case state != "running":
+		// A cancellation requested before the restart is applied to the
+		// next Action.
+		if reason, requested := t.journal.GetSagaCancelRequest(sagaID); requested {
+			t.cancelSaga(sagaID, reason)
+		}
+	case t.isForward(sagaID, def.subRequestDefs):
+		err := t.journal.MarkSubRequestAsFailed(ctx, sagaID, step, arg)
+		if err != nil {
+			return fmt.Errorf("failed to mark the interrupted subrequest %q as failed: %s", step, err)
+		}
+
+		t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, step, PhaseAction, "retry", errInterrupted)
+		t.observers.SubRequestFailed(ctx, sagaID, step, arg, errInterrupted)
+	default:
+		err := t.journal.MarkSubRequestAsAborted(ctx, sagaID, step, arg, errInterrupted.Error())
+		if err != nil {
+			return fmt.Errorf("failed to mark the interrupted subrequest %q as aborted: %s", step, err)
+		}
+
+		t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, step, PhaseAction, "aborted", errInterrupted)
+		t.observers.SubRequestFailed(ctx, sagaID, step, arg, errInterrupted)
+		t.recordActionFailure(sagaID, step, errInterrupted)
+	}
+
+	_, err = t.continueSaga(ctx, sagaID)
+
+	return err
 }
 
-// RunSaga execute the given Saga synchronously.
-func (t *SEC) runSaga(ctx context.Context, sagaID string) error {
+// RunSaga execute the given Saga synchronously. The duplicated
+// StartSagaWithKey calls are notified of its end.
+func (t *SEC) runSaga(ctx context.Context, sagaID string) (*Outcome, error) {
+	pending := t.markRunning(sagaID)
+
+	outcome, err := t.execSaga(ctx, sagaID)
+	t.markFinished(sagaID, pending, outcome, err)
+
+	return outcome, err
+}
+
+// execSaga run the given saga until it is done or stuck.
+func (t *SEC) execSaga(ctx context.Context, sagaID string) (*Outcome, error) {
+	outcome := &Outcome{SagaID: sagaID, Status: "committed"}
+
+	def, err := t.getDefinition(sagaID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = withSagaState(ctx, t.journal, sagaID)
+
+	// Only the Actions are stopped by the saga timeout, the Compensations
+	// must be able to run once it is reached.
+	actionsCtx, cancel := t.withSagaDeadline(ctx, sagaID, def)
+	defer cancel()
+
+	actionsCtx, stop := t.withCancel(actionsCtx, sagaID)
+	defer stop()
+
 	for {
 		switch t.journal.GetSagaStatus(sagaID) {
 		case "running":
-			err := t.execNextSubRequestAction(ctx, sagaID)
+			// Once the pivot is done the saga must be finished whatever
+			// its timeout.
+			actionCtx := actionsCtx
+			if t.isForward(sagaID, def.subRequestDefs) {
+				actionCtx = ctx
+			}
+
+			err := t.execNextSubRequestAction(actionCtx, sagaID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 		case "done":
-			fmt.Println("delete saga")
+			_, _, outcome.Context = t.journal.GetSagaLastEventLog(sagaID)
+			if outcome.Status == "compensated" {
+				outcome.Err = t.abortedError(sagaID, false)
+			}
+
+			t.logSaga(ctx, slog.LevelInfo, "saga finished", sagaID, outcome.Status, nil)
+			if outcome.Err != nil {
+				t.observers.SagaCompensated(ctx, sagaID, outcome.Context, outcome.Err)
+			} else {
+				t.observers.SagaCommitted(ctx, sagaID, outcome.Context)
+			}
+
 			t.journal.DeleteSaga(ctx, sagaID)
-			return nil
+			t.forgetFailure(sagaID)
+			return outcome, nil
+
+		case "stuck":
+			// Keep the saga into the journal for a manual intervention.
+			_, _, outcome.Context = t.journal.GetSagaLastEventLog(sagaID)
+			outcome.Status = "stuck"
+			outcome.Err = t.abortedError(sagaID, true)
+			t.logSaga(ctx, slog.LevelError, "saga stuck", sagaID, outcome.Status, outcome.Err)
+			t.observers.SagaStuck(ctx, sagaID, outcome.Context, outcome.Err)
+			return outcome, nil
 
 		case "aborted":
+			outcome.Status = "compensated"
+
 			err := t.execNextSubRequestCompensation(ctx, sagaID)
 			if err != nil {
-				return err
+				return nil, err
 			}
 
 		default:
-			return fmt.Errorf("unknown status %q for saga %q", t.journal.GetSagaStatus(sagaID), sagaID)
+			return nil, fmt.Errorf("unknown status %q for saga %q", t.journal.GetSagaStatus(sagaID), sagaID)
 		}
 
 	}
 }
 
 func (t *SEC) execNextSubRequestAction(ctx context.Context, sagaID string) error {
+	var (
+		subReq *subRequestDef
+		err    error
+	)
+
+	def, err := t.getDefinition(sagaID)
+	if err != nil {
+		return err
+	}
+	defs := def.subRequestDefs
+	forward := t.isForward(sagaID, defs)
+
 	step, state, arg := t.journal.GetSagaLastEventLog(sagaID)
 	if state == "running" {
 		// The previous subRequest is not finished, abort.
 		return errors.New("the previous sub-request action/compensation is not finished")
 	}
-	fmt.Printf("step: %s / %s\n", step, state)
 
-	// Select the next subRequest.
-	subReq, err := t.subRequestDefs.GetSubRequestAfter(step)
-	if err != nil {
-		return fmt.Errorf("failed to select the next sub-request: %s", err)
+	if state == "failed" {
+		// Retry the failed subRequest.
+		subReq = defs.GetSubRequestDef(step)
+		if subReq == nil {
+			return fmt.Errorf("failed to select the next sub-request: unknown sub-request id %q", step)
+		}
+
+		// Once the saga timeout is reached or the saga is cancelled the
+		// attempt fails immediately.
+		err = t.waitBeforeRetry(ctx, sagaID, actionRetry(subReq, forward))
+		if err != nil && !isStopped(ctx) {
+			return err
+		}
+	} else {
+		// Select the next subRequest.
+		subReq, err = defs.GetSubRequestAfter(step)
+		if err != nil {
+			return fmt.Errorf("failed to select the next sub-request: %s", err)
+		}
 	}
 
 	if subReq == nil {
-		fmt.Println("mark saga as done")
 		err = t.journal.MarkSagaAsDone(ctx, sagaID)
 		if err != nil {
 			return fmt.Errorf("failed to mark the saga %q as done: %s", sagaID, err)
@@ -115,24 +391,48 @@ func (t *SEC) execNextSubRequestAction(ctx context.Context, sagaID string) error
 		return nil
 	}
 
-	fmt.Printf("exec: %s\n", subReq.SubRequestID)
+	t.logSubRequest(ctx, slog.LevelDebug, "run sub-request", sagaID, subReq.SubRequestID, PhaseAction, "", nil)
+	t.observers.SubRequestStarted(ctx, sagaID, subReq.SubRequestID, arg)
 	err = t.journal.MarkSubRequestAsRunning(ctx, sagaID, subReq.SubRequestID, arg)
 	if err != nil {
 		return fmt.Errorf("failed to mark the subrequest %q for saga %q as running: %s", subReq.SubRequestID, sagaID, err)
 	}
 
-	result := subReq.Action(ctx, arg)
+	actionCtx, end := t.startStep(ctx, sagaID, subReq.SubRequestID, PhaseAction)
+	result, err := t.execAction(actionCtx, sagaID, subReq, arg)
+	if err != nil {
+		end(Failure(err, arg))
+		return err
+	}
+	end(result)
 	if result.IsSuccess() {
-		err = t.journal.MarkSubRequestAsDone(ctx, sagaID, subReq.SubRequestID, result.Context())
+		t.logSubRequest(ctx, slog.LevelInfo, "sub-request succeeded", sagaID, subReq.SubRequestID, PhaseAction, "success", nil)
+		t.observers.SubRequestSucceeded(ctx, sagaID, subReq.SubRequestID, result.Context())
+		if subReq.Pivot {
+			err = t.journal.MarkSubRequestAsPivoted(ctx, sagaID, subReq.SubRequestID, result.Context())
+		} else {
+			err = t.journal.MarkSubRequestAsDone(ctx, sagaID, subReq.SubRequestID, result.Context())
+		}
 		if err != nil {
 			return fmt.Errorf("failed to mark the subrequest %q for saga %q as done: %s", subReq.SubRequestID, sagaID, err)
 		}
+	} else if (forward || !isStopError(resultErr(result))) && t.canRetry(sagaID, actionRetry(subReq, forward)) {
+		t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, subReq.SubRequestID, PhaseAction, "retry", resultErr(result))
+		t.observers.SubRequestFailed(ctx, sagaID, subReq.SubRequestID, result.Context(), resultErr(result))
+		// The next attempt is made with the same arguments.
+		err = t.journal.MarkSubRequestAsFailed(ctx, sagaID, subReq.SubRequestID, arg)
+		if err != nil {
+			return fmt.Errorf("failed to mark the subrequest %q for saga %q as failed: %s", subReq.SubRequestID, sagaID, err)
+		}
 	} else {
-		fmt.Printf("failed %q\n", step)
-		err = t.journal.MarkSubRequestAsAborted(ctx, sagaID, subReq.SubRequestID, result.Context())
+		t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, subReq.SubRequestID, PhaseAction, "aborted", resultErr(result))
+		t.observers.SubRequestFailed(ctx, sagaID, subReq.SubRequestID, result.Context(), resultErr(result))
+		err = t.journal.MarkSubRequestAsAborted(ctx, sagaID, subReq.SubRequestID, result.Context(), resultErr(result).Error())
 		if err != nil {
 			return fmt.Errorf("failed to mark the subrequest %q for saga %q as aborted: %s", subReq.SubRequestID, sagaID, err)
 		}
+
+		t.recordActionFailure(sagaID, subReq.SubRequestID, resultErr(result))
 	}
 
 	return nil
@@ -144,14 +444,29 @@ func (t *SEC) execNextSubRequestCompensation(ctx context.Context, sagaID string)
 		err    error
 	)
 
+	def, err := t.getDefinition(sagaID)
+	if err != nil {
+		return err
+	}
+	defs := def.subRequestDefs
+
 	step, state, arg := t.journal.GetSagaLastEventLog(sagaID)
-	fmt.Printf("revert step: %s / %s\n", step, state)
 
 	switch state {
-	case "running", "aborted":
-		subReq = t.subRequestDefs.GetSubRequestDef(step)
-	case "done":
-		subReq, err = t.subRequestDefs.GetSubRequestBefore(step)
+	case "running", "aborted", "resumed":
+		subReq = defs.GetSubRequestDef(step)
+	case "failed":
+		subReq = defs.GetSubRequestDef(step)
+		if subReq == nil {
+			return fmt.Errorf("failed to select the next sub-request: unknown sub-request id %q", step)
+		}
+
+		err = t.waitBeforeRetry(ctx, sagaID, subReq.CompensationRetry)
+		if err != nil {
+			return err
+		}
+	case "done", "skipped":
+		subReq, err = defs.GetSubRequestBefore(step)
 		if err != nil {
 			return fmt.Errorf("failed to select the next sub-request: %s", err)
 		}
@@ -160,7 +475,6 @@ func (t *SEC) execNextSubRequestCompensation(ctx context.Context, sagaID string)
 	}
 
 	if subReq == nil {
-		fmt.Println("mark saga as done")
 		err := t.journal.MarkSagaAsDone(ctx, sagaID)
 		if err != nil {
 			return fmt.Errorf("failed to mark the saga %q as done: %s", sagaID, err)
@@ -169,23 +483,80 @@ func (t *SEC) execNextSubRequestCompensation(ctx context.Context, sagaID string)
 		return nil
 	}
 
-	fmt.Printf("revert : %s\n", subReq.SubRequestID)
+	t.logSubRequest(ctx, slog.LevelDebug, "run sub-request", sagaID, subReq.SubRequestID, PhaseCompensation, "", nil)
+	t.observers.CompensationStarted(ctx, sagaID, subReq.SubRequestID, arg)
 	err = t.journal.MarkSubRequestAsRunning(ctx, sagaID, subReq.SubRequestID, arg)
 	if err != nil {
 		return fmt.Errorf("failed to mark the subrequest %q for saga %q as running: %s", subReq.SubRequestID, sagaID, err)
 	}
 
-	result := subReq.Compensation(ctx, arg)
+	compensationCtx, end := t.startStep(ctx, sagaID, subReq.SubRequestID, PhaseCompensation)
+	result, err := t.execCompensation(compensationCtx, sagaID, subReq, arg)
+	if err != nil {
+		end(Failure(err, arg))
+		return err
+	}
+	end(result)
 	if result.IsSuccess() {
+		t.logSubRequest(ctx, slog.LevelInfo, "sub-request succeeded", sagaID, subReq.SubRequestID, PhaseCompensation, "success", nil)
+		t.observers.CompensationSucceeded(ctx, sagaID, subReq.SubRequestID, result.Context())
 		err = t.journal.MarkSubRequestAsDone(ctx, sagaID, subReq.SubRequestID, result.Context())
 		if err != nil {
 			return fmt.Errorf("failed to mark the subrequest %q for saga %q as done: %s", subReq.SubRequestID, sagaID, err)
 		}
-	} else {
-		err = t.journal.MarkSubRequestAsAborted(ctx, sagaID, subReq.SubRequestID, result.Context())
+
+		return nil
+	}
+
+	t.recordCompensationFailure(sagaID, resultErr(result))
+	t.observers.CompensationFailed(ctx, sagaID, subReq.SubRequestID, result.Context(), resultErr(result))
+
+	if !t.canRetry(sagaID, subReq.CompensationRetry) {
+		t.logSubRequest(ctx, slog.LevelError, "sub-request failed", sagaID, subReq.SubRequestID, PhaseCompensation, "stuck", resultErr(result))
+		err = t.journal.MarkSubRequestAsStuck(ctx, sagaID, subReq.SubRequestID, arg)
 		if err != nil {
-			return fmt.Errorf("failed to mark the subrequest %q for saga %q as aborted: %s", subReq.SubRequestID, sagaID, err)
+			return fmt.Errorf("failed to mark the subrequest %q for saga %q as stuck: %s", subReq.SubRequestID, sagaID, err)
 		}
+
+		return nil
+	}
+
+	t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, subReq.SubRequestID, PhaseCompensation, "retry", resultErr(result))
+
+	// The next attempt is made with the same arguments.
+	err = t.journal.MarkSubRequestAsFailed(ctx, sagaID, subReq.SubRequestID, arg)
+	if err != nil {
+		return fmt.Errorf("failed to mark the subrequest %q for saga %q as failed: %s", subReq.SubRequestID, sagaID, err)
+	}
+
+	return nil
+}
+
+// canRetry return if the policy allows a new attempt of the running
+// subRequest.
+func (t *SEC) canRetry(sagaID string, policy RetryPolicy) bool {
+	// Avoid to read the journal for the most common policies.
+	if policy.MaxAttempts == 1 {
+		return false
+	}
+
+	if policy.MaxAttempts == 0 && policy.MaxElapsedTime == 0 {
+		return true
+	}
+
+	attempts, firstAttempt := t.journal.GetSubRequestAttempts(sagaID)
+
+	return policy.canRetry(attempts, firstAttempt, time.Now())
+}
+
+// waitBeforeRetry wait the backoff delay before the next attempt of the failed
+// subRequest.
+func (t *SEC) waitBeforeRetry(ctx context.Context, sagaID string, policy RetryPolicy) error {
+	failures, _ := t.journal.GetSubRequestAttempts(sagaID)
+
+	err := sleep(ctx, policy.delay(failures))
+	if err != nil {
+		return fmt.Errorf("interrupted while waiting before the next attempt: %s", err)
 	}
 
 	return nil
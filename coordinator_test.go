@@ -4,11 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // SubRequestMock is a mock implementation of a Sub-Request and a Compensation
@@ -48,7 +54,7 @@ func Test_SEC_StartSaga_success(t *testing.T) {
 	scheduler.AppendNewSubRequest("step1", subRequest.Action, subRequest.Compensation)
 
 	// Create and save the Saga
-	journal.On("CreateNewSaga", sagaCtx).Return("some-saga-id", nil).Once()
+	journal.On("CreateNewSaga", "", 0, sagaCtx, map[string]string{}).Return("some-saga-id", nil).Once()
 
 	// There is 3 loops:
 	// 1 - Execute "step1"
@@ -69,6 +75,7 @@ func Test_SEC_StartSaga_success(t *testing.T) {
 
 	// 3 - Delete the saga
 	journal.On("GetSagaStatus", "some-saga-id").Return("done").Once()
+	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step1", "done", sagaCtx).Once()
 	journal.On("DeleteSaga", "some-saga-id").Once()
 
 	err := scheduler.StartSaga(context.Background(), sagaCtx)
@@ -87,7 +94,7 @@ func Test_SEC_StartSaga_with_journal_CreateNewSaga_error_should_fail(t *testing.
 	scheduler.AppendNewSubRequest("step1", subRequest.Action, subRequest.Compensation)
 
 	// Create and save the Saga
-	journal.On("CreateNewSaga", sagaCtx).Return("", errors.New("some-error")).Once()
+	journal.On("CreateNewSaga", "", 0, sagaCtx, map[string]string{}).Return("", errors.New("some-error")).Once()
 
 	err := scheduler.StartSaga(context.Background(), sagaCtx)
 	assert.EqualError(t, err, "failed to create a new saga: some-error")
@@ -105,7 +112,7 @@ func Test_SEC_runSaga_with_an_invalid_status_should_fail(t *testing.T) {
 	// GetSagaStatus twice, one time for the switch, one time for the error message.
 	journal.On("GetSagaStatus", "some-saga-id").Return("some-invalid-status").Twice()
 
-	err := scheduler.runSaga(context.Background(), "some-saga-id")
+	_, err := scheduler.runSaga(context.Background(), "some-saga-id")
 	assert.EqualError(t, err, `unknown status "some-invalid-status" for saga "some-saga-id"`)
 
 	journal.AssertExpectations(t)
@@ -123,7 +130,7 @@ func Test_SEC_runSaga_with_an_error_from_execNextSubRequestAction_should_fail(t
 	journal.On("GetSagaStatus", "some-saga-id").Return("running").Once()
 	journal.On("GetSagaLastEventLog", "some-saga-id").Return("_init", "running", sagaCtx).Once()
 
-	err := scheduler.runSaga(context.Background(), "some-saga-id")
+	_, err := scheduler.runSaga(context.Background(), "some-saga-id")
 	assert.EqualError(t, err, `the previous sub-request action/compensation is not finished`)
 
 	journal.AssertExpectations(t)
@@ -141,7 +148,7 @@ func Test_SEC_runSaga_with_an_error_from_execNextSubRequestCompensation_should_f
 	journal.On("GetSagaStatus", "some-saga-id").Return("aborted").Once()
 	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step1", "invalid-status", sagaCtx).Once()
 
-	err := scheduler.runSaga(context.Background(), "some-saga-id")
+	_, err := scheduler.runSaga(context.Background(), "some-saga-id")
 	assert.EqualError(t, err, `unknown status "invalid-status" for subrequest "step1"`)
 
 	journal.AssertExpectations(t)
@@ -249,7 +256,7 @@ func Test_execNextSubRequestAction_with_a_MarkSubRequestAsAborted_error(t *testi
 	journal.On("GetSagaLastEventLog", "some-saga-id").Return("_init", "done", sagaCtx).Once()
 	journal.On("MarkSubRequestAsRunning", "some-saga-id", "step1", sagaCtx).Return(nil).Once()
 	subRequest.On("Action", sagaCtx).Return(Failure(errors.New("some-action-error"), sagaCtx)).Once()
-	journal.On("MarkSubRequestAsAborted", "some-saga-id", "step1", sagaCtx).Return(errors.New("some-error")).Once()
+	journal.On("MarkSubRequestAsAborted", "some-saga-id", "step1", sagaCtx, "some-action-error").Return(errors.New("some-error")).Once()
 
 	err := scheduler.execNextSubRequestAction(context.Background(), "some-saga-id")
 	assert.EqualError(t, err, "failed to mark the subrequest \"step1\" for saga \"some-saga-id\" as aborted: some-error")
@@ -333,7 +340,7 @@ func Test_execNextSubRequestCompensation_with_a_MarkSubRequestAsDone_error(t *te
 	subRequest.AssertExpectations(t)
 }
 
-func Test_execNextSubRequestCompensation_with_a_MarkSubRequestAsAborted_error(t *testing.T) {
+func Test_execNextSubRequestCompensation_with_a_MarkSubRequestAsFailed_error(t *testing.T) {
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	journal := new(journal.Mock)
@@ -345,10 +352,10 @@ func Test_execNextSubRequestCompensation_with_a_MarkSubRequestAsAborted_error(t
 	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step2", "done", sagaCtx).Once()
 	journal.On("MarkSubRequestAsRunning", "some-saga-id", "step1", sagaCtx).Return(nil).Once()
 	subRequest.On("Compensation", sagaCtx).Return(Failure(errors.New("some-action-error"), sagaCtx)).Once()
-	journal.On("MarkSubRequestAsAborted", "some-saga-id", "step1", sagaCtx).Return(errors.New("some-error")).Once()
+	journal.On("MarkSubRequestAsFailed", "some-saga-id", "step1", sagaCtx).Return(errors.New("some-error")).Once()
 
 	err := scheduler.execNextSubRequestCompensation(context.Background(), "some-saga-id")
-	assert.EqualError(t, err, "failed to mark the subrequest \"step1\" for saga \"some-saga-id\" as aborted: some-error")
+	assert.EqualError(t, err, "failed to mark the subrequest \"step1\" for saga \"some-saga-id\" as failed: some-error")
 
 	journal.AssertExpectations(t)
 	subRequest.AssertExpectations(t)
@@ -371,3 +378,223 @@ func Test_execNextSubRequestCompensation_with_an_invalid_subrequest_id(t *testin
 	journal.AssertExpectations(t)
 	subRequest.AssertExpectations(t)
 }
+
+func Test_SEC_Recover_with_an_interrupted_action(t *testing.T) {
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	journal := new(journal.Mock)
+	subRequest := new(SubRequestMock)
+	scheduler := &SEC{subRequestDefs: []subRequestDef{}, journal: journal, workers: make(chan struct{}, 1)}
+	scheduler.AppendNewSubRequest("step1", subRequest.Action, subRequest.Compensation)
+
+	journal.On("Restore").Return([]string{"some-saga-id"}, nil).Once()
+
+	// The "step1" action have been interrupted, it is aborted.
+	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step1", "running", sagaCtx).Once()
+	journal.On("GetSagaStatus", "some-saga-id").Return("running").Once()
+	journal.On("MarkSubRequestAsAborted", "some-saga-id", "step1", sagaCtx, errInterrupted.Error()).Return(nil).Once()
+
+	// Compensate the "step1" SubRequest
+	journal.On("GetSagaStatus", "some-saga-id").Return("aborted").Once()
+	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step1", "aborted", sagaCtx).Once()
+	journal.On("MarkSubRequestAsRunning", "some-saga-id", "step1", sagaCtx).Return(nil).Once()
+	subRequest.On("Compensation", sagaCtx).Return(Success(sagaCtx)).Once()
+	journal.On("MarkSubRequestAsDone", "some-saga-id", "step1", sagaCtx).Return(nil).Once()
+
+	// Mark the saga as "done"
+	journal.On("GetSagaStatus", "some-saga-id").Return("aborted").Once()
+	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step1", "done", sagaCtx).Once()
+	journal.On("MarkSagaAsDone", "some-saga-id").Return(nil).Once()
+
+	// Delete the saga
+	journal.On("GetSagaStatus", "some-saga-id").Return("done").Once()
+	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step1", "done", sagaCtx).Once()
+	journal.On("DeleteSaga", "some-saga-id").Once()
+
+	err := scheduler.Recover(context.Background())
+	assert.NoError(t, err)
+
+	journal.AssertExpectations(t)
+	subRequest.AssertExpectations(t)
+}
+
+func Test_SEC_Recover_with_a_Restore_error(t *testing.T) {
+	journal := new(journal.Mock)
+	subRequest := new(SubRequestMock)
+	scheduler := &SEC{subRequestDefs: []subRequestDef{}, journal: journal, workers: make(chan struct{}, 1)}
+	scheduler.AppendNewSubRequest("step1", subRequest.Action, subRequest.Compensation)
+
+	journal.On("Restore").Return(nil, errors.New("some-error")).Once()
+
+	err := scheduler.Recover(context.Background())
+	assert.EqualError(t, err, "failed to restore the journal: some-error")
+
+	journal.AssertExpectations(t)
+	subRequest.AssertExpectations(t)
+}
+
+func Test_SEC_Recover_with_a_MarkSubRequestAsAborted_error(t *testing.T) {
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	journal := new(journal.Mock)
+	subRequest := new(SubRequestMock)
+	scheduler := &SEC{subRequestDefs: []subRequestDef{}, journal: journal, workers: make(chan struct{}, 1)}
+	scheduler.AppendNewSubRequest("step1", subRequest.Action, subRequest.Compensation)
+
+	journal.On("Restore").Return([]string{"some-saga-id"}, nil).Once()
+	journal.On("GetSagaLastEventLog", "some-saga-id").Return("step1", "running", sagaCtx).Once()
+	journal.On("GetSagaStatus", "some-saga-id").Return("running").Once()
+	journal.On("MarkSubRequestAsAborted", "some-saga-id", "step1", sagaCtx, errInterrupted.Error()).Return(errors.New("some-error")).Once()
+
+	err := scheduler.Recover(context.Background())
+	assert.EqualError(t, err, `failed to recover the saga "some-saga-id": failed to mark the interrupted subrequest "step1" as aborted: some-error`)
+
+	journal.AssertExpectations(t)
+	subRequest.AssertExpectations(t)
+}
+
+func Test_SEC_Recover_should_continue_after_a_saga_error(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	for _, eventLog := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", SagaType: "unknown", SagaVersion: 1},
+		{SagaID: "saga-2", Step: "_init", State: "done", SagaType: "payment", SagaVersion: 1, Context: json.RawMessage(`{}`)},
+		{SagaID: "saga-3", Step: "_init", State: "done", SagaType: "unknown", SagaVersion: 2},
+	} {
+		require.NoError(t, memory.SaveEventLog(ctx, &eventLog))
+	}
+
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(memory).
+		WithWorkers(1).
+		RegisterSaga(NewSagaDefinition("payment", 1).
+			AppendNewSubRequest("step1", calls.record("step1", Success(nil)), nil))
+
+	err := scheduler.Recover(ctx)
+	assert.ErrorContains(t, err, `failed to recover the saga "saga-1": unknown saga definition "unknown" version 1`)
+	assert.ErrorContains(t, err, `failed to recover the saga "saga-3": unknown saga definition "unknown" version 2`)
+
+	// The saga following the error is recovered.
+	assert.Equal(t, []string{"step1"}, calls.sorted())
+
+	unfinished, err := memory.GetUnfinishedSagaIDs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"saga-1", "saga-3"}, unfinished)
+}
+
+func Test_SEC_Recover_should_run_the_sagas_concurrently(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	for _, sagaID := range []string{"saga-1", "saga-2"} {
+		require.NoError(t, memory.SaveEventLog(ctx, &model.EventLog{SagaID: sagaID, Step: "_init", State: "done", Context: json.RawMessage(`{}`)}))
+	}
+
+	// Each saga waits the other one.
+	started := new(sync.WaitGroup)
+	started.Add(2)
+	scheduler := NewSagaExecutionCoordinator(memory).
+		WithWorkers(2).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			started.Done()
+			started.Wait()
+			return Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.Recover(ctx)
+	require.NoError(t, err)
+}
+
+func Test_SEC_Recover_with_a_context_done(t *testing.T) {
+	memory := storage.NewMemory()
+	require.NoError(t, memory.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done", Context: json.RawMessage(`{}`)}))
+
+	release := make(chan struct{})
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			<-release
+			return Success(sagaCtx)
+		}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := scheduler.Recover(ctx)
+	assert.EqualError(t, err, "interrupted while waiting the recovered sagas: context deadline exceeded")
+
+	// The saga continue in background.
+	close(release)
+	assert.Eventually(t, func() bool {
+		unfinished, err := memory.GetUnfinishedSagaIDs(context.Background())
+		return err == nil && len(unfinished) == 0
+	}, time.Second, 5*time.Millisecond)
+}
+
+func Test_SEC_StartSaga_concurrent_sagas(t *testing.T) {
+	memory := storage.NewMemory()
+
+	var (
+		mutex         sync.Mutex
+		debited       = map[int]int{}
+		credited      = map[int]int{}
+		compensations = map[int]int{}
+	)
+
+	count := func(calls map[int]int, fail bool) Action {
+		return func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			var req struct{ ID int }
+			err := json.Unmarshal(sagaCtx, &req)
+			if err != nil {
+				return Failure(err, sagaCtx)
+			}
+
+			mutex.Lock()
+			calls[req.ID]++
+			mutex.Unlock()
+
+			// Every third saga is aborted.
+			if fail && req.ID%3 == 0 {
+				return Failure(errors.New("some-error"), sagaCtx)
+			}
+
+			return Success(sagaCtx)
+		}
+	}
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("debit", count(debited, false), count(compensations, false)).
+		AppendNewSubRequest("credit", count(credited, true), count(compensations, false))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 300; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			err := scheduler.StartSaga(context.Background(), json.RawMessage(fmt.Sprintf(`{"ID": %d}`, id)))
+			if id%3 == 0 {
+				var abortErr *SagaAbortedError
+				assert.True(t, errors.As(err, &abortErr))
+			} else {
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 300; i++ {
+		assert.Equal(t, 1, debited[i])
+		assert.Equal(t, 1, credited[i])
+
+		if i%3 == 0 {
+			assert.Equal(t, 2, compensations[i])
+		} else {
+			assert.Zero(t, compensations[i])
+		}
+	}
+
+	unfinished, err := memory.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, unfinished)
+}
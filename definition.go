@@ -0,0 +1,149 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SagaDefinition is a named and versioned list of Sub-Requests.
+//
+// Several definitions can be registered into a single SEC with RegisterSaga.
+// The definition name and version are saved with each saga, so a saga is
+// always run with the definition it have been started with, even after a
+// restart with a new version.
+type SagaDefinition struct {
+	name           string
+	version        int
+	timeout        time.Duration
+	subRequestDefs subRequestDefs
+}
+
+// definitionKey identify a registered SagaDefinition.
+type definitionKey struct {
+	name    string
+	version int
+}
+
+// NewSagaDefinition instantiate a new empty SagaDefinition.
+func NewSagaDefinition(name string, version int) *SagaDefinition {
+	return &SagaDefinition{
+		name:           name,
+		version:        version,
+		subRequestDefs: []subRequestDef{},
+	}
+}
+
+// AppendNewSubRequest append a new SubRequest to the definition. See
+// SEC.AppendNewSubRequest.
+func (t *SagaDefinition) AppendNewSubRequest(name string, action Action, compensation Action, opts ...SubRequestOption) *SagaDefinition {
+	t.subRequestDefs = t.subRequestDefs.append(newSubRequestDef(name, action, compensation, opts))
+
+	return t
+}
+
+// AppendNewStage append a parallel stage to the definition. See
+// SEC.AppendNewStage.
+func (t *SagaDefinition) AppendNewStage(name string, branches []Branch, opts ...SubRequestOption) *SagaDefinition {
+	t.subRequestDefs = t.subRequestDefs.append(newStageDef(name, branches, opts))
+
+	return t
+}
+
+// WithTimeout set the maximum duration of the sagas started with the
+// definition. See SEC.WithSagaTimeout, used when no timeout is set.
+func (t *SagaDefinition) WithTimeout(timeout time.Duration) *SagaDefinition {
+	t.timeout = timeout
+
+	return t
+}
+
+// RegisterSaga register the given definition. A definition already registered
+// with the same name and version is replaced.
+//
+// The previous versions must stay registered as long as some sagas started
+// with them are unfinished. It panics if the definition name is empty.
+func (t *SEC) RegisterSaga(def *SagaDefinition) *SEC {
+	if def.name == "" {
+		panic("gosaga: a saga definition must have a name")
+	}
+
+	if t.definitions == nil {
+		t.definitions = map[definitionKey]*SagaDefinition{}
+	}
+
+	t.definitions[definitionKey{name: def.name, version: def.version}] = def
+
+	return t
+}
+
+// StartNamedSaga create a new saga with the last version of the given
+// definition and run it. See StartSaga.
+func (t *SEC) StartNamedSaga(ctx context.Context, name string, sagaCtx json.RawMessage) error {
+	version, err := t.lastVersion(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.startNewSaga(ctx, "", name, version, sagaCtx)
+
+	return err
+}
+
+// SubmitNamed create a new saga with the last version of the given
+// definition and schedule it into the worker pool. See Submit.
+func (t *SEC) SubmitNamed(ctx context.Context, name string, sagaCtx json.RawMessage) (string, error) {
+	version, err := t.lastVersion(name)
+	if err != nil {
+		return "", err
+	}
+
+	return t.submitNewSaga(ctx, "", name, version, sagaCtx)
+}
+
+// lastVersion return the highest registered version of the given definition.
+func (t *SEC) lastVersion(name string) (int, error) {
+	version, found := 0, false
+	for key := range t.definitions {
+		if key.name == name && (!found || key.version > version) {
+			version, found = key.version, true
+		}
+	}
+
+	if !found {
+		return 0, fmt.Errorf("unknown saga definition %q", name)
+	}
+
+	return version, nil
+}
+
+// getDefinition return the definition the given saga have been started with.
+// The sagas started with StartSaga or Submit use the SEC Sub-Requests.
+func (t *SEC) getDefinition(sagaID string) (*SagaDefinition, error) {
+	def := &SagaDefinition{subRequestDefs: t.subRequestDefs, timeout: t.sagaTimeout}
+
+	// Avoid to read the journal when only the SEC Sub-Requests are used.
+	if len(t.definitions) == 0 {
+		return def, nil
+	}
+
+	name, version := t.journal.GetSagaDefinition(sagaID)
+	if name == "" {
+		return def, nil
+	}
+
+	registered, ok := t.definitions[definitionKey{name: name, version: version}]
+	if !ok {
+		return nil, fmt.Errorf("unknown saga definition %q version %d", name, version)
+	}
+
+	if registered.timeout == 0 {
+		def.timeout = t.sagaTimeout
+	} else {
+		def.timeout = registered.timeout
+	}
+	def.subRequestDefs = registered.subRequestDefs
+
+	return def, nil
+}
@@ -0,0 +1,500 @@
+// Package definition build the sagas from a declarative definition written in
+// YAML or JSON, so the steps can be changed without a code change.
+//
+// The Actions and Compensations are referenced by their name into an
+// ActionRegistry:
+//
+//	name: payment
+//	version: 2
+//	timeout: 1h
+//	steps:
+//	  - name: debit
+//	    action: debit
+//	    compensation: refund
+//	    timeout: 30s
+//	    actionRetry:
+//	      maxAttempts: 3
+//	      initialInterval: 1s
+//	      multiplier: 2
+//	  - name: reserve
+//	    branches:
+//	      - name: hotel
+//	        action: reserveHotel
+//	        compensation: cancelHotel
+//	      - name: flight
+//	        action: reserveFlight
+//	        compensation: cancelFlight
+//	  - name: pay
+//	    action: pay
+//	    pivot: true
+//	  - name: notify
+//	    action: notify
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/internal/journal"
+	"gopkg.in/yaml.v3"
+)
+
+// Error is a validation error of a definition.
+type Error struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (t *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", t.Line, t.Column, t.Message)
+}
+
+// Definition is a validated saga definition.
+type Definition struct {
+	name    string
+	version int
+	timeout time.Duration
+	steps   []step
+}
+
+// step is a Sub-Request or a parallel stage if it has some branches.
+type step struct {
+	name         string
+	pivot        bool
+	action       gosaga.Action
+	compensation gosaga.Action
+	opts         []gosaga.SubRequestOption
+	branches     []step
+}
+
+// Parse parse and validate the given YAML or JSON definition. The Actions and
+// Compensations are resolved with the given registry.
+//
+// All the validation errors are returned, joined and sorted by position, as
+// *Error.
+func Parse(data []byte, registry *ActionRegistry) (*Definition, error) {
+	var root yaml.Node
+
+	err := yaml.Unmarshal(data, &root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid definition: %s", err)
+	}
+
+	if len(root.Content) == 0 {
+		return nil, &Error{Line: 1, Column: 1, Message: "empty definition"}
+	}
+
+	p := parser{registry: registry}
+	def := p.parseDefinition(root.Content[0])
+
+	if len(p.errs) > 0 {
+		sort.SliceStable(p.errs, func(i, j int) bool {
+			a, b := p.errs[i].(*Error), p.errs[j].(*Error)
+			return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+		})
+
+		return nil, errors.Join(p.errs...)
+	}
+
+	return def, nil
+}
+
+// ParseFile parse and validate the definition contained into the given file.
+func ParseFile(path string, registry *ActionRegistry) (*Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the definition: %s", err)
+	}
+
+	return Parse(data, registry)
+}
+
+// Name return the optional name of the definition.
+func (t *Definition) Name() string {
+	return t.name
+}
+
+// Version return the version of the definition, 1 by default.
+func (t *Definition) Version() int {
+	return t.version
+}
+
+// NewSEC instantiate a new SEC running the defined saga with StartSaga and
+// Submit.
+func (t *Definition) NewSEC(storage journal.Storage) *gosaga.SEC {
+	sec := gosaga.NewSagaExecutionCoordinator(storage).
+		WithSagaTimeout(t.timeout)
+
+	for _, step := range t.steps {
+		if len(step.branches) == 0 {
+			sec.AppendNewSubRequest(step.name, step.action, step.compensation, step.opts...)
+		} else {
+			sec.AppendNewStage(step.name, step.newBranches(), step.opts...)
+		}
+	}
+
+	return sec
+}
+
+// SagaDefinition return the definition to register into a SEC with
+// RegisterSaga. The definition must have a name.
+func (t *Definition) SagaDefinition() *gosaga.SagaDefinition {
+	def := gosaga.NewSagaDefinition(t.name, t.version).
+		WithTimeout(t.timeout)
+
+	for _, step := range t.steps {
+		if len(step.branches) == 0 {
+			def.AppendNewSubRequest(step.name, step.action, step.compensation, step.opts...)
+		} else {
+			def.AppendNewStage(step.name, step.newBranches(), step.opts...)
+		}
+	}
+
+	return def
+}
+
+func (t *step) newBranches() []gosaga.Branch {
+	branches := []gosaga.Branch{}
+	for _, branch := range t.branches {
+		branches = append(branches, gosaga.NewBranch(branch.name, branch.action, branch.compensation, branch.opts...))
+	}
+
+	return branches
+}
+
+// parser collect the validation errors of a definition.
+type parser struct {
+	registry *ActionRegistry
+	errs     []error
+
+	// pivotLine is the line of the pivot step once parsed, the next steps
+	// can't have a compensation.
+	pivotLine int
+}
+
+func (t *parser) errorf(node *yaml.Node, format string, args ...interface{}) {
+	t.errs = append(t.errs, &Error{
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (t *parser) parseDefinition(node *yaml.Node) *Definition {
+	fields, ok := t.mapping(node, "name", "version", "timeout", "steps")
+	if !ok {
+		return nil
+	}
+
+	def := &Definition{version: 1}
+
+	if nameNode, ok := fields["name"]; ok {
+		def.name, ok = t.string(nameNode)
+		if ok && def.name == "" {
+			t.errorf(nameNode, "must not be empty")
+		}
+	}
+
+	if versionNode, ok := fields["version"]; ok {
+		def.version = t.integer(versionNode)
+		if def.version < 1 {
+			t.errorf(versionNode, "must be greater than 0")
+		}
+	}
+
+	if timeoutNode, ok := fields["timeout"]; ok {
+		def.timeout = t.duration(timeoutNode)
+	}
+
+	stepsNode, ok := fields["steps"]
+	if !ok {
+		t.errorf(node, `missing "steps"`)
+		return nil
+	}
+
+	def.steps = t.parseSteps(stepsNode, true)
+
+	return def
+}
+
+func (t *parser) parseSteps(node *yaml.Node, allowStages bool) []step {
+	if node.Kind != yaml.SequenceNode {
+		t.errorf(node, "must be a list")
+		return nil
+	}
+
+	if len(node.Content) == 0 {
+		t.errorf(node, "must contain at least one step")
+		return nil
+	}
+
+	steps := []step{}
+	lines := map[string]int{}
+	for _, stepNode := range node.Content {
+		step, ok := t.parseStep(stepNode, allowStages)
+		if !ok {
+			continue
+		}
+
+		if line, ok := lines[step.name]; ok {
+			t.errorf(stepNode, "duplicate name %q, already used line %d", step.name, line)
+			continue
+		}
+
+		if step.pivot {
+			if t.pivotLine != 0 {
+				t.errorf(stepNode, "duplicate pivot, already set line %d", t.pivotLine)
+			} else {
+				t.pivotLine = stepNode.Line
+			}
+		}
+
+		lines[step.name] = stepNode.Line
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+func (t *parser) parseStep(node *yaml.Node, allowStages bool) (step, bool) {
+	keys := []string{"name", "action", "compensation", "actionRetry", "compensationRetry", "timeout", "compensationTimeout"}
+	if allowStages {
+		keys = append(keys, "branches", "pivot")
+	}
+
+	fields, ok := t.mapping(node, keys...)
+	if !ok {
+		return step{}, false
+	}
+
+	res := step{}
+
+	nameNode, ok := fields["name"]
+	if !ok {
+		t.errorf(node, `missing "name"`)
+		return step{}, false
+	}
+
+	res.name, ok = t.name(nameNode)
+	if !ok {
+		return step{}, false
+	}
+
+	branchesNode, isStage := fields["branches"]
+	if isStage {
+		for _, key := range []string{"action", "compensation", "actionRetry"} {
+			if valueNode, ok := fields[key]; ok {
+				t.errorf(valueNode, "a stage can't have %q, set it on its branches", key)
+			}
+		}
+
+		res.branches = t.parseSteps(branchesNode, false)
+	} else {
+		actionNode, ok := fields["action"]
+		if !ok {
+			t.errorf(node, `missing "action"`)
+		} else {
+			res.action = t.action(actionNode)
+		}
+
+		if actionRetryNode, ok := fields["actionRetry"]; ok {
+			res.opts = append(res.opts, gosaga.WithActionRetry(t.retryPolicy(actionRetryNode)))
+		}
+	}
+
+	if compensationNode, ok := fields["compensation"]; ok && !isStage {
+		res.compensation = t.action(compensationNode)
+
+		if t.pivotLine != 0 {
+			t.errorf(compensationNode, "a step following the pivot can't have a compensation")
+		}
+	}
+
+	if pivotNode, ok := fields["pivot"]; ok {
+		res.pivot = t.boolean(pivotNode)
+		if res.pivot {
+			res.opts = append(res.opts, gosaga.AsPivot())
+		}
+	}
+
+	if compensationRetryNode, ok := fields["compensationRetry"]; ok {
+		res.opts = append(res.opts, gosaga.WithCompensationRetry(t.retryPolicy(compensationRetryNode)))
+	}
+
+	if timeoutNode, ok := fields["timeout"]; ok {
+		res.opts = append(res.opts, gosaga.WithActionTimeout(t.duration(timeoutNode)))
+	}
+
+	if compensationTimeoutNode, ok := fields["compensationTimeout"]; ok {
+		res.opts = append(res.opts, gosaga.WithCompensationTimeout(t.duration(compensationTimeoutNode)))
+	}
+
+	return res, true
+}
+
+func (t *parser) retryPolicy(node *yaml.Node) gosaga.RetryPolicy {
+	fields, ok := t.mapping(node, "maxAttempts", "initialInterval", "maxInterval", "multiplier", "jitter", "maxElapsedTime")
+	if !ok {
+		return gosaga.RetryPolicy{}
+	}
+
+	policy := gosaga.RetryPolicy{}
+
+	if valueNode, ok := fields["maxAttempts"]; ok {
+		policy.MaxAttempts = t.integer(valueNode)
+		if policy.MaxAttempts < 0 {
+			t.errorf(valueNode, "must be positive")
+		}
+	}
+
+	if valueNode, ok := fields["initialInterval"]; ok {
+		policy.InitialInterval = t.duration(valueNode)
+	}
+
+	if valueNode, ok := fields["maxInterval"]; ok {
+		policy.MaxInterval = t.duration(valueNode)
+	}
+
+	if valueNode, ok := fields["maxElapsedTime"]; ok {
+		policy.MaxElapsedTime = t.duration(valueNode)
+	}
+
+	if valueNode, ok := fields["multiplier"]; ok {
+		policy.Multiplier = t.float(valueNode)
+		if policy.Multiplier < 0 {
+			t.errorf(valueNode, "must be positive")
+		}
+	}
+
+	if valueNode, ok := fields["jitter"]; ok {
+		policy.Jitter = t.float(valueNode)
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			t.errorf(valueNode, "must be between 0 and 1")
+		}
+	}
+
+	return policy
+}
+
+// mapping return the values of the given mapping node by key. The keys must be
+// part of the allowed ones.
+func (t *parser) mapping(node *yaml.Node, allowed ...string) (map[string]*yaml.Node, bool) {
+	if node.Kind != yaml.MappingNode {
+		t.errorf(node, "must be an object")
+		return nil, false
+	}
+
+	res := map[string]*yaml.Node{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		known := false
+		for _, key := range allowed {
+			known = known || key == keyNode.Value
+		}
+
+		switch {
+		case !known:
+			t.errorf(keyNode, "unknown field %q", keyNode.Value)
+		case res[keyNode.Value] != nil:
+			t.errorf(keyNode, "duplicate field %q", keyNode.Value)
+		default:
+			res[keyNode.Value] = valueNode
+		}
+	}
+
+	return res, true
+}
+
+func (t *parser) name(node *yaml.Node) (string, bool) {
+	name, ok := t.string(node)
+	switch {
+	case !ok:
+		return "", false
+	case name == "":
+		t.errorf(node, "must not be empty")
+		return "", false
+	case strings.HasPrefix(name, "_"):
+		t.errorf(node, "the names starting with \"_\" are reserved")
+		return "", false
+	case strings.Contains(name, "/"):
+		t.errorf(node, "must not contain \"/\"")
+		return "", false
+	}
+
+	return name, true
+}
+
+func (t *parser) action(node *yaml.Node) gosaga.Action {
+	name, ok := t.string(node)
+	if !ok {
+		return nil
+	}
+
+	action, ok := t.registry.Get(name)
+	if !ok {
+		t.errorf(node, "unknown action %q", name)
+		return nil
+	}
+
+	return action
+}
+
+func (t *parser) string(node *yaml.Node) (string, bool) {
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!null" {
+		t.errorf(node, "must be a string")
+		return "", false
+	}
+
+	return node.Value, true
+}
+
+func (t *parser) boolean(node *yaml.Node) bool {
+	var res bool
+
+	if node.Kind != yaml.ScalarNode || node.Decode(&res) != nil {
+		t.errorf(node, "must be a boolean")
+	}
+
+	return res
+}
+
+func (t *parser) integer(node *yaml.Node) int {
+	var res int
+
+	if node.Kind != yaml.ScalarNode || node.Decode(&res) != nil {
+		t.errorf(node, "must be an integer")
+	}
+
+	return res
+}
+
+func (t *parser) float(node *yaml.Node) float64 {
+	var res float64
+
+	if node.Kind != yaml.ScalarNode || node.Decode(&res) != nil {
+		t.errorf(node, "must be a number")
+	}
+
+	return res
+}
+
+func (t *parser) duration(node *yaml.Node) time.Duration {
+	value, ok := t.string(node)
+	if !ok {
+		return 0
+	}
+
+	res, err := time.ParseDuration(value)
+	if err != nil || res < 0 {
+		t.errorf(node, "invalid duration %q", value)
+	}
+
+	return res
+}
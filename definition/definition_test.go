@@ -0,0 +1,357 @@
+package definition
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRegistry return a registry whose Actions record their calls. The "fail"
+// Action always fails.
+func testRegistry() (*ActionRegistry, *[]string) {
+	mutex := new(sync.Mutex)
+	calls := []string{}
+
+	record := func(name string) gosaga.Action {
+		return func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			calls = append(calls, name)
+			return gosaga.Success(sagaCtx)
+		}
+	}
+
+	registry := NewActionRegistry().
+		Register("debit", record("debit")).
+		Register("refund", record("refund")).
+		Register("reserveHotel", record("reserveHotel")).
+		Register("cancelHotel", record("cancelHotel")).
+		Register("reserveFlight", record("reserveFlight")).
+		Register("cancelFlight", record("cancelFlight")).
+		Register("fail", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			calls = append(calls, "fail")
+			return gosaga.Failure(errors.New("some-error"), sagaCtx)
+		})
+
+	return registry, &calls
+}
+
+const validDefinition = `
+steps:
+  - name: debit
+    action: debit
+    compensation: refund
+    compensationRetry:
+      maxAttempts: 2
+  - name: reserve
+    branches:
+      - name: hotel
+        action: reserveHotel
+        compensation: cancelHotel
+      - name: flight
+        action: reserveFlight
+        compensation: cancelFlight
+  - name: pay
+    action: fail
+    actionRetry:
+      maxAttempts: 3
+      initialInterval: 1ms
+      maxInterval: 10ms
+      multiplier: 2
+      jitter: 0.1
+      maxElapsedTime: 1m
+`
+
+func Test_Parse_success(t *testing.T) {
+	registry, calls := testRegistry()
+
+	def, err := Parse([]byte(validDefinition), registry)
+	require.NoError(t, err)
+
+	err = def.NewSEC(storage.NewMemory()).StartSaga(context.Background(), json.RawMessage(`{}`))
+
+	var abortedErr *gosaga.SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "pay", abortedErr.SubRequestID)
+
+	sort.Strings(*calls)
+	assert.Equal(t, []string{
+		"cancelFlight", "cancelHotel",
+		"debit",
+		"fail", "fail", "fail",
+		"refund",
+		"reserveFlight", "reserveHotel",
+	}, *calls)
+}
+
+func Test_Parse_with_a_JSON_definition(t *testing.T) {
+	registry, calls := testRegistry()
+
+	def, err := Parse([]byte(`{
+		"steps": [
+			{"name": "debit", "action": "debit", "compensation": "refund"},
+			{"name": "hotel", "action": "reserveHotel", "actionRetry": {"maxAttempts": 2, "initialInterval": "1s"}}
+		]
+	}`), registry)
+	require.NoError(t, err)
+
+	err = def.NewSEC(storage.NewMemory()).StartSaga(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"debit", "reserveHotel"}, *calls)
+}
+
+func Test_Parse_with_validation_errors(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := Parse([]byte(`
+steps:
+  - name: debit
+    action: some-unknown-action
+    someField: 1s
+  - name: debit
+    action: debit
+  - action: debit
+  - name: _init
+    action: debit
+  - name: stage
+    action: debit
+    branches:
+      - name: hotel
+        branches: []
+  - name: retry
+    action: debit
+    actionRetry:
+      maxAttempts: -1
+      initialInterval: soon
+      jitter: 2
+      multiplier: many
+`), registry)
+
+	assert.EqualError(t, err, `line 4, column 13: unknown action "some-unknown-action"
+line 5, column 5: unknown field "someField"
+line 6, column 5: duplicate name "debit", already used line 3
+line 8, column 5: missing "name"
+line 9, column 11: the names starting with "_" are reserved
+line 12, column 13: a stage can't have "action", set it on its branches
+line 14, column 9: missing "action"
+line 15, column 9: unknown field "branches"
+line 19, column 20: must be positive
+line 20, column 24: invalid duration "soon"
+line 21, column 15: must be between 0 and 1
+line 22, column 19: must be a number`)
+
+	var defErr *Error
+	require.ErrorAs(t, err, &defErr)
+	assert.Equal(t, &Error{Line: 4, Column: 13, Message: `unknown action "some-unknown-action"`}, defErr)
+}
+
+func Test_Definition_SagaDefinition_success(t *testing.T) {
+	registry, calls := testRegistry()
+
+	def, err := Parse([]byte(`
+name: payment
+version: 3
+steps:
+  - name: debit
+    action: debit
+`), registry)
+	require.NoError(t, err)
+	assert.Equal(t, "payment", def.Name())
+	assert.Equal(t, 3, def.Version())
+
+	memory := storage.NewMemory()
+	scheduler := gosaga.NewSagaExecutionCoordinator(memory).
+		RegisterSaga(def.SagaDefinition())
+
+	sagaID, err := scheduler.SubmitNamed(context.Background(), "payment", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"debit"}, *calls)
+}
+
+func Test_Parse_with_the_default_version(t *testing.T) {
+	registry, _ := testRegistry()
+
+	def, err := Parse([]byte("steps:\n  - name: debit\n    action: debit\n"), registry)
+	require.NoError(t, err)
+
+	assert.Empty(t, def.Name())
+	assert.Equal(t, 1, def.Version())
+}
+
+func Test_Parse_with_an_invalid_name_and_version(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := Parse([]byte(`
+name: ""
+version: 0
+steps:
+  - name: debit
+    action: debit
+`), registry)
+
+	assert.EqualError(t, err, "line 2, column 7: must not be empty\nline 3, column 10: must be greater than 0")
+}
+
+func Test_Parse_with_timeouts(t *testing.T) {
+	registry := NewActionRegistry().
+		Register("debit", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			<-ctx.Done()
+			return gosaga.Failure(ctx.Err(), sagaCtx)
+		})
+
+	def, err := Parse([]byte(`
+timeout: 1h
+steps:
+  - name: debit
+    action: debit
+    timeout: 10ms
+    compensationTimeout: 1s
+`), registry)
+	require.NoError(t, err)
+
+	err = def.NewSEC(storage.NewMemory()).StartSaga(context.Background(), json.RawMessage(`{}`))
+
+	assert.ErrorIs(t, err, gosaga.ErrTimeout)
+	assert.ErrorContains(t, err, "the action took more than 10ms")
+}
+
+func Test_Parse_with_invalid_timeouts(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := Parse([]byte(`
+timeout: never
+steps:
+  - name: debit
+    action: debit
+    timeout: -1s
+`), registry)
+
+	assert.EqualError(t, err, "line 2, column 10: invalid duration \"never\"\nline 6, column 14: invalid duration \"-1s\"")
+}
+
+func Test_Parse_with_a_pivot(t *testing.T) {
+	registry, calls := testRegistry()
+
+	def, err := Parse([]byte(`
+steps:
+  - name: debit
+    action: debit
+    compensation: refund
+    pivot: true
+  - name: reserve
+    branches:
+      - name: hotel
+        action: reserveHotel
+      - name: flight
+        action: reserveFlight
+`), registry)
+	require.NoError(t, err)
+
+	err = def.NewSEC(storage.NewMemory()).StartSaga(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	sort.Strings(*calls)
+	assert.Equal(t, []string{"debit", "reserveFlight", "reserveHotel"}, *calls)
+}
+
+func Test_Parse_with_invalid_pivots(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := Parse([]byte(`
+steps:
+  - name: debit
+    action: debit
+    pivot: yes please
+  - name: pay
+    action: debit
+    pivot: true
+  - name: refund
+    action: debit
+    compensation: refund
+    pivot: true
+  - name: reserve
+    branches:
+      - name: hotel
+        action: reserveHotel
+        compensation: cancelHotel
+        pivot: true
+`), registry)
+
+	assert.EqualError(t, err, `line 5, column 12: must be a boolean
+line 9, column 5: duplicate pivot, already set line 6
+line 11, column 19: a step following the pivot can't have a compensation
+line 17, column 23: a step following the pivot can't have a compensation
+line 18, column 9: unknown field "pivot"`)
+}
+
+func Test_Parse_with_an_invalid_structure(t *testing.T) {
+	registry, _ := testRegistry()
+
+	_, err := Parse([]byte(`steps: {}`), registry)
+	assert.EqualError(t, err, "line 1, column 8: must be a list")
+
+	_, err = Parse([]byte(`steps: []`), registry)
+	assert.EqualError(t, err, "line 1, column 8: must contain at least one step")
+
+	_, err = Parse([]byte(`foo: bar`), registry)
+	assert.EqualError(t, err, "line 1, column 1: unknown field \"foo\"\nline 1, column 1: missing \"steps\"")
+
+	_, err = Parse([]byte(`[]`), registry)
+	assert.EqualError(t, err, "line 1, column 1: must be an object")
+
+	_, err = Parse([]byte(`steps: [foo]`), registry)
+	assert.EqualError(t, err, "line 1, column 9: must be an object")
+}
+
+func Test_Parse_with_an_empty_definition(t *testing.T) {
+	_, err := Parse([]byte(``), NewActionRegistry())
+
+	assert.EqualError(t, err, "line 1, column 1: empty definition")
+}
+
+func Test_Parse_with_a_syntax_error(t *testing.T) {
+	_, err := Parse([]byte("steps:\n  - name: [debit\n"), NewActionRegistry())
+
+	assert.ErrorContains(t, err, "invalid definition: yaml: line ")
+}
+
+func Test_ParseFile_success(t *testing.T) {
+	registry, calls := testRegistry()
+
+	path := filepath.Join(t.TempDir(), "saga.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("steps:\n  - name: debit\n    action: debit\n"), 0o600))
+
+	def, err := ParseFile(path, registry)
+	require.NoError(t, err)
+
+	err = def.NewSEC(storage.NewMemory()).StartSaga(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"debit"}, *calls)
+}
+
+func Test_ParseFile_with_a_missing_file(t *testing.T) {
+	def, err := ParseFile(filepath.Join(t.TempDir(), "saga.yaml"), NewActionRegistry())
+
+	assert.Nil(t, def)
+	assert.ErrorContains(t, err, "failed to read the definition: ")
+}
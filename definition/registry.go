@@ -0,0 +1,33 @@
+package definition
+
+import (
+	"github.com/Peltoche/gosaga"
+)
+
+// ActionRegistry reference by name the Actions and Compensations usable into
+// the definitions.
+type ActionRegistry struct {
+	actions map[string]gosaga.Action
+}
+
+// NewActionRegistry instantiate a new empty ActionRegistry.
+func NewActionRegistry() *ActionRegistry {
+	return &ActionRegistry{
+		actions: map[string]gosaga.Action{},
+	}
+}
+
+// Register add the given Action under the given name. An Action already
+// registered with the same name is replaced.
+func (t *ActionRegistry) Register(name string, action gosaga.Action) *ActionRegistry {
+	t.actions[name] = action
+
+	return t
+}
+
+// Get return the Action registered with the given name.
+func (t *ActionRegistry) Get(name string) (gosaga.Action, bool) {
+	action, ok := t.actions[name]
+
+	return action, ok
+}
@@ -0,0 +1,43 @@
+package definition
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ActionRegistry_Register_success(t *testing.T) {
+	registry := NewActionRegistry().
+		Register("debit", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Success(json.RawMessage(`"debit"`))
+		})
+
+	action, ok := registry.Get("debit")
+	require.True(t, ok)
+	assert.Equal(t, json.RawMessage(`"debit"`), action(context.Background(), nil).Context())
+}
+
+func Test_ActionRegistry_Register_should_replace_the_previous_action(t *testing.T) {
+	registry := NewActionRegistry().
+		Register("debit", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Success(json.RawMessage(`"first"`))
+		}).
+		Register("debit", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Success(json.RawMessage(`"second"`))
+		})
+
+	action, ok := registry.Get("debit")
+	require.True(t, ok)
+	assert.Equal(t, json.RawMessage(`"second"`), action(context.Background(), nil).Context())
+}
+
+func Test_ActionRegistry_Get_with_an_unknown_name(t *testing.T) {
+	action, ok := NewActionRegistry().Get("some-unknown-name")
+
+	assert.False(t, ok)
+	assert.Nil(t, action)
+}
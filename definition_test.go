@@ -0,0 +1,137 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SEC_StartNamedSaga_should_use_the_last_version(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("default", calls.record("default", Success(nil)), nil).
+		RegisterSaga(NewSagaDefinition("payment", 2).
+			AppendNewSubRequest("debit", calls.record("debit-v2", Success(nil)), nil)).
+		RegisterSaga(NewSagaDefinition("payment", 1).
+			AppendNewSubRequest("debit", calls.record("debit-v1", Success(nil)), nil)).
+		RegisterSaga(NewSagaDefinition("booking", 1).
+			AppendNewStage("reserve", []Branch{
+				NewBranch("hotel", calls.record("hotel", Success(nil)), nil),
+			}))
+
+	err := scheduler.StartNamedSaga(context.Background(), "payment", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	err = scheduler.StartNamedSaga(context.Background(), "booking", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	// The SEC Sub-Requests are still used by StartSaga.
+	err = scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"debit-v2", "hotel", "default"}, calls.names)
+}
+
+func Test_SEC_StartNamedSaga_with_an_unknown_definition(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		RegisterSaga(NewSagaDefinition("payment", 1))
+
+	err := scheduler.StartNamedSaga(context.Background(), "some-unknown-saga", json.RawMessage(`{}`))
+	assert.EqualError(t, err, `unknown saga definition "some-unknown-saga"`)
+}
+
+func Test_SEC_SubmitNamed_success(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		RegisterSaga(NewSagaDefinition("payment", 1).
+			AppendNewSubRequest("debit", calls.record("debit", Success(json.RawMessage(`{"debited": true}`))), nil))
+
+	sagaID, err := scheduler.SubmitNamed(context.Background(), "payment", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, json.RawMessage(`{"debited": true}`), outcome.Context)
+
+	// The definition is saved with the saga.
+	eventLogs, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, "payment", eventLogs[0].SagaType)
+	assert.Equal(t, 1, eventLogs[0].SagaVersion)
+
+	_, err = scheduler.SubmitNamed(context.Background(), "some-unknown-saga", json.RawMessage(`{}`))
+	assert.EqualError(t, err, `unknown saga definition "some-unknown-saga"`)
+}
+
+func Test_SEC_Recover_should_use_the_definition_version_of_the_saga(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	// The saga have been started with the version 1 and interrupted after
+	// the "debit" Sub-Request.
+	events := []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{}`), SagaType: "payment", SagaVersion: 1},
+		{SagaID: "some-saga-id", Step: "debit", State: "running", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "debit", State: "done", Context: json.RawMessage(`{}`)},
+	}
+	for i := range events {
+		require.NoError(t, memory.SaveEventLog(ctx, &events[i]))
+	}
+
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(memory).
+		RegisterSaga(NewSagaDefinition("payment", 1).
+			AppendNewSubRequest("debit", calls.record("debit-v1", Success(nil)), nil).
+			AppendNewSubRequest("credit", calls.record("credit-v1", Success(nil)), nil)).
+		RegisterSaga(NewSagaDefinition("payment", 2).
+			AppendNewSubRequest("check", calls.record("check-v2", Success(nil)), nil).
+			AppendNewSubRequest("transfer", calls.record("transfer-v2", Success(nil)), nil))
+
+	err := scheduler.Recover(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"credit-v1"}, calls.names)
+}
+
+func Test_SEC_Recover_with_an_unregistered_definition_version(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	events := []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{}`), SagaType: "payment", SagaVersion: 1},
+	}
+	for i := range events {
+		require.NoError(t, memory.SaveEventLog(ctx, &events[i]))
+	}
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		RegisterSaga(NewSagaDefinition("payment", 2).
+			AppendNewSubRequest("debit", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+				return Success(sagaCtx)
+			}, nil))
+
+	err := scheduler.Recover(ctx)
+	assert.EqualError(t, err, `failed to recover the saga "some-saga-id": unknown saga definition "payment" version 1`)
+
+	// The saga is kept for a later run with the right definition.
+	unfinished, err := memory.GetUnfinishedSagaIDs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"some-saga-id"}, unfinished)
+}
+
+func Test_SEC_RegisterSaga_without_name_should_panic(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	assert.PanicsWithValue(t, "gosaga: a saga definition must have a name", func() {
+		scheduler.RegisterSaga(NewSagaDefinition("", 1))
+	})
+}
@@ -0,0 +1,146 @@
+package gosaga
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxCompensationErrors is the number of Compensation errors kept by a
+// SagaAbortedError: the first one and the last ones.
+const maxCompensationErrors = 10
+
+// errInterrupted is the cause of the Actions interrupted by a restart.
+var errInterrupted = errors.New("the action have been interrupted by a restart")
+
+// SagaAbortedError is returned for the sagas aborted by a failing Action.
+//
+// It wraps the error given to Failure by the Action so it can be inspected
+// with errors.Is and errors.As.
+type SagaAbortedError struct {
+	SagaID string
+
+	// SubRequestID is the Sub-Request with the failing Action.
+	SubRequestID string
+
+	// Err is the error returned by the failing Action. It is nil if the
+	// failure happened before a restart.
+	Err error
+
+	// CompensationErrors contains the errors returned by the failed
+	// Compensation attempts. Only the first error and the 9 last ones are
+	// kept.
+	CompensationErrors []error
+
+	// CompensationFailures is the number of failed Compensation attempts.
+	CompensationFailures int
+
+	// Stuck is true if the saga is waiting for a manual intervention because
+	// a Compensation have exhausted its RetryPolicy.
+	Stuck bool
+}
+
+// Error implements the error interface.
+func (t *SagaAbortedError) Error() string {
+	var msg strings.Builder
+
+	if t.Stuck {
+		fmt.Fprintf(&msg, "saga %q is stuck and needs a manual intervention", t.SagaID)
+	} else {
+		fmt.Fprintf(&msg, "saga %q have been compensated", t.SagaID)
+	}
+
+	if t.SubRequestID != "" {
+		fmt.Fprintf(&msg, ": sub-request %q failed", t.SubRequestID)
+	}
+
+	if t.Err != nil {
+		fmt.Fprintf(&msg, ": %s", t.Err)
+	}
+
+	if len(t.CompensationErrors) > 0 {
+		fmt.Fprintf(&msg, " (%d compensation errors, last: %s)", max(t.CompensationFailures, len(t.CompensationErrors)), t.CompensationErrors[len(t.CompensationErrors)-1])
+	}
+
+	return msg.String()
+}
+
+// Unwrap return the error returned by the failing Action.
+func (t *SagaAbortedError) Unwrap() error {
+	return t.Err
+}
+
+// resultErr return the error given to Failure.
+func resultErr(result Result) error {
+	failure, ok := result.(interface{ Err() error })
+	if !ok || failure.Err() == nil {
+		return errors.New("unknown failure")
+	}
+
+	return failure.Err()
+}
+
+// recordActionFailure save the cause of the saga abort.
+func (t *SEC) recordActionFailure(sagaID string, subRequestID string, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.failures == nil {
+		t.failures = map[string]*SagaAbortedError{}
+	}
+
+	t.failures[sagaID] = &SagaAbortedError{
+		SagaID:       sagaID,
+		SubRequestID: subRequestID,
+		Err:          err,
+	}
+}
+
+// recordCompensationFailure save the error of a failed Compensation attempt.
+func (t *SEC) recordCompensationFailure(sagaID string, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.failures == nil {
+		t.failures = map[string]*SagaAbortedError{}
+	}
+
+	failure, ok := t.failures[sagaID]
+	if !ok {
+		failure = &SagaAbortedError{SagaID: sagaID}
+		t.failures[sagaID] = failure
+	}
+
+	failure.CompensationFailures++
+	if len(failure.CompensationErrors) == maxCompensationErrors {
+		// Drop the oldest error after the first one.
+		failure.CompensationErrors = append(failure.CompensationErrors[:1], failure.CompensationErrors[2:]...)
+	}
+	failure.CompensationErrors = append(failure.CompensationErrors, err)
+}
+
+// abortedError return the error for an aborted saga.
+func (t *SEC) abortedError(sagaID string, stuck bool) *SagaAbortedError {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	res := &SagaAbortedError{SagaID: sagaID}
+	if failure, ok := t.failures[sagaID]; ok {
+		*res = *failure
+		if failure.CompensationErrors != nil {
+			res.CompensationErrors = append([]error{}, failure.CompensationErrors...)
+		}
+	}
+	res.Stuck = stuck
+
+	return res
+}
+
+// forgetFailure remove the failure recorded for the given saga once it is
+// finished.
+func (t *SEC) forgetFailure(sagaID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.failures, sagaID)
+}
@@ -0,0 +1,133 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errInsufficientFunds = errors.New("insufficient funds")
+
+func Test_SagaAbortedError_Error(t *testing.T) {
+	err := &SagaAbortedError{
+		SagaID:             "some-saga-id",
+		SubRequestID:       "step1",
+		Err:                errors.New("some-error"),
+		CompensationErrors: []error{errors.New("some-compensation-error")},
+	}
+
+	assert.EqualError(t, err, `saga "some-saga-id" have been compensated: sub-request "step1" failed: some-error (1 compensation errors, last: some-compensation-error)`)
+
+	err = &SagaAbortedError{SagaID: "some-saga-id", Stuck: true}
+
+	assert.EqualError(t, err, `saga "some-saga-id" is stuck and needs a manual intervention`)
+}
+
+func Test_SEC_StartSaga_with_a_compensated_saga_should_return_a_SagaAbortedError(t *testing.T) {
+	compensationAttempts := 0
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("debit", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			compensationAttempts++
+			if compensationAttempts == 1 {
+				return Failure(errors.New("some-compensation-error"), sagaCtx)
+			}
+
+			return Success(sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 2})).
+		AppendNewSubRequest("credit", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(fmt.Errorf("failed to credit: %w", errInsufficientFunds), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		})
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+
+	assert.True(t, errors.Is(err, errInsufficientFunds))
+
+	var abortErr *SagaAbortedError
+	require.True(t, errors.As(err, &abortErr))
+	assert.NotEmpty(t, abortErr.SagaID)
+	assert.Equal(t, "credit", abortErr.SubRequestID)
+	assert.False(t, abortErr.Stuck)
+	assert.Equal(t, []error{errors.New("some-compensation-error")}, abortErr.CompensationErrors)
+}
+
+func Test_SEC_StartSaga_with_a_committed_saga_should_return_nil(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("debit", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	assert.NoError(t, err)
+}
+
+func Test_SEC_Recover_with_an_interrupted_action_should_record_its_failure(t *testing.T) {
+	memory := storage.NewMemory()
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+	for _, event := range []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx},
+		{SagaID: "some-saga-id", Step: "debit", State: "running", Context: sagaCtx},
+	} {
+		require.NoError(t, memory.SaveEventLog(context.Background(), &event))
+	}
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("debit", nil, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-compensation-error"), sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 1}))
+
+	err := scheduler.Recover(context.Background())
+	assert.NoError(t, err)
+
+	// The saga is stuck so its failure is kept for the operators.
+	assert.Equal(t, &SagaAbortedError{
+		SagaID:               "some-saga-id",
+		SubRequestID:         "debit",
+		Err:                  errInterrupted,
+		CompensationErrors:   []error{errors.New("some-compensation-error")},
+		CompensationFailures: 1,
+		Stuck:                true,
+	}, scheduler.abortedError("some-saga-id", true))
+}
+
+func Test_SEC_recordCompensationFailure_should_keep_the_first_and_last_errors(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	for i := 1; i <= 15; i++ {
+		scheduler.recordCompensationFailure("some-saga-id", fmt.Errorf("error-%d", i))
+	}
+
+	res := scheduler.abortedError("some-saga-id", true)
+	assert.Equal(t, 15, res.CompensationFailures)
+	assert.Equal(t, []error{
+		errors.New("error-1"),
+		errors.New("error-7"),
+		errors.New("error-8"),
+		errors.New("error-9"),
+		errors.New("error-10"),
+		errors.New("error-11"),
+		errors.New("error-12"),
+		errors.New("error-13"),
+		errors.New("error-14"),
+		errors.New("error-15"),
+	}, res.CompensationErrors)
+	assert.EqualError(t, res, `saga "some-saga-id" is stuck and needs a manual intervention (15 compensation errors, last: error-15)`)
+}
+
+func Test_resultErr(t *testing.T) {
+	assert.EqualError(t, resultErr(Failure(errors.New("some-error"), nil)), "some-error")
+	assert.EqualError(t, resultErr(Failure(nil, nil)), "unknown failure")
+	assert.EqualError(t, resultErr(Success(nil)), "unknown failure")
+}
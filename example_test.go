@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"github.com/Peltoche/gosaga/storage"
 )
@@ -18,56 +19,70 @@ type request struct {
 
 func Example() {
 	sagaLog := storage.NewMemory()
+	logs := new(recordHandler)
 
 	foo = 50
 	bar = 50
 
 	saga := NewSagaExecutionCoordinator(sagaLog).
+		WithLogger(slog.New(logs)).
 		AppendNewSubRequest("debit", debitAction, debitCompensation).
 		AppendNewSubRequest("credit", creditAction, creditCompensation)
 
 	saga.StartSaga(context.Background(), json.RawMessage(`{"amount": 10}`))
+
+	for _, line := range logs.Lines() {
+		fmt.Println(line)
+	}
 	// Output:
-	// step: _init / done
-	// exec: debit
 	// Foo 50 -> 40
-	// step: debit / done
-	// exec: credit
 	// Bar 50 -> 60
-	// step: credit / done
-	// mark saga as done
-	// delete saga
+	// DEBUG run sub-request sub_request_id=debit phase=action
+	// INFO sub-request succeeded sub_request_id=debit phase=action outcome=success
+	// DEBUG run sub-request sub_request_id=credit phase=action
+	// INFO sub-request succeeded sub_request_id=credit phase=action outcome=success
+	// INFO saga finished outcome=committed
 }
 
 func Example_with_abort() {
 	sagaLog := storage.NewMemory()
+	logs := new(recordHandler)
 
 	foo = 50
 	bar = 50
 
 	saga := NewSagaExecutionCoordinator(sagaLog).
+		WithLogger(slog.New(logs)).
 		// The credit step will fail and the debit step will be automatically
 		// rollback.
 		AppendNewSubRequest("debit", debitAction, debitCompensation).
 		AppendNewSubRequest("credit", actionReturningAFailure, creditCompensation)
 
-	saga.StartSaga(context.Background(), json.RawMessage(`{"amount": 10}`))
+	// The key is used as saga ID.
+	_, err := saga.StartSagaWithKey(context.Background(), "some-saga-id", json.RawMessage(`{"amount": 10}`))
+
+	for _, line := range logs.Lines() {
+		fmt.Println(line)
+	}
+
+	var abortedErr *SagaAbortedError
+	if errors.As(err, &abortedErr) {
+		fmt.Printf("saga %s aborted by %s: %s\n", abortedErr.SagaID, abortedErr.SubRequestID, abortedErr.Err)
+	}
 	// Output:
-	// step: _init / done
-	// exec: debit
 	// Foo 50 -> 40
-	// step: debit / done
-	// exec: credit
-	// failed "debit"
-	// revert step: credit / aborted
-	// revert : credit
 	// Revert Bar 50 -> 60
-	// revert step: credit / done
-	// revert : debit
 	// Revert Foo 40 -> 50
-	// revert step: debit / done
-	// mark saga as done
-	// delete saga
+	// DEBUG run sub-request sub_request_id=debit phase=action
+	// INFO sub-request succeeded sub_request_id=debit phase=action outcome=success
+	// DEBUG run sub-request sub_request_id=credit phase=action
+	// WARN sub-request failed sub_request_id=credit phase=action outcome=aborted error=some-error
+	// DEBUG run sub-request sub_request_id=credit phase=compensation
+	// INFO sub-request succeeded sub_request_id=credit phase=compensation outcome=success
+	// DEBUG run sub-request sub_request_id=debit phase=compensation
+	// INFO sub-request succeeded sub_request_id=debit phase=compensation outcome=success
+	// INFO saga finished outcome=compensated
+	// saga some-saga-id aborted by credit: some-error
 }
 
 func debitAction(ctx context.Context, sagaCtx json.RawMessage) Result {
@@ -131,3 +146,27 @@ func creditCompensation(ctx context.Context, sagaCtx json.RawMessage) Result {
 func actionReturningAFailure(ctx context.Context, sagaCtx json.RawMessage) Result {
 	return Failure(errors.New("some-error"), sagaCtx)
 }
+
+func Example_typed() {
+	type transfer struct {
+		Amount uint `json:"amount"`
+	}
+
+	type receipt struct {
+		Debited uint `json:"debited"`
+	}
+
+	debit := NewStep("debit", func(ctx context.Context, in transfer) (receipt, error) {
+		return receipt{Debited: in.Amount}, nil
+	}, func(ctx context.Context, in transfer, out *receipt) error {
+		return nil
+	})
+
+	saga := Then(NewSaga[transfer](storage.NewMemory()), debit)
+
+	out, err := saga.Start(context.Background(), transfer{Amount: 10})
+
+	fmt.Println(out.Debited, err)
+	// Output:
+	// 10 <nil>
+}
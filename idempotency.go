@@ -0,0 +1,234 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+)
+
+// ErrSagaAlreadyExists is wrapped by the errors returned when a saga is
+// created with the key of an existing saga.
+var ErrSagaAlreadyExists = model.ErrSagaAlreadyExists
+
+// existingSagaPollInterval is the delay between two reads of an existing saga
+// run by another SEC and waited by StartSagaWithKey.
+const existingSagaPollInterval = 100 * time.Millisecond
+
+// StartSagaWithKey create a new saga identified by the given idempotency key
+// and run it as StartSaga. The key is used as saga ID.
+//
+// If a saga with the same key already exists into the storage, no saga is
+// created: the existing saga is waited until it is done or stuck, whatever
+// the SEC running it, and its Outcome is returned. The Outcome Err is also
+// returned if the saga have been compensated or is stuck, as with Wait. A
+// saga run by this SEC is waited in memory, a saga run by another SEC is read
+// from the storage every 100ms.
+//
+// An existing saga not run by any SEC, as an unfinished saga not recovered
+// yet, is waited until ctx is done: ctx should have a deadline.
+//
+// The keys are unique until the saga is compacted from the storage: once
+// compacted its key is free and a retry with the same key starts a new saga.
+// The sagas must so be kept longer than the period the callers can retry
+// their requests. As the keys are used as saga IDs they are shared by all the
+// sagas of the storage,
+// whatever their definition: a key used by a saga of another definition is
+// refused with an error wrapping ErrSagaAlreadyExists. Prefix the keys with
+// the definition name in order to use the same key for several definitions.
+func (t *SEC) StartSagaWithKey(ctx context.Context, key string, sagaCtx json.RawMessage) (*Outcome, error) {
+	return t.startSagaWithKey(ctx, key, "", 0, sagaCtx)
+}
+
+// StartNamedSagaWithKey create a new saga with the last version of the given
+// definition, identified by the given idempotency key, and run it. See
+// StartSagaWithKey.
+func (t *SEC) StartNamedSagaWithKey(ctx context.Context, key string, name string, sagaCtx json.RawMessage) (*Outcome, error) {
+	version, err := t.lastVersion(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.startSagaWithKey(ctx, key, name, version, sagaCtx)
+}
+
+func (t *SEC) startSagaWithKey(ctx context.Context, key string, sagaType string, sagaVersion int, sagaCtx json.RawMessage) (*Outcome, error) {
+	if key == "" {
+		return nil, errors.New("the idempotency key is empty")
+	}
+
+	outcome, err := t.startNewSaga(ctx, key, sagaType, sagaVersion, sagaCtx)
+	if errors.Is(err, ErrSagaAlreadyExists) {
+		return t.waitExistingSaga(ctx, key, sagaType)
+	}
+
+	return outcome, err
+}
+
+// SubmitWithKey create a new saga identified by the given idempotency key and
+// schedule it as Submit. The key is used as saga ID, see StartSagaWithKey for
+// its scope.
+//
+// If a saga with the same key already exists into the storage, nothing is
+// scheduled and the key is returned with an error wrapping
+// ErrSagaAlreadyExists. The existing saga can be inspected with
+// GetSagaSummary.
+func (t *SEC) SubmitWithKey(ctx context.Context, key string, sagaCtx json.RawMessage) (string, error) {
+	return t.submitSagaWithKey(ctx, key, "", 0, sagaCtx)
+}
+
+// SubmitNamedWithKey create a new saga with the last version of the given
+// definition, identified by the given idempotency key, and schedule it into
+// the worker pool. See SubmitWithKey.
+func (t *SEC) SubmitNamedWithKey(ctx context.Context, key string, name string, sagaCtx json.RawMessage) (string, error) {
+	version, err := t.lastVersion(name)
+	if err != nil {
+		return "", err
+	}
+
+	return t.submitSagaWithKey(ctx, key, name, version, sagaCtx)
+}
+
+func (t *SEC) submitSagaWithKey(ctx context.Context, key string, sagaType string, sagaVersion int, sagaCtx json.RawMessage) (string, error) {
+	if key == "" {
+		return "", errors.New("the idempotency key is empty")
+	}
+
+	sagaID, err := t.submitNewSaga(ctx, key, sagaType, sagaVersion, sagaCtx)
+	if errors.Is(err, ErrSagaAlreadyExists) {
+		return key, err
+	}
+
+	return sagaID, err
+}
+
+// waitExistingSaga wait the given saga until it is done or stuck and return
+// its Outcome. The saga must have been started with the given definition.
+func (t *SEC) waitExistingSaga(ctx context.Context, sagaID string, sagaType string) (*Outcome, error) {
+	for {
+		eventLogs, err := t.journal.GetSagaHistory(ctx, sagaID)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(eventLogs) > 0 && eventLogs[0].SagaType != sagaType {
+			return nil, fmt.Errorf("%w: %q is used by a saga of definition %q", ErrSagaAlreadyExists, sagaID, eventLogs[0].SagaType)
+		}
+
+		if outcome := sagaOutcome(sagaID, eventLogs); outcome != nil {
+			return outcome, outcome.Err
+		}
+
+		// A saga run by this SEC is waited without reading the storage
+		// again.
+		if pending := t.getRunning(sagaID); pending != nil {
+			select {
+			case <-pending.done:
+			case <-ctx.Done():
+				return nil, fmt.Errorf("interrupted while waiting the saga %q: %s", sagaID, ctx.Err())
+			}
+
+			if pending.err != nil {
+				return nil, pending.err
+			}
+
+			return pending.outcome, pending.outcome.Err
+		}
+
+		err = sleep(ctx, existingSagaPollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("interrupted while waiting the saga %q: %s", sagaID, err)
+		}
+	}
+}
+
+// markRunning register the given saga as run by the SEC.
+func (t *SEC) markRunning(sagaID string) *pendingOutcome {
+	pending := &pendingOutcome{done: make(chan struct{})}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.running == nil {
+		t.running = map[string]*pendingOutcome{}
+	}
+	t.running[sagaID] = pending
+
+	return pending
+}
+
+// markFinished notify the end of the given saga run to its waiters.
+func (t *SEC) markFinished(sagaID string, pending *pendingOutcome, outcome *Outcome, err error) {
+	pending.outcome, pending.err = outcome, err
+	close(pending.done)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.running[sagaID] == pending {
+		delete(t.running, sagaID)
+	}
+}
+
+// getRunning return the pending Outcome of the given saga if it is run or
+// submitted to the SEC, nil otherwise.
+func (t *SEC) getRunning(sagaID string) *pendingOutcome {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if pending, ok := t.running[sagaID]; ok {
+		return pending
+	}
+
+	return t.outcomes[sagaID]
+}
+
+// sagaOutcome compute the Outcome of a saga from its eventlogs. It returns
+// nil if the saga is neither done nor stuck.
+//
+// The failure is rebuilt from the eventlogs so the SagaAbortedError contains
+// only the failing Action reason.
+func sagaOutcome(sagaID string, eventLogs []model.EventLog) *Outcome {
+	status := model.ComputeStatus(eventLogs)
+	if status != "done" && status != "stuck" {
+		return nil
+	}
+
+	outcome := &Outcome{SagaID: sagaID, Status: "committed"}
+
+	var failure *SagaAbortedError
+	for _, eventLog := range eventLogs {
+		if eventLog.Branch != "" || eventLog.Step == "_cancel" || eventLog.Step == "_finish" {
+			continue
+		}
+
+		outcome.Context = eventLog.Context
+
+		if eventLog.State == "aborted" {
+			failure = &SagaAbortedError{SagaID: sagaID, SubRequestID: eventLog.Step}
+			if eventLog.Reason != "" {
+				failure.Err = errors.New(eventLog.Reason)
+			}
+		}
+	}
+
+	switch {
+	case status == "stuck":
+		outcome.Status = "stuck"
+	case failure != nil:
+		outcome.Status = "compensated"
+	default:
+		return outcome
+	}
+
+	if failure == nil {
+		failure = &SagaAbortedError{SagaID: sagaID}
+	}
+	failure.Stuck = status == "stuck"
+	outcome.Err = failure
+
+	return outcome
+}
@@ -0,0 +1,332 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SEC_StartSagaWithKey_success(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{"step": 1}`))), nil)
+
+	outcome, err := scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "some-key", outcome.SagaID)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.JSONEq(t, `{"step": 1}`, string(outcome.Context))
+
+	// The saga is not run again, its Outcome is read from the storage.
+	outcome, err = scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "some-key", outcome.SagaID)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.JSONEq(t, `{"step": 1}`, string(outcome.Context))
+
+	assert.Equal(t, []string{"step1"}, calls.sorted())
+
+	events, err := memory.GetSagaEventLogs(context.Background(), "some-key")
+	require.NoError(t, err)
+	assert.Equal(t, "_init", events[0].Step)
+	assert.Equal(t, "_finish", events[len(events)-1].Step)
+}
+
+func Test_SEC_StartSagaWithKey_with_a_compensated_saga(t *testing.T) {
+	calls := newCalls()
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{}`))), calls.record("undo-step1", Success(nil))).
+		AppendNewSubRequest("step2", calls.record("step2", Failure(errors.New("some-error"), nil)), nil)
+
+	outcome, err := scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	assert.Equal(t, "compensated", outcome.Status)
+	assert.EqualError(t, err, `saga "some-key" have been compensated: sub-request "step2" failed: some-error`)
+
+	outcome, err = scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	assert.Equal(t, "compensated", outcome.Status)
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "some-key", abortedErr.SagaID)
+	assert.Equal(t, "step2", abortedErr.SubRequestID)
+	assert.EqualError(t, abortedErr.Err, "some-error")
+	assert.False(t, abortedErr.Stuck)
+
+	assert.Equal(t, []string{"step1", "step2", "undo-step1"}, calls.sorted())
+}
+
+func Test_SEC_StartSagaWithKey_with_a_stuck_saga(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", newCalls().record("step1", Success(json.RawMessage(`{}`))), newCalls().record("undo-step1", Failure(errors.New("some-compensation-error"), nil)), WithCompensationRetry(RetryPolicy{MaxAttempts: 1})).
+		AppendNewSubRequest("step2", newCalls().record("step2", Failure(errors.New("some-error"), nil)), nil)
+
+	outcome, err := scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	assert.Equal(t, "stuck", outcome.Status)
+	assert.Error(t, err)
+
+	// The stuck saga is not waited.
+	outcome, err = scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	assert.Equal(t, "stuck", outcome.Status)
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.True(t, abortedErr.Stuck)
+	assert.Equal(t, "step2", abortedErr.SubRequestID)
+}
+
+func Test_SEC_StartSagaWithKey_with_concurrent_calls(t *testing.T) {
+	attempts := new(atomic.Int32)
+	release := make(chan struct{})
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			attempts.Add(1)
+			<-release
+			return Success(sagaCtx)
+		}, nil)
+
+	wg := new(sync.WaitGroup)
+	outcomes := make(chan *Outcome, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			outcome, err := scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+			assert.NoError(t, err)
+			outcomes <- outcome
+		}()
+	}
+
+	// Let the duplicated calls reach the storage.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(outcomes)
+
+	for outcome := range outcomes {
+		assert.Equal(t, "some-key", outcome.SagaID)
+		assert.Equal(t, "committed", outcome.Status)
+	}
+
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+// countingStorage count the eventlogs reads of a saga.
+type countingStorage struct {
+	journal.Storage
+	reads atomic.Int32
+}
+
+func (t *countingStorage) GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	t.reads.Add(1)
+
+	return t.Storage.GetSagaEventLogs(ctx, sagaID)
+}
+
+func Test_SEC_StartSagaWithKey_with_a_saga_run_by_the_same_SEC(t *testing.T) {
+	memory := &countingStorage{Storage: storage.NewMemory()}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			close(started)
+			<-release
+			return Success(json.RawMessage(`{"done": true}`))
+		}, nil)
+
+	go func() {
+		_, err := scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+		assert.NoError(t, err)
+	}()
+
+	<-started
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	outcome, err := scheduler.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.JSONEq(t, `{"done": true}`, string(outcome.Context))
+
+	// The saga is read only once, its end is waited in memory.
+	assert.Equal(t, int32(1), memory.reads.Load())
+}
+
+func Test_SEC_StartSagaWithKey_with_a_saga_run_by_another_SEC(t *testing.T) {
+	memory := storage.NewMemory()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	first := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			close(started)
+			<-release
+			return Success(json.RawMessage(`{"done": true}`))
+		}, nil)
+
+	sagaID, err := first.SubmitWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "some-key", sagaID)
+
+	<-started
+
+	calls := newCalls()
+	second := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", calls.record("step1", Success(nil)), nil)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+
+	outcome, err := second.StartSagaWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.JSONEq(t, `{"done": true}`, string(outcome.Context))
+	assert.Empty(t, calls.sorted())
+
+	_, err = first.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+}
+
+func Test_SEC_StartSagaWithKey_with_a_canceled_context(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	// The saga is never finished.
+	err := memory.SaveEventLog(ctx, &model.EventLog{SagaID: "some-key", Step: "_init", State: "done"})
+	require.NoError(t, err)
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", newCalls().record("step1", Success(nil)), nil)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+
+	_, err = scheduler.StartSagaWithKey(ctx, "some-key", json.RawMessage(`{}`))
+	assert.EqualError(t, err, `interrupted while waiting the saga "some-key": context deadline exceeded`)
+}
+
+func Test_SEC_StartNamedSagaWithKey_success(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		RegisterSaga(NewSagaDefinition("payment", 1).
+			AppendNewSubRequest("step1", calls.record("v1", Success(nil)), nil)).
+		RegisterSaga(NewSagaDefinition("payment", 2).
+			AppendNewSubRequest("step1", calls.record("v2", Success(json.RawMessage(`{"step": 1}`))), nil))
+
+	outcome, err := scheduler.StartNamedSagaWithKey(context.Background(), "some-key", "payment", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+
+	outcome, err = scheduler.StartNamedSagaWithKey(context.Background(), "some-key", "payment", json.RawMessage(`{}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"step": 1}`, string(outcome.Context))
+
+	sagaID, err := scheduler.SubmitNamedWithKey(context.Background(), "some-key", "payment", json.RawMessage(`{}`))
+	assert.Equal(t, "some-key", sagaID)
+	assert.ErrorIs(t, err, ErrSagaAlreadyExists)
+
+	assert.Equal(t, []string{"v2"}, calls.sorted())
+
+	summary, err := scheduler.GetSagaSummary(context.Background(), "some-key")
+	require.NoError(t, err)
+	assert.Equal(t, "payment", summary.SagaType)
+	assert.Equal(t, 2, summary.SagaVersion)
+}
+
+func Test_SEC_StartNamedSagaWithKey_with_a_key_used_by_another_definition(t *testing.T) {
+	calls := newCalls()
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		RegisterSaga(NewSagaDefinition("payment", 1).
+			AppendNewSubRequest("step1", calls.record("payment", Success(nil)), nil)).
+		RegisterSaga(NewSagaDefinition("shipment", 1).
+			AppendNewSubRequest("step1", calls.record("shipment", Success(nil)), nil))
+
+	_, err := scheduler.StartNamedSagaWithKey(context.Background(), "some-key", "payment", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.StartNamedSagaWithKey(context.Background(), "some-key", "shipment", json.RawMessage(`{}`))
+	assert.ErrorIs(t, err, ErrSagaAlreadyExists)
+	assert.EqualError(t, err, `saga already exists: "some-key" is used by a saga of definition "payment"`)
+
+	assert.Equal(t, []string{"payment"}, calls.sorted())
+}
+
+func Test_SEC_StartNamedSagaWithKey_with_an_unknown_definition(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	_, err := scheduler.StartNamedSagaWithKey(context.Background(), "some-key", "payment", json.RawMessage(`{}`))
+	assert.EqualError(t, err, `unknown saga definition "payment"`)
+
+	_, err = scheduler.SubmitNamedWithKey(context.Background(), "some-key", "payment", json.RawMessage(`{}`))
+	assert.EqualError(t, err, `unknown saga definition "payment"`)
+}
+
+func Test_SEC_StartSagaWithKey_with_an_empty_key(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	_, err := scheduler.StartSagaWithKey(context.Background(), "", json.RawMessage(`{}`))
+	assert.EqualError(t, err, "the idempotency key is empty")
+
+	_, err = scheduler.SubmitWithKey(context.Background(), "", json.RawMessage(`{}`))
+	assert.EqualError(t, err, "the idempotency key is empty")
+}
+
+func Test_SEC_SubmitWithKey_with_an_existing_saga(t *testing.T) {
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", calls.record("step1", Success(nil)), nil)
+
+	sagaID, err := scheduler.SubmitWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	sagaID, err = scheduler.SubmitWithKey(context.Background(), "some-key", json.RawMessage(`{}`))
+	assert.Equal(t, "some-key", sagaID)
+	assert.ErrorIs(t, err, ErrSagaAlreadyExists)
+	assert.EqualError(t, err, `saga already exists: "some-key"`)
+
+	assert.Equal(t, []string{"step1"}, calls.sorted())
+}
+
+func Test_SEC_SubmitWithKey_with_journal_CreateSaga_error_should_fail(t *testing.T) {
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	journal := new(journal.Mock)
+	subRequest := new(SubRequestMock)
+	scheduler := &SEC{subRequestDefs: []subRequestDef{}, journal: journal, workers: make(chan struct{}, 1)}
+	scheduler.AppendNewSubRequest("step1", subRequest.Action, subRequest.Compensation)
+
+	journal.On("CreateSaga", "some-key", "", 0, sagaCtx, map[string]string{}).Return(errors.New("some-error")).Once()
+
+	sagaID, err := scheduler.SubmitWithKey(context.Background(), "some-key", sagaCtx)
+	assert.EqualError(t, err, "failed to create a new saga: some-error")
+	assert.Empty(t, sagaID)
+
+	journal.AssertExpectations(t)
+	subRequest.AssertExpectations(t)
+}
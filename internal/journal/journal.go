@@ -5,62 +5,155 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/Peltoche/gosaga/model"
 	uuid "github.com/satori/go.uuid"
 )
 
 // Storage is the driver used to save the eventlogs in a persistent way.
+//
+// SaveEventLog must refuse atomically an "_init" eventlog for an existing saga
+// with an error wrapping model.ErrSagaAlreadyExists.
 type Storage interface {
 	SaveEventLog(ctx context.Context, state *model.EventLog) error
+	GetUnfinishedSagaIDs(ctx context.Context) ([]string, error)
+	GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error)
+	ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error)
 }
 
 // Journal handle all the interfactions with the eventlogs.
 //
 // It contains an internal map which contains all the eventslogs by Saga.
+//
+// It is safe for concurrent use as long as each saga is run by a single
+// goroutine at a time. The manual state changes of a stuck saga can be made
+// concurrently, only the first one succeeds.
 type Journal struct {
 	storage    Storage
+	mutex      *sync.RWMutex
 	journal    map[string]model.Saga
+	cancels    map[string]string
+	locks      map[string]*sync.Mutex
 	generateID func() string
+	now        func() time.Time
 }
 
 // New instanciate a new Journal.
 func New(storage Storage) *Journal {
 	return &Journal{
 		storage:    storage,
+		mutex:      new(sync.RWMutex),
 		journal:    map[string]model.Saga{},
+		cancels:    map[string]string{},
+		locks:      map[string]*sync.Mutex{},
 		generateID: func() string { return uuid.NewV4().String() },
+		now:        func() time.Time { return time.Now().UTC() },
 	}
 }
 
 // CreateNewSaga mark the given Saga a started.
-func (t *Journal) CreateNewSaga(ctx context.Context, sagaCtx json.RawMessage) (string, error) {
+//
+// The saga definition and the metadata are saved with the saga and can be
+// retrieved with GetSagaDefinition and GetSagaMetadata.
+func (t *Journal) CreateNewSaga(ctx context.Context, sagaType string, sagaVersion int, sagaCtx json.RawMessage, metadata map[string]string) (string, error) {
 	sagaID := t.generateID()
 
-	eventLog := model.EventLog{SagaID: sagaID, Step: "_init", State: "done", Context: sagaCtx}
+	err := t.CreateSaga(ctx, sagaID, sagaType, sagaVersion, sagaCtx, metadata)
+	if err != nil {
+		return "", err
+	}
+
+	return sagaID, nil
+}
+
+// CreateSaga mark the Saga with the given ID as started, as CreateNewSaga.
+//
+// An error wrapping model.ErrSagaAlreadyExists is returned if the saga
+// already exists into the storage.
+func (t *Journal) CreateSaga(ctx context.Context, sagaID string, sagaType string, sagaVersion int, sagaCtx json.RawMessage, metadata map[string]string) error {
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+
+	eventLog := model.EventLog{
+		SagaID:      sagaID,
+		Step:        "_init",
+		State:       "done",
+		Context:     sagaCtx,
+		CreatedAt:   t.now(),
+		Metadata:    metadata,
+		SagaType:    sagaType,
+		SagaVersion: sagaVersion,
+	}
 	err := t.storage.SaveEventLog(ctx, &eventLog)
+	if errors.Is(err, model.ErrSagaAlreadyExists) {
+		return err
+	}
+
 	if err != nil {
-		return "", fmt.Errorf("failed to save into the storage: %s", err)
+		return fmt.Errorf("failed to save into the storage: %s", err)
 	}
 
-	t.journal[sagaID] = model.Saga{
+	t.setSaga(model.Saga{
 		ID:        sagaID,
 		Status:    "running",
 		EventLogs: []model.EventLog{eventLog},
+	})
+
+	return nil
+}
+
+// Restore reload all the unfinished sagas from the storage into the journal.
+//
+// It returns the IDs of the restored sagas. The sagas already present into the
+// journal are left untouched.
+func (t *Journal) Restore(ctx context.Context) ([]string, error) {
+	sagaIDs, err := t.storage.GetUnfinishedSagaIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the unfinished sagas: %s", err)
 	}
 
-	return sagaID, nil
+	restored := []string{}
+	for _, sagaID := range sagaIDs {
+		if _, exists := t.getSaga(sagaID); exists {
+			continue
+		}
+
+		eventLogs, err := t.storage.GetSagaEventLogs(ctx, sagaID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve the eventlogs for saga %q: %s", sagaID, err)
+		}
+
+		if len(eventLogs) == 0 {
+			continue
+		}
+
+		eventLogs = t.restoreCancelRequest(sagaID, eventLogs)
+
+		t.setSaga(model.Saga{
+			ID:        sagaID,
+			Status:    model.ComputeStatus(eventLogs),
+			EventLogs: eventLogs,
+		})
+
+		restored = append(restored, sagaID)
+	}
+
+	return restored, nil
 }
 
 // MarkSubRequestAsRunning make the given Sub-Request as started for the given Saga.
 func (t *Journal) MarkSubRequestAsRunning(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
-	saga, ok := t.journal[sagaID]
+	saga, ok := t.getSaga(sagaID)
 	if !ok {
 		return fmt.Errorf("saga %q not found into the journal", sagaID)
 	}
 
-	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: "running", Context: sagaCtx}
+	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: "running", Context: sagaCtx, CreatedAt: t.now()}
 	err := t.storage.SaveEventLog(ctx, &eventLog)
 	if err != nil {
 		return fmt.Errorf("failed to save into the storage: %s", err)
@@ -68,21 +161,33 @@ func (t *Journal) MarkSubRequestAsRunning(ctx context.Context, sagaID string, su
 
 	saga.EventLogs = append(saga.EventLogs, eventLog)
 
-	t.journal[sagaID] = saga
+	t.setSaga(saga)
 
 	return nil
 }
 
 // MarkSubRequestAsDone make the given Sub-Request as started for the given Saga.
 func (t *Journal) MarkSubRequestAsDone(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
-	saga, ok := t.journal[sagaID]
+	return t.endRunningSubRequest(ctx, sagaID, subRequestID, "done", sagaCtx)
+}
+
+// MarkSubRequestAsPivoted mark the pivot Sub-Request as done and switch the
+// saga to the forward recovery: it will never be compensated and its next
+// Sub-Requests are retried until their success.
+func (t *Journal) MarkSubRequestAsPivoted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.endRunningSubRequest(ctx, sagaID, subRequestID, "pivoted", sagaCtx)
+}
+
+// endRunningSubRequest save the final state of the given running Sub-Request.
+func (t *Journal) endRunningSubRequest(ctx context.Context, sagaID string, subRequestID string, state string, sagaCtx json.RawMessage) error {
+	saga, ok := t.getSaga(sagaID)
 	if !ok {
 		return fmt.Errorf("saga %q not found into the journal", sagaID)
 	}
 
 	subRequestCurrentStep := ""
 	for _, eventLog := range saga.EventLogs {
-		if strings.HasPrefix(eventLog.Step, subRequestID) {
+		if eventLog.Branch == "" && strings.HasPrefix(eventLog.Step, subRequestID) {
 			subRequestCurrentStep = eventLog.State
 		}
 	}
@@ -95,7 +200,7 @@ func (t *Journal) MarkSubRequestAsDone(ctx context.Context, sagaID string, subRe
 		return fmt.Errorf("expected current state to be \"running\", have %q", subRequestCurrentStep)
 	}
 
-	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: "done", Context: sagaCtx}
+	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: state, Context: sagaCtx, CreatedAt: t.now()}
 	err := t.storage.SaveEventLog(ctx, &eventLog)
 	if err != nil {
 		return fmt.Errorf("failed to save into the storage: %s", err)
@@ -103,21 +208,57 @@ func (t *Journal) MarkSubRequestAsDone(ctx context.Context, sagaID string, subRe
 
 	saga.EventLogs = append(saga.EventLogs, eventLog)
 
-	t.journal[sagaID] = saga
+	t.setSaga(saga)
+
+	return nil
+}
+
+// MarkSubRequestAsFailed mark a failed attempt of the given Sub-Request. The
+// Sub-Request will be retried so the saga status is not changed.
+func (t *Journal) MarkSubRequestAsFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	saga, ok := t.getSaga(sagaID)
+	if !ok {
+		return fmt.Errorf("saga %q not found into the journal", sagaID)
+	}
+
+	subRequestCurrentStep := ""
+	for _, eventLog := range saga.EventLogs {
+		if eventLog.Branch == "" && strings.HasPrefix(eventLog.Step, subRequestID) {
+			subRequestCurrentStep = eventLog.State
+		}
+	}
+
+	if subRequestCurrentStep == "" {
+		return errors.New("expected current state to be \"running\", have not previous state")
+	}
+
+	if subRequestCurrentStep != "running" {
+		return fmt.Errorf("expected current state to be \"running\", have %q", subRequestCurrentStep)
+	}
+
+	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: "failed", Context: sagaCtx, CreatedAt: t.now()}
+	err := t.storage.SaveEventLog(ctx, &eventLog)
+	if err != nil {
+		return fmt.Errorf("failed to save into the storage: %s", err)
+	}
+
+	saga.EventLogs = append(saga.EventLogs, eventLog)
+
+	t.setSaga(saga)
 
 	return nil
 }
 
 // MarkSubRequestAsAborted make the given Sub-Request and saga as aborted for the given Saga.
-func (t *Journal) MarkSubRequestAsAborted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
-	saga, ok := t.journal[sagaID]
+func (t *Journal) MarkSubRequestAsAborted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, reason string) error {
+	saga, ok := t.getSaga(sagaID)
 	if !ok {
 		return fmt.Errorf("saga %q not found into the journal", sagaID)
 	}
 
 	subRequestCurrentStep := ""
 	for _, eventLog := range saga.EventLogs {
-		if strings.HasPrefix(eventLog.Step, subRequestID) {
+		if eventLog.Branch == "" && strings.HasPrefix(eventLog.Step, subRequestID) {
 			subRequestCurrentStep = eventLog.State
 		}
 	}
@@ -130,7 +271,7 @@ func (t *Journal) MarkSubRequestAsAborted(ctx context.Context, sagaID string, su
 		return fmt.Errorf("expected current state to be \"running\", have %q", subRequestCurrentStep)
 	}
 
-	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: "aborted", Context: sagaCtx}
+	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: "aborted", Context: sagaCtx, CreatedAt: t.now(), Reason: reason}
 	err := t.storage.SaveEventLog(ctx, &eventLog)
 	if err != nil {
 		return fmt.Errorf("failed to save into the storage: %s", err)
@@ -139,42 +280,138 @@ func (t *Journal) MarkSubRequestAsAborted(ctx context.Context, sagaID string, su
 	saga.Status = "aborted"
 	saga.EventLogs = append(saga.EventLogs, eventLog)
 
-	t.journal[sagaID] = saga
+	t.setSaga(saga)
 
 	return nil
 }
 
 // MarkSagaAsDone mark the given Saga a done.
 func (t *Journal) MarkSagaAsDone(ctx context.Context, sagaID string) error {
-	saga, ok := t.journal[sagaID]
+	saga, ok := t.getSaga(sagaID)
 	if !ok {
 		return fmt.Errorf("saga %q not found into the journal", sagaID)
 	}
 
-	subRequestCurrentStep := saga.EventLogs[len(saga.EventLogs)-1].State
-	if subRequestCurrentStep != "done" {
+	subRequestCurrentStep := lastEventLog(saga).State
+	if subRequestCurrentStep != "done" && subRequestCurrentStep != "pivoted" && subRequestCurrentStep != "skipped" {
 		return fmt.Errorf("expected current state to be \"done\", have %q", subRequestCurrentStep)
 	}
-	err := t.storage.SaveEventLog(ctx, &model.EventLog{SagaID: sagaID, Step: "_finish", State: "done"})
+	err := t.storage.SaveEventLog(ctx, &model.EventLog{SagaID: sagaID, Step: "_finish", State: "done", CreatedAt: t.now()})
 	if err != nil {
 		return fmt.Errorf("failed to save into the storage: %s", err)
 	}
 
 	saga.Status = "done"
 
-	t.journal[sagaID] = saga
+	t.setSaga(saga)
+
+	return nil
+}
+
+// MarkSubRequestAsStuck mark the given Sub-Request and saga as stuck. A stuck
+// saga is not run anymore until a manual intervention.
+func (t *Journal) MarkSubRequestAsStuck(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.changeSubRequestState(ctx, sagaID, subRequestID, "running", "stuck", sagaCtx)
+}
+
+// MarkSubRequestAsResumed resume the compensation of the stuck Sub-Request.
+// The Sub-Request attempts are reset.
+func (t *Journal) MarkSubRequestAsResumed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.changeSubRequestState(ctx, sagaID, subRequestID, "stuck", "resumed", sagaCtx)
+}
+
+// MarkSubRequestAsSkipped skip the compensation of the stuck Sub-Request. The
+// compensation continue with the previous Sub-Request.
+func (t *Journal) MarkSubRequestAsSkipped(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.changeSubRequestState(ctx, sagaID, subRequestID, "stuck", "skipped", sagaCtx)
+}
+
+// MarkSagaAsForcedDone mark the given stuck saga as done whatever the state
+// of its Sub-Requests.
+//
+// The saga is locked from the check to the save so a concurrent resume or
+// skip of the saga fails.
+func (t *Journal) MarkSagaAsForcedDone(ctx context.Context, sagaID string) error {
+	unlock := t.lockSaga(sagaID)
+	defer unlock()
+
+	saga, ok := t.getSaga(sagaID)
+	if !ok {
+		return fmt.Errorf("saga %q not found into the journal", sagaID)
+	}
+
+	if saga.Status != "stuck" {
+		return fmt.Errorf("expected saga %q to be \"stuck\", have %q", sagaID, saga.Status)
+	}
+
+	err := t.storage.SaveEventLog(ctx, &model.EventLog{SagaID: sagaID, Step: "_finish", State: "forced", CreatedAt: t.now()})
+	if err != nil {
+		return fmt.Errorf("failed to save into the storage: %s", err)
+	}
+
+	saga.Status = "done"
+
+	t.setSaga(saga)
+
+	return nil
+}
+
+// changeSubRequestState save a new state for the given Sub-Request if its
+// current state is the expected one. The saga status follows the new state.
+//
+// The saga is locked from the check to the save so only one of several
+// concurrent changes succeeds.
+func (t *Journal) changeSubRequestState(ctx context.Context, sagaID string, subRequestID string, expected string, state string, sagaCtx json.RawMessage) error {
+	unlock := t.lockSaga(sagaID)
+	defer unlock()
+
+	saga, ok := t.getSaga(sagaID)
+	if !ok {
+		return fmt.Errorf("saga %q not found into the journal", sagaID)
+	}
+
+	// A forced saga keeps its last Sub-Request state.
+	if saga.Status == "done" {
+		return errors.New("the saga is already done")
+	}
+
+	last := lastEventLog(saga)
+	if last.Step != subRequestID || last.State != expected {
+		return fmt.Errorf("expected current state to be %q for %q, have %q for %q", expected, subRequestID, last.State, last.Step)
+	}
+
+	eventLog := model.EventLog{SagaID: sagaID, Step: subRequestID, State: state, Context: sagaCtx, CreatedAt: t.now()}
+	err := t.storage.SaveEventLog(ctx, &eventLog)
+	if err != nil {
+		return fmt.Errorf("failed to save into the storage: %s", err)
+	}
+
+	switch state {
+	case "stuck":
+		saga.Status = "stuck"
+	case "resumed", "skipped":
+		saga.Status = "aborted"
+	}
+	saga.EventLogs = append(saga.EventLogs, eventLog)
+
+	t.setSaga(saga)
 
 	return nil
 }
 
 // DeleteSaga remove the saga from the local journal but keep it into the storage.
 func (t *Journal) DeleteSaga(ctx context.Context, sagaID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
 	delete(t.journal, sagaID)
+	delete(t.cancels, sagaID)
+	delete(t.locks, sagaID)
 }
 
 // GetSagaStatus return the status for the given sagaID.
 func (t *Journal) GetSagaStatus(sagaID string) string {
-	saga, exists := t.journal[sagaID]
+	saga, exists := t.getSaga(sagaID)
 
 	if !exists {
 		return ""
@@ -185,14 +422,328 @@ func (t *Journal) GetSagaStatus(sagaID string) string {
 
 // GetSagaLastEventLog return the last eventlog for a given saga.
 func (t *Journal) GetSagaLastEventLog(sagaID string) (string, string, json.RawMessage) {
-	saga, exists := t.journal[sagaID]
+	saga, exists := t.getSaga(sagaID)
 
 	if !exists || len(saga.EventLogs) == 0 {
 		return "", "", nil
 	}
 
 	// Retrieve the last save State
-	eventLog := saga.EventLogs[len(saga.EventLogs)-1]
+	eventLog := lastEventLog(saga)
 
 	return eventLog.Step, eventLog.State, eventLog.Context
 }
+
+// GetSagaMetadata return the metadata saved at the saga creation.
+func (t *Journal) GetSagaMetadata(sagaID string) map[string]string {
+	saga, exists := t.getSaga(sagaID)
+
+	if !exists || len(saga.EventLogs) == 0 {
+		return nil
+	}
+
+	return saga.EventLogs[0].Metadata
+}
+
+// GetSagaCreatedAt return the creation date of the saga, zero if unknown.
+func (t *Journal) GetSagaCreatedAt(sagaID string) time.Time {
+	saga, exists := t.getSaga(sagaID)
+
+	if !exists || len(saga.EventLogs) == 0 {
+		return time.Time{}
+	}
+
+	return saga.EventLogs[0].CreatedAt
+}
+
+// GetSagaDefinition return the type and the version of the definition the
+// saga have been started with.
+func (t *Journal) GetSagaDefinition(sagaID string) (string, int) {
+	saga, exists := t.getSaga(sagaID)
+
+	if !exists || len(saga.EventLogs) == 0 {
+		return "", 0
+	}
+
+	return saga.EventLogs[0].SagaType, saga.EventLogs[0].SagaVersion
+}
+
+// GetSagasByStatus return the IDs of all the sagas with the given status,
+// sorted by ID.
+func (t *Journal) GetSagasByStatus(status string) []string {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	res := []string{}
+	for sagaID, saga := range t.journal {
+		if saga.Status == status {
+			res = append(res, sagaID)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// GetSagaOutputs return the context the given saga have been started with and
+// the contexts returned by its succeeded Actions, by Sub-Request ID. The
+// branches of a parallel stage are identified by "stage/branch".
+//
+// The Compensations results are ignored.
+func (t *Journal) GetSagaOutputs(sagaID string) (json.RawMessage, map[string]json.RawMessage) {
+	saga, exists := t.getSaga(sagaID)
+	if !exists || len(saga.EventLogs) == 0 {
+		return nil, nil
+	}
+
+	outputs := map[string]json.RawMessage{}
+	for _, eventLog := range saga.EventLogs[1:] {
+		if eventLog.Branch != "" {
+			if eventLog.State == "done" {
+				outputs[eventLog.Step+"/"+eventLog.Branch] = eventLog.Context
+			}
+
+			continue
+		}
+
+		// All the next eventlogs are about the compensations.
+		if eventLog.State == "aborted" {
+			break
+		}
+
+		if eventLog.State == "done" || eventLog.State == "pivoted" {
+			outputs[eventLog.Step] = eventLog.Context
+		}
+	}
+
+	return saga.EventLogs[0].Context, outputs
+}
+
+// GetSagaRecovery return "forward" once the pivot of the given saga is done
+// and "backward" before. It returns an empty string for an unknown saga.
+func (t *Journal) GetSagaRecovery(sagaID string) string {
+	saga, exists := t.getSaga(sagaID)
+	if !exists {
+		return ""
+	}
+
+	for _, eventLog := range saga.EventLogs {
+		if eventLog.Branch == "" && eventLog.State == "pivoted" {
+			return "forward"
+		}
+	}
+
+	return "backward"
+}
+
+// GetSubRequestAttempts return the number of attempts for the last
+// Sub-Request action/compensation of the given saga and the date of the first
+// one.
+//
+// The attempts are counted since the last time the Sub-Request have been done
+// or aborted.
+func (t *Journal) GetSubRequestAttempts(sagaID string) (int, time.Time) {
+	saga, exists := t.getSaga(sagaID)
+
+	if !exists || len(saga.EventLogs) == 0 {
+		return 0, time.Time{}
+	}
+
+	var (
+		attempts     int
+		firstAttempt time.Time
+	)
+
+	step := lastEventLog(saga).Step
+	for i := len(saga.EventLogs) - 1; i >= 0; i-- {
+		eventLog := saga.EventLogs[i]
+		if eventLog.Branch != "" {
+			continue
+		}
+
+		if eventLog.Step != step || (eventLog.State != "running" && eventLog.State != "failed") {
+			break
+		}
+
+		if eventLog.State == "running" {
+			attempts++
+			firstAttempt = eventLog.CreatedAt
+		}
+	}
+
+	return attempts, firstAttempt
+}
+
+// MarkBranchState save a new state for a branch of the given parallel stage.
+//
+// Contrary to the other methods, it can be called concurrently for the
+// different branches of a stage. The branch states are not checked and don't
+// change the saga status.
+func (t *Journal) MarkBranchState(ctx context.Context, sagaID string, stageID string, branchID string, state string, sagaCtx json.RawMessage) error {
+	if _, ok := t.getSaga(sagaID); !ok {
+		return fmt.Errorf("saga %q not found into the journal", sagaID)
+	}
+
+	eventLog := model.EventLog{SagaID: sagaID, Step: stageID, Branch: branchID, State: state, Context: sagaCtx, CreatedAt: t.now()}
+	err := t.storage.SaveEventLog(ctx, &eventLog)
+	if err != nil {
+		return fmt.Errorf("failed to save into the storage: %s", err)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	saga := t.journal[sagaID]
+	saga.EventLogs = append(saga.EventLogs, eventLog)
+	t.journal[sagaID] = saga
+
+	return nil
+}
+
+// GetBranchStates return the last eventlog of each branch of the given
+// parallel stage, by branch ID.
+func (t *Journal) GetBranchStates(sagaID string, stageID string) map[string]model.EventLog {
+	saga, _ := t.getSaga(sagaID)
+
+	res := map[string]model.EventLog{}
+	for _, eventLog := range saga.EventLogs {
+		if eventLog.Branch != "" && eventLog.Step == stageID {
+			res[eventLog.Branch] = eventLog
+		}
+	}
+
+	return res
+}
+
+// GetSagaHistory return all the eventlogs saved for the given saga, finished
+// or not, in their saving order.
+//
+// It is read from the storage as the finished sagas are removed from the
+// journal.
+func (t *Journal) GetSagaHistory(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	eventLogs, err := t.storage.GetSagaEventLogs(ctx, sagaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the eventlogs for saga %q: %s", sagaID, err)
+	}
+
+	return eventLogs, nil
+}
+
+// ListSagas return the page of sagas selected by the query and the cursor of
+// the next page, empty for the last one. It is read from the storage.
+func (t *Journal) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	sagas, cursor, err := t.storage.ListSagas(ctx, query)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list the sagas: %s", err)
+	}
+
+	return sagas, cursor, nil
+}
+
+// RequestSagaCancel save a request to cancel the given running saga with the
+// given reason. Requesting again the cancellation of a saga is a no-op.
+//
+// The request is saved apart from the Sub-Requests states, it can be made
+// while the saga is run by an other goroutine.
+func (t *Journal) RequestSagaCancel(ctx context.Context, sagaID string, reason string) error {
+	saga, ok := t.getSaga(sagaID)
+	if !ok {
+		return fmt.Errorf("saga %q not found into the journal", sagaID)
+	}
+
+	if saga.Status != "running" {
+		return fmt.Errorf("expected saga status to be \"running\", have %q", saga.Status)
+	}
+
+	if _, requested := t.GetSagaCancelRequest(sagaID); requested {
+		return nil
+	}
+
+	err := t.storage.SaveEventLog(ctx, &model.EventLog{SagaID: sagaID, Step: "_cancel", State: "requested", Reason: reason, CreatedAt: t.now()})
+	if err != nil {
+		return fmt.Errorf("failed to save into the storage: %s", err)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.cancels[sagaID] = reason
+
+	return nil
+}
+
+// GetSagaCancelRequest return the reason of the cancellation requested for
+// the given saga, if any.
+func (t *Journal) GetSagaCancelRequest(sagaID string) (string, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	reason, ok := t.cancels[sagaID]
+
+	return reason, ok
+}
+
+// restoreCancelRequest register the cancellation requested into the given
+// eventlogs and return the eventlogs without it.
+func (t *Journal) restoreCancelRequest(sagaID string, eventLogs []model.EventLog) []model.EventLog {
+	res := make([]model.EventLog, 0, len(eventLogs))
+	for _, eventLog := range eventLogs {
+		if eventLog.Step != "_cancel" {
+			res = append(res, eventLog)
+			continue
+		}
+
+		t.mutex.Lock()
+		t.cancels[sagaID] = eventLog.Reason
+		t.mutex.Unlock()
+	}
+
+	return res
+}
+
+// lastEventLog return the last eventlog of the saga, ignoring the branch
+// eventlogs.
+func lastEventLog(saga model.Saga) model.EventLog {
+	for i := len(saga.EventLogs) - 1; i >= 0; i-- {
+		if saga.EventLogs[i].Branch == "" {
+			return saga.EventLogs[i]
+		}
+	}
+
+	return model.EventLog{}
+}
+
+// lockSaga lock the state changes of the given saga until the returned
+// function is called.
+func (t *Journal) lockSaga(sagaID string) func() {
+	t.mutex.Lock()
+	lock, ok := t.locks[sagaID]
+	if !ok {
+		lock = new(sync.Mutex)
+		t.locks[sagaID] = lock
+	}
+	t.mutex.Unlock()
+
+	lock.Lock()
+
+	return lock.Unlock
+}
+
+// getSaga return a copy of the given saga.
+func (t *Journal) getSaga(sagaID string) (model.Saga, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	saga, exists := t.journal[sagaID]
+
+	return saga, exists
+}
+
+// setSaga save the given saga into the journal.
+func (t *Journal) setSaga(saga model.Saga) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.journal[saga.ID] = saga
+}
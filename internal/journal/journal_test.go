@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/Peltoche/gosaga/model"
 	"github.com/Peltoche/gosaga/storage"
@@ -13,22 +16,32 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+var someDate = time.Date(2019, time.January, 2, 15, 4, 5, 0, time.UTC)
+
 func Test_New_default_generateID_method(t *testing.T) {
 	journal := New(nil)
 
 	assert.NotNil(t, uuid.FromStringOrNil(journal.generateID()))
 }
 
+func Test_New_default_now_method(t *testing.T) {
+	journal := New(nil)
+
+	assert.WithinDuration(t, time.Now(), journal.now(), time.Second)
+	assert.Equal(t, time.UTC, journal.now().Location())
+}
+
 func Test_Journal_CreateNewSaga_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 
-	id, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	id, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "some-saga-id", id)
@@ -40,12 +53,13 @@ func Test_Journal_CreateNewSaga_with_a_storage_error(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(errors.New("some-error"))
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(errors.New("some-error"))
 
-	id, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	id, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 
 	assert.EqualError(t, err, `failed to save into the storage: some-error`)
 	assert.Empty(t, id)
@@ -53,20 +67,54 @@ func Test_Journal_CreateNewSaga_with_a_storage_error(t *testing.T) {
 	storageMock.AssertExpectations(t)
 }
 
+func Test_Journal_CreateSaga_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-key", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+
+	err := journal.CreateSaga(context.Background(), "some-key", "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "running", journal.GetSagaStatus("some-key"))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_CreateSaga_with_an_existing_saga(t *testing.T) {
+	journal := New(storage.NewMemory())
+
+	err := journal.CreateSaga(context.Background(), "some-key", "", 0, json.RawMessage(`{}`), nil)
+	require.NoError(t, err)
+
+	journal.DeleteSaga(context.Background(), "some-key")
+
+	err = journal.CreateSaga(context.Background(), "some-key", "", 0, json.RawMessage(`{}`), nil)
+	assert.ErrorIs(t, err, model.ErrSagaAlreadyExists)
+	assert.EqualError(t, err, `saga already exists: "some-key"`)
+
+	// The existing saga is not restored into the journal.
+	assert.Empty(t, journal.GetSagaStatus("some-key"))
+}
+
 func Test_Journal_MarkSubRequestAsRunning_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	assert.NoError(t, err)
@@ -78,16 +126,17 @@ func Test_Journal_MarkSubRequestAsRunning_with_storage_error(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(errors.New("some-error"))
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(errors.New("some-error"))
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	assert.EqualError(t, err, "failed to save into the storage: some-error")
@@ -99,6 +148,7 @@ func Test_Journal_MarkSubRequestAsRunning_with_an_unknown_saga(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
@@ -113,20 +163,21 @@ func Test_Journal_MarkSubRequestAsDone_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	// Mark the subrequest as done
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsDone(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	assert.NoError(t, err)
@@ -134,24 +185,78 @@ func Test_Journal_MarkSubRequestAsDone_success(t *testing.T) {
 	storageMock.AssertExpectations(t)
 }
 
+func Test_Journal_MarkSubRequestAsPivoted_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "backward", journal.GetSagaRecovery(sagaID))
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "pivoted", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	err = journal.MarkSubRequestAsPivoted(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	require.NoError(t, err)
+
+	assert.Equal(t, "forward", journal.GetSagaRecovery(sagaID))
+	assert.Empty(t, journal.GetSagaRecovery("some-unknown-saga-id"))
+
+	// The pivot can be the last Sub-Request.
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_finish", State: "done", CreatedAt: someDate}).Once().Return(nil)
+	err = journal.MarkSagaAsDone(context.Background(), sagaID)
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkSubRequestAsPivoted_with_the_subrequest_not_in_running_state(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	err = journal.MarkSubRequestAsPivoted(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	assert.EqualError(t, err, "expected current state to be \"running\", have not previous state")
+
+	assert.Equal(t, "backward", journal.GetSagaRecovery(sagaID))
+
+	storageMock.AssertExpectations(t)
+}
+
 func Test_Journal_MarkSubRequestAsDone_with_storage_error(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	// Mark the subrequest as done
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx}).Once().Return(errors.New("some-error"))
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(errors.New("some-error"))
 	err = journal.MarkSubRequestAsDone(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	assert.EqualError(t, err, "failed to save into the storage: some-error")
@@ -163,6 +268,7 @@ func Test_Journal_MarkSubRequestAsDone_with_an_unknown_saga(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
@@ -177,20 +283,21 @@ func Test_Journal_MarkSubRequestAsDone_with_the_subrequest_not_in_running_state(
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	// Mark the subrequest as done
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsDone(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 	require.NoError(t, err)
 
@@ -205,12 +312,13 @@ func Test_Journal_MarkSubRequestAsDone_with_not_subrequest_previous_state(t *tes
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as done without calling the MarkSubRequestAsRunning before.
@@ -224,16 +332,17 @@ func Test_Journal_MarkSagaAsDone_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as done without calling the MarkSubRequestAsRunning before.
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_finish", State: "done"}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_finish", State: "done", CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSagaAsDone(context.Background(), sagaID)
 
 	assert.NoError(t, err)
@@ -245,6 +354,7 @@ func Test_Journal_MarkSagaAsDone_with_unknown_sagaID(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	// Mark an unknown saga as done.
 	err := journal.MarkSagaAsDone(context.Background(), "some-invalid-saga-id")
@@ -258,16 +368,17 @@ func Test_Journal_MarkSagaAsDone_with_a_running_subrequest(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 	require.NoError(t, err)
 
@@ -283,16 +394,17 @@ func Test_Journal_MarkSagaAsDone_with_a_storage_error(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the saga as "done".
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_finish", State: "done"}).Once().Return(errors.New("some-error"))
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_finish", State: "done", CreatedAt: someDate}).Once().Return(errors.New("some-error"))
 	err = journal.MarkSagaAsDone(context.Background(), sagaID)
 
 	assert.EqualError(t, err, `failed to save into the storage: some-error`)
@@ -304,12 +416,13 @@ func Test_Journal_GetSagaStatus_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	status := journal.GetSagaStatus(sagaID)
@@ -323,6 +436,7 @@ func Test_Journal_GetSagaStatus_with_unknown_sagaID(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	status := journal.GetSagaStatus("some-invalid-saga-id")
 
@@ -335,12 +449,13 @@ func Test_Journal_GetSagaLastEventLog_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	step, state, arg := journal.GetSagaLastEventLog(sagaID)
@@ -352,10 +467,54 @@ func Test_Journal_GetSagaLastEventLog_success(t *testing.T) {
 	storageMock.AssertExpectations(t)
 }
 
+func Test_Journal_GetSagaMetadata_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+	metadata := map[string]string{"traceparent": "some-trace"}
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate, Metadata: metadata}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "step1", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, metadata)
+	require.NoError(t, err)
+
+	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "step1", sagaCtx)
+	require.NoError(t, err)
+
+	assert.Equal(t, metadata, journal.GetSagaMetadata(sagaID))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_GetSagaMetadata_with_an_empty_metadata(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	// An empty metadata is not saved.
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, map[string]string{})
+	require.NoError(t, err)
+
+	assert.Nil(t, journal.GetSagaMetadata(sagaID))
+	assert.Nil(t, journal.GetSagaMetadata("some-unknown-saga-id"))
+
+	storageMock.AssertExpectations(t)
+}
+
 func Test_Journal_GetSagaLastEventLog_with_an_unknown_sagaID(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	step, state, arg := journal.GetSagaLastEventLog("some-unknown-saga-id")
 
@@ -370,12 +529,13 @@ func Test_Journal_DeleteSaga_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	journal.DeleteSaga(context.Background(), sagaID)
@@ -388,21 +548,22 @@ func Test_Journal_MarkSubRequestAsAborted_success(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	// Mark the subrequest as aborted
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "aborted", Context: sagaCtx}).Once().Return(nil)
-	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "aborted", Context: sagaCtx, CreatedAt: someDate, Reason: "some-reason"}).Once().Return(nil)
+	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx, "some-reason")
 
 	assert.NoError(t, err)
 
@@ -413,21 +574,22 @@ func Test_Journal_MarkSubRequestAsAborted_with_storage_error(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	// Mark the subrequest as aborted
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "aborted", Context: sagaCtx}).Once().Return(errors.New("some-error"))
-	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "aborted", Context: sagaCtx, CreatedAt: someDate, Reason: "some-reason"}).Once().Return(errors.New("some-error"))
+	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx, "some-reason")
 
 	assert.EqualError(t, err, "failed to save into the storage: some-error")
 
@@ -438,10 +600,11 @@ func Test_Journal_MarkSubRequestAsAborted_with_an_unknown_saga(t *testing.T) {
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
-	err := journal.MarkSubRequestAsAborted(context.Background(), "some-unknown-saga-id", "some-subrequest-id", sagaCtx)
+	err := journal.MarkSubRequestAsAborted(context.Background(), "some-unknown-saga-id", "some-subrequest-id", sagaCtx, "some-reason")
 
 	assert.EqualError(t, err, "saga \"some-unknown-saga-id\" not found into the journal")
 
@@ -452,25 +615,26 @@ func Test_Journal_MarkSubRequestAsAborted_with_the_subrequest_not_in_running_sta
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as running
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 
 	// Mark the subrequest as done
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
 	err = journal.MarkSubRequestAsDone(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 	require.NoError(t, err)
 
 	// Mark the subrequest as aborted. It should fail as the subrequest is in not in the "running" State.
-	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx, "some-reason")
 	assert.EqualError(t, err, `expected current state to be "running", have "done"`)
 
 	storageMock.AssertExpectations(t)
@@ -480,17 +644,822 @@ func Test_Journal_MarkSubRequestAsAborted_with_not_subrequest_previous_state(t *
 	storageMock := new(storage.Mock)
 	journal := New(storageMock)
 	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
 	// Initialize the saga
-	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx}).Once().Return(nil)
-	sagaID, err := journal.CreateNewSaga(context.Background(), sagaCtx)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
 	require.NoError(t, err)
 
 	// Mark the subrequest as done without calling the MarkSubRequestAsRunning before.
-	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	err = journal.MarkSubRequestAsAborted(context.Background(), sagaID, "some-subrequest-id", sagaCtx, "some-reason")
+	assert.EqualError(t, err, "expected current state to be \"running\", have not previous state")
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_Restore_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	runningLogs := []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", Context: sagaCtx},
+		{SagaID: "saga-1", Step: "step1", State: "running", Context: sagaCtx},
+	}
+	abortedLogs := []model.EventLog{
+		{SagaID: "saga-2", Step: "_init", State: "done", Context: sagaCtx},
+		{SagaID: "saga-2", Step: "step1", State: "running", Context: sagaCtx},
+		{SagaID: "saga-2", Step: "step1", State: "aborted", Context: sagaCtx},
+		{SagaID: "saga-2", Step: "step1", State: "running", Context: sagaCtx},
+	}
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"saga-1", "saga-2"}, nil).Once()
+	storageMock.On("GetSagaEventLogs", "saga-1").Return(runningLogs, nil).Once()
+	storageMock.On("GetSagaEventLogs", "saga-2").Return(abortedLogs, nil).Once()
+
+	sagaIDs, err := journal.Restore(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saga-1", "saga-2"}, sagaIDs)
+
+	assert.Equal(t, "running", journal.GetSagaStatus("saga-1"))
+	step, state, res := journal.GetSagaLastEventLog("saga-1")
+	assert.Equal(t, "step1", step)
+	assert.Equal(t, "running", state)
+	assert.Equal(t, sagaCtx, res)
+
+	assert.Equal(t, "aborted", journal.GetSagaStatus("saga-2"))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_Restore_should_skip_the_sagas_already_in_the_journal(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	// Initialize the saga
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	_, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"some-saga-id"}, nil).Once()
+
+	sagaIDs, err := journal.Restore(context.Background())
+
+	assert.NoError(t, err)
+	assert.Empty(t, sagaIDs)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_Restore_with_a_GetUnfinishedSagaIDs_error(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	storageMock.On("GetUnfinishedSagaIDs").Return(nil, errors.New("some-error")).Once()
+
+	sagaIDs, err := journal.Restore(context.Background())
+
+	assert.EqualError(t, err, "failed to retrieve the unfinished sagas: some-error")
+	assert.Nil(t, sagaIDs)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_Restore_with_a_GetSagaEventLogs_error(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"saga-1"}, nil).Once()
+	storageMock.On("GetSagaEventLogs", "saga-1").Return(nil, errors.New("some-error")).Once()
+
+	sagaIDs, err := journal.Restore(context.Background())
+
+	assert.EqualError(t, err, `failed to retrieve the eventlogs for saga "saga-1": some-error`)
+	assert.Nil(t, sagaIDs)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_concurrent_sagas(t *testing.T) {
+	memory := storage.NewMemory()
+	journal := New(memory)
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+			require.NoError(t, err)
+
+			require.NoError(t, journal.MarkSubRequestAsRunning(context.Background(), sagaID, "step1", sagaCtx))
+			require.NoError(t, journal.MarkSubRequestAsDone(context.Background(), sagaID, "step1", sagaCtx))
+			require.NoError(t, journal.MarkSagaAsDone(context.Background(), sagaID))
+
+			assert.Equal(t, "done", journal.GetSagaStatus(sagaID))
+			step, state, _ := journal.GetSagaLastEventLog(sagaID)
+			assert.Equal(t, "step1", step)
+			assert.Equal(t, "done", state)
+
+			eventLogs, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+			require.NoError(t, err)
+			assert.Equal(t, []model.EventLog{
+				{SagaID: sagaID, Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate},
+				{SagaID: sagaID, Step: "step1", State: "running", Context: sagaCtx, CreatedAt: someDate},
+				{SagaID: sagaID, Step: "step1", State: "done", Context: sagaCtx, CreatedAt: someDate},
+				{SagaID: sagaID, Step: "_finish", State: "done", CreatedAt: someDate},
+			}, eventLogs)
+
+			journal.DeleteSaga(context.Background(), sagaID)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, journal.journal, 0)
+}
+
+func Test_Journal_MarkSubRequestAsFailed_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	// Initialize the saga
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	// Mark the subrequest as running
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	require.NoError(t, err)
+
+	// Mark the subrequest as failed
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "failed", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	err = journal.MarkSubRequestAsFailed(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+
+	assert.NoError(t, err)
+
+	// The saga status is not changed.
+	assert.Equal(t, "running", journal.GetSagaStatus(sagaID))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkSubRequestAsFailed_with_storage_error(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	// Initialize the saga
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	// Mark the subrequest as running
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+	require.NoError(t, err)
+
+	// Mark the subrequest as failed
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "some-subrequest-id", State: "failed", Context: sagaCtx, CreatedAt: someDate}).Once().Return(errors.New("some-error"))
+	err = journal.MarkSubRequestAsFailed(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
+
+	assert.EqualError(t, err, "failed to save into the storage: some-error")
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkSubRequestAsFailed_with_an_unknown_saga(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	err := journal.MarkSubRequestAsFailed(context.Background(), "some-unknown-saga-id", "some-subrequest-id", nil)
+
+	assert.EqualError(t, err, "saga \"some-unknown-saga-id\" not found into the journal")
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkSubRequestAsFailed_with_not_subrequest_previous_state(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	// Initialize the saga
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	err = journal.MarkSubRequestAsFailed(context.Background(), sagaID, "some-subrequest-id", sagaCtx)
 	assert.EqualError(t, err, "expected current state to be \"running\", have not previous state")
 
 	storageMock.AssertExpectations(t)
 }
+
+func Test_Journal_GetSubRequestAttempts_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	firstDate := someDate.Add(-time.Minute)
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"saga-1"}, nil).Once()
+	storageMock.On("GetSagaEventLogs", "saga-1").Return([]model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", CreatedAt: firstDate},
+		{SagaID: "saga-1", Step: "step1", State: "running", CreatedAt: firstDate},
+		{SagaID: "saga-1", Step: "step1", State: "aborted", CreatedAt: firstDate},
+		{SagaID: "saga-1", Step: "step1", State: "running", CreatedAt: someDate},
+		{SagaID: "saga-1", Step: "step1", State: "failed", CreatedAt: someDate},
+		{SagaID: "saga-1", Step: "step1", State: "running", CreatedAt: someDate.Add(time.Second)},
+		{SagaID: "saga-1", Step: "step1", State: "failed", CreatedAt: someDate.Add(time.Second)},
+	}, nil).Once()
+
+	_, err := journal.Restore(context.Background())
+	require.NoError(t, err)
+
+	// Only the compensation attempts are counted.
+	attempts, firstAttempt := journal.GetSubRequestAttempts("saga-1")
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, someDate, firstAttempt)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_GetSubRequestAttempts_with_an_unknown_sagaID(t *testing.T) {
+	journal := New(nil)
+
+	attempts, firstAttempt := journal.GetSubRequestAttempts("some-unknown-id")
+	assert.Zero(t, attempts)
+	assert.True(t, firstAttempt.IsZero())
+}
+
+func Test_Journal_stuck_saga_lifecycle(t *testing.T) {
+	memory := storage.NewMemory()
+	journal := New(memory)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+	require.NoError(t, journal.MarkSubRequestAsRunning(context.Background(), sagaID, "step1", sagaCtx))
+	require.NoError(t, journal.MarkSubRequestAsAborted(context.Background(), sagaID, "step1", sagaCtx, "some-reason"))
+	require.NoError(t, journal.MarkSubRequestAsRunning(context.Background(), sagaID, "step1", sagaCtx))
+
+	// The resume is allowed only for the stuck sub-requests.
+	err = journal.MarkSubRequestAsResumed(context.Background(), sagaID, "step1", sagaCtx)
+	assert.EqualError(t, err, `expected current state to be "stuck" for "step1", have "running" for "step1"`)
+
+	require.NoError(t, journal.MarkSubRequestAsStuck(context.Background(), sagaID, "step1", sagaCtx))
+	assert.Equal(t, "stuck", journal.GetSagaStatus(sagaID))
+	assert.Equal(t, []string{sagaID}, journal.GetSagasByStatus("stuck"))
+
+	require.NoError(t, journal.MarkSubRequestAsResumed(context.Background(), sagaID, "step1", sagaCtx))
+	assert.Equal(t, "aborted", journal.GetSagaStatus(sagaID))
+	assert.Empty(t, journal.GetSagasByStatus("stuck"))
+
+	// The resume reset the attempts.
+	require.NoError(t, journal.MarkSubRequestAsRunning(context.Background(), sagaID, "step1", sagaCtx))
+	attempts, _ := journal.GetSubRequestAttempts(sagaID)
+	assert.Equal(t, 1, attempts)
+
+	require.NoError(t, journal.MarkSubRequestAsStuck(context.Background(), sagaID, "step1", sagaCtx))
+	require.NoError(t, journal.MarkSubRequestAsSkipped(context.Background(), sagaID, "step1", sagaCtx))
+	assert.Equal(t, "aborted", journal.GetSagaStatus(sagaID))
+
+	// A skipped sub-request is considered as done.
+	require.NoError(t, journal.MarkSagaAsDone(context.Background(), sagaID))
+	assert.Equal(t, "done", journal.GetSagaStatus(sagaID))
+}
+
+// slowStorage is a storage taking some time to save the eventlogs.
+type slowStorage struct {
+	*storage.Memory
+}
+
+func (t slowStorage) SaveEventLog(ctx context.Context, event *model.EventLog) error {
+	time.Sleep(5 * time.Millisecond)
+
+	return t.Memory.SaveEventLog(ctx, event)
+}
+
+func Test_Journal_MarkSubRequestAsResumed_with_concurrent_calls(t *testing.T) {
+	memory := storage.NewMemory()
+	journal := New(slowStorage{memory})
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+	require.NoError(t, journal.MarkSubRequestAsRunning(context.Background(), sagaID, "step1", sagaCtx))
+	require.NoError(t, journal.MarkSubRequestAsStuck(context.Background(), sagaID, "step1", sagaCtx))
+
+	succeeded := new(atomic.Int32)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if journal.MarkSubRequestAsResumed(context.Background(), sagaID, "step1", sagaCtx) == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), succeeded.Load())
+
+	eventLogs, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Len(t, eventLogs, 4)
+}
+
+func Test_Journal_MarkSagaAsForcedDone_with_a_concurrent_resume(t *testing.T) {
+	memory := storage.NewMemory()
+	journal := New(slowStorage{memory})
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+	require.NoError(t, journal.MarkSubRequestAsRunning(context.Background(), sagaID, "step1", sagaCtx))
+	require.NoError(t, journal.MarkSubRequestAsStuck(context.Background(), sagaID, "step1", sagaCtx))
+
+	succeeded := new(atomic.Int32)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+
+			if journal.MarkSubRequestAsResumed(context.Background(), sagaID, "step1", sagaCtx) == nil {
+				succeeded.Add(1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+
+			if journal.MarkSagaAsForcedDone(context.Background(), sagaID) == nil {
+				succeeded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), succeeded.Load())
+
+	eventLogs, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Len(t, eventLogs, 4)
+}
+
+func Test_Journal_Restore_with_a_stuck_saga(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"saga-1"}, nil).Once()
+	storageMock.On("GetSagaEventLogs", "saga-1").Return([]model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "step1", State: "running"},
+		{SagaID: "saga-1", Step: "step1", State: "aborted"},
+		{SagaID: "saga-1", Step: "step1", State: "running"},
+		{SagaID: "saga-1", Step: "step1", State: "stuck"},
+	}, nil).Once()
+
+	_, err := journal.Restore(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "stuck", journal.GetSagaStatus("saga-1"))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkSagaAsForcedDone_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.now = func() time.Time { return someDate }
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"saga-1"}, nil).Once()
+	storageMock.On("GetSagaEventLogs", "saga-1").Return([]model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "step1", State: "running"},
+		{SagaID: "saga-1", Step: "step1", State: "stuck"},
+	}, nil).Once()
+
+	_, err := journal.Restore(context.Background())
+	require.NoError(t, err)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "saga-1", Step: "_finish", State: "forced", CreatedAt: someDate}).Once().Return(nil)
+	err = journal.MarkSagaAsForcedDone(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "done", journal.GetSagaStatus("saga-1"))
+
+	// A done saga can't be forced nor resumed.
+	err = journal.MarkSagaAsForcedDone(context.Background(), "saga-1")
+	assert.EqualError(t, err, `expected saga "saga-1" to be "stuck", have "done"`)
+
+	err = journal.MarkSubRequestAsResumed(context.Background(), "saga-1", "step1", nil)
+	assert.EqualError(t, err, "the saga is already done")
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkSagaAsForcedDone_with_a_running_saga(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	err = journal.MarkSagaAsForcedDone(context.Background(), sagaID)
+	assert.EqualError(t, err, `expected saga "some-saga-id" to be "stuck", have "running"`)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkSagaAsForcedDone_with_an_unknown_saga(t *testing.T) {
+	journal := New(nil)
+
+	err := journal.MarkSagaAsForcedDone(context.Background(), "some-unknown-saga-id")
+	assert.EqualError(t, err, "saga \"some-unknown-saga-id\" not found into the journal")
+}
+
+func Test_Journal_MarkBranchState_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+	branchCtx := json.RawMessage(`{"id": 1}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "stage", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "done", Context: branchCtx, CreatedAt: someDate}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "stage", Branch: "branch2", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	err = journal.MarkSubRequestAsRunning(context.Background(), sagaID, "stage", sagaCtx)
+	require.NoError(t, err)
+
+	require.NoError(t, journal.MarkBranchState(context.Background(), sagaID, "stage", "branch1", "running", sagaCtx))
+	require.NoError(t, journal.MarkBranchState(context.Background(), sagaID, "stage", "branch1", "done", branchCtx))
+	require.NoError(t, journal.MarkBranchState(context.Background(), sagaID, "stage", "branch2", "running", sagaCtx))
+
+	// The branch eventlogs are not visible as the saga last eventlog.
+	step, state, arg := journal.GetSagaLastEventLog(sagaID)
+	assert.Equal(t, "stage", step)
+	assert.Equal(t, "running", state)
+	assert.Equal(t, sagaCtx, arg)
+
+	states := journal.GetBranchStates(sagaID, "stage")
+	assert.Equal(t, map[string]model.EventLog{
+		"branch1": {SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "done", Context: branchCtx, CreatedAt: someDate},
+		"branch2": {SagaID: "some-saga-id", Step: "stage", Branch: "branch2", State: "running", Context: sagaCtx, CreatedAt: someDate},
+	}, states)
+
+	assert.Empty(t, journal.GetBranchStates(sagaID, "some-other-stage"))
+
+	// The stage can be finished once its branches are finished.
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "stage", State: "done", Context: branchCtx, CreatedAt: someDate}).Once().Return(nil)
+
+	err = journal.MarkSubRequestAsDone(context.Background(), sagaID, "stage", branchCtx)
+	assert.NoError(t, err)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkBranchState_with_storage_error(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "running", Context: sagaCtx, CreatedAt: someDate}).Once().Return(errors.New("some-error"))
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	err = journal.MarkBranchState(context.Background(), sagaID, "stage", "branch1", "running", sagaCtx)
+	assert.EqualError(t, err, "failed to save into the storage: some-error")
+	assert.Empty(t, journal.GetBranchStates(sagaID, "stage"))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_MarkBranchState_with_an_unknown_saga(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	err := journal.MarkBranchState(context.Background(), "some-unknown-saga-id", "stage", "branch1", "running", nil)
+	assert.EqualError(t, err, `saga "some-unknown-saga-id" not found into the journal`)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_Restore_with_branch_eventlogs(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"some-saga-id"}, nil).Once()
+	storageMock.On("GetSagaEventLogs", "some-saga-id").Return([]model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx},
+		{SagaID: "some-saga-id", Step: "stage", State: "running", Context: sagaCtx},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "running", Context: sagaCtx},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "aborted", Context: sagaCtx},
+	}, nil).Once()
+
+	sagaIDs, err := journal.Restore(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"some-saga-id"}, sagaIDs)
+
+	// An aborted branch doesn't abort the saga.
+	step, state, _ := journal.GetSagaLastEventLog("some-saga-id")
+	assert.Equal(t, "stage", step)
+	assert.Equal(t, "running", state)
+	assert.Equal(t, "running", journal.GetSagaStatus("some-saga-id"))
+	assert.Len(t, journal.GetBranchStates("some-saga-id", "stage"), 1)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_GetSagaDefinition_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate, SagaType: "payment", SagaVersion: 2}).Once().Return(nil)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "payment", 2, sagaCtx, nil)
+	require.NoError(t, err)
+
+	sagaType, sagaVersion := journal.GetSagaDefinition(sagaID)
+	assert.Equal(t, "payment", sagaType)
+	assert.Equal(t, 2, sagaVersion)
+
+	sagaType, sagaVersion = journal.GetSagaDefinition("some-unknown-saga-id")
+	assert.Empty(t, sagaType)
+	assert.Zero(t, sagaVersion)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_GetSagaOutputs_success(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	events := []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{"input": 1}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "running", Context: json.RawMessage(`{"input": 1}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "done", Context: json.RawMessage(`{"step1": 1}`)},
+		{SagaID: "some-saga-id", Step: "stage", State: "running", Context: json.RawMessage(`{"step1": 1}`)},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "done", Context: json.RawMessage(`{"branch1": 1}`)},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch2", State: "aborted", Context: json.RawMessage(`{"step1": 1}`)},
+		{SagaID: "some-saga-id", Step: "stage", State: "aborted", Context: json.RawMessage(`{"step1": 1}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "running", Context: json.RawMessage(`{"step1": 1}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "done", Context: json.RawMessage(`{"compensated": true}`)},
+	}
+	for i := range events {
+		require.NoError(t, memory.SaveEventLog(ctx, &events[i]))
+	}
+
+	journal := New(memory)
+	_, err := journal.Restore(ctx)
+	require.NoError(t, err)
+
+	input, outputs := journal.GetSagaOutputs("some-saga-id")
+	assert.Equal(t, json.RawMessage(`{"input": 1}`), input)
+	assert.Equal(t, map[string]json.RawMessage{
+		"step1":         json.RawMessage(`{"step1": 1}`),
+		"stage/branch1": json.RawMessage(`{"branch1": 1}`),
+	}, outputs)
+
+	input, outputs = journal.GetSagaOutputs("some-unknown-saga-id")
+	assert.Nil(t, input)
+	assert.Nil(t, outputs)
+}
+
+func Test_Journal_GetSagaCreatedAt_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, someDate, journal.GetSagaCreatedAt(sagaID))
+	assert.True(t, journal.GetSagaCreatedAt("some-unknown-saga-id").IsZero())
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_GetSagaHistory_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	eventLogs := []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done"},
+		{SagaID: "some-saga-id", Step: "_finish", State: "done"},
+	}
+
+	storageMock.On("GetSagaEventLogs", "some-saga-id").Once().Return(eventLogs, nil)
+
+	res, err := journal.GetSagaHistory(context.Background(), "some-saga-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, eventLogs, res)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_GetSagaHistory_with_a_storage_error(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	storageMock.On("GetSagaEventLogs", "some-saga-id").Once().Return(nil, errors.New("some-error"))
+
+	res, err := journal.GetSagaHistory(context.Background(), "some-saga-id")
+
+	assert.EqualError(t, err, `failed to retrieve the eventlogs for saga "some-saga-id": some-error`)
+	assert.Nil(t, res)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_ListSagas_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	sagas := []model.Saga{{ID: "some-saga-id", Status: "stuck"}}
+
+	storageMock.On("ListSagas", model.SagaQuery{Status: "stuck", Limit: 10}).Once().Return(sagas, "some-cursor", nil)
+
+	res, cursor, err := journal.ListSagas(context.Background(), model.SagaQuery{Status: "stuck", Limit: 10})
+
+	assert.NoError(t, err)
+	assert.Equal(t, sagas, res)
+	assert.Equal(t, "some-cursor", cursor)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_ListSagas_with_a_storage_error(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	storageMock.On("ListSagas", model.SagaQuery{}).Once().Return(nil, "", errors.New("some-error"))
+
+	res, cursor, err := journal.ListSagas(context.Background(), model.SagaQuery{})
+
+	assert.EqualError(t, err, "failed to list the sagas: some-error")
+	assert.Nil(t, res)
+	assert.Empty(t, cursor)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_RequestSagaCancel_success(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.generateID = func() string { return "some-saga-id" }
+	journal.now = func() time.Time { return someDate }
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, CreatedAt: someDate}).Once().Return(nil)
+	sagaID, err := journal.CreateNewSaga(context.Background(), "", 0, sagaCtx, nil)
+	require.NoError(t, err)
+
+	_, requested := journal.GetSagaCancelRequest(sagaID)
+	assert.False(t, requested)
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_cancel", State: "requested", Reason: "some-reason", CreatedAt: someDate}).Once().Return(nil)
+	err = journal.RequestSagaCancel(context.Background(), sagaID, "some-reason")
+	require.NoError(t, err)
+
+	reason, requested := journal.GetSagaCancelRequest(sagaID)
+	assert.True(t, requested)
+	assert.Equal(t, "some-reason", reason)
+
+	// The request doesn't change the Sub-Requests states.
+	step, state, _ := journal.GetSagaLastEventLog(sagaID)
+	assert.Equal(t, "_init", step)
+	assert.Equal(t, "done", state)
+
+	// A second request is ignored.
+	err = journal.RequestSagaCancel(context.Background(), sagaID, "some-other-reason")
+	require.NoError(t, err)
+
+	reason, _ = journal.GetSagaCancelRequest(sagaID)
+	assert.Equal(t, "some-reason", reason)
+
+	journal.DeleteSaga(context.Background(), sagaID)
+	_, requested = journal.GetSagaCancelRequest(sagaID)
+	assert.False(t, requested)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_RequestSagaCancel_with_a_storage_error(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+	journal.now = func() time.Time { return someDate }
+	journal.journal["some-saga-id"] = model.Saga{ID: "some-saga-id", Status: "running", EventLogs: []model.EventLog{{SagaID: "some-saga-id", Step: "_init", State: "done"}}}
+
+	storageMock.On("SaveEventLog", &model.EventLog{SagaID: "some-saga-id", Step: "_cancel", State: "requested", Reason: "some-reason", CreatedAt: someDate}).Once().Return(errors.New("some-error"))
+
+	err := journal.RequestSagaCancel(context.Background(), "some-saga-id", "some-reason")
+	assert.EqualError(t, err, "failed to save into the storage: some-error")
+
+	_, requested := journal.GetSagaCancelRequest("some-saga-id")
+	assert.False(t, requested)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Journal_RequestSagaCancel_with_a_saga_not_running(t *testing.T) {
+	journal := New(nil)
+	journal.journal["some-saga-id"] = model.Saga{ID: "some-saga-id", Status: "aborted"}
+
+	err := journal.RequestSagaCancel(context.Background(), "some-saga-id", "some-reason")
+	assert.EqualError(t, err, `expected saga status to be "running", have "aborted"`)
+}
+
+func Test_Journal_RequestSagaCancel_with_an_unknown_saga(t *testing.T) {
+	journal := New(nil)
+
+	err := journal.RequestSagaCancel(context.Background(), "some-unknown-saga-id", "some-reason")
+	assert.EqualError(t, err, "saga \"some-unknown-saga-id\" not found into the journal")
+}
+
+func Test_Journal_Restore_with_a_cancel_request(t *testing.T) {
+	storageMock := new(storage.Mock)
+	journal := New(storageMock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	storageMock.On("GetUnfinishedSagaIDs").Return([]string{"some-saga-id"}, nil).Once()
+	storageMock.On("GetSagaEventLogs", "some-saga-id").Return([]model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx},
+		{SagaID: "some-saga-id", Step: "step1", State: "running", Context: sagaCtx},
+		{SagaID: "some-saga-id", Step: "_cancel", State: "requested", Reason: "some-reason"},
+	}, nil).Once()
+
+	_, err := journal.Restore(context.Background())
+	require.NoError(t, err)
+
+	reason, requested := journal.GetSagaCancelRequest("some-saga-id")
+	assert.True(t, requested)
+	assert.Equal(t, "some-reason", reason)
+
+	step, state, _ := journal.GetSagaLastEventLog("some-saga-id")
+	assert.Equal(t, "step1", step)
+	assert.Equal(t, "running", state)
+	assert.Equal(t, "running", journal.GetSagaStatus("some-saga-id"))
+
+	storageMock.AssertExpectations(t)
+}
@@ -3,7 +3,9 @@ package journal
 import (
 	"context"
 	"encoding/json"
+	"time"
 
+	"github.com/Peltoche/gosaga/model"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -13,12 +15,28 @@ type Mock struct {
 }
 
 // CreateNewSaga mock.
-func (t *Mock) CreateNewSaga(ctx context.Context, sagaCtx json.RawMessage) (string, error) {
-	args := t.Called(sagaCtx)
+func (t *Mock) CreateNewSaga(ctx context.Context, sagaType string, sagaVersion int, sagaCtx json.RawMessage, metadata map[string]string) (string, error) {
+	args := t.Called(sagaType, sagaVersion, sagaCtx, metadata)
 
 	return args.String(0), args.Error(1)
 }
 
+// CreateSaga mock.
+func (t *Mock) CreateSaga(ctx context.Context, sagaID string, sagaType string, sagaVersion int, sagaCtx json.RawMessage, metadata map[string]string) error {
+	return t.Called(sagaID, sagaType, sagaVersion, sagaCtx, metadata).Error(0)
+}
+
+// Restore mock.
+func (t *Mock) Restore(ctx context.Context) ([]string, error) {
+	args := t.Called()
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
 // MarkSubRequestAsRunning mock.
 func (t *Mock) MarkSubRequestAsRunning(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
 	return t.Called(sagaID, subRequestID, sagaCtx).Error(0)
@@ -29,11 +47,41 @@ func (t *Mock) MarkSubRequestAsDone(ctx context.Context, sagaID string, subReque
 	return t.Called(sagaID, subRequestID, sagaCtx).Error(0)
 }
 
+// MarkSubRequestAsPivoted mock.
+func (t *Mock) MarkSubRequestAsPivoted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.Called(sagaID, subRequestID, sagaCtx).Error(0)
+}
+
+// MarkSubRequestAsFailed mock.
+func (t *Mock) MarkSubRequestAsFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.Called(sagaID, subRequestID, sagaCtx).Error(0)
+}
+
 // MarkSubRequestAsAborted mock.
-func (t *Mock) MarkSubRequestAsAborted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+func (t *Mock) MarkSubRequestAsAborted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, reason string) error {
+	return t.Called(sagaID, subRequestID, sagaCtx, reason).Error(0)
+}
+
+// MarkSubRequestAsStuck mock.
+func (t *Mock) MarkSubRequestAsStuck(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
 	return t.Called(sagaID, subRequestID, sagaCtx).Error(0)
 }
 
+// MarkSubRequestAsResumed mock.
+func (t *Mock) MarkSubRequestAsResumed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.Called(sagaID, subRequestID, sagaCtx).Error(0)
+}
+
+// MarkSubRequestAsSkipped mock.
+func (t *Mock) MarkSubRequestAsSkipped(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) error {
+	return t.Called(sagaID, subRequestID, sagaCtx).Error(0)
+}
+
+// MarkSagaAsForcedDone mock.
+func (t *Mock) MarkSagaAsForcedDone(ctx context.Context, sagaID string) error {
+	return t.Called(sagaID).Error(0)
+}
+
 // MarkSagaAsDone mock.
 func (t *Mock) MarkSagaAsDone(ctx context.Context, sagaID string) error {
 	return t.Called(sagaID).Error(0)
@@ -55,7 +103,104 @@ func (t *Mock) GetSagaLastEventLog(sagaID string) (string, string, json.RawMessa
 	return args.String(0), args.String(1), args.Get(2).(json.RawMessage)
 }
 
+// GetSagasByStatus mock.
+func (t *Mock) GetSagasByStatus(status string) []string {
+	return t.Called(status).Get(0).([]string)
+}
+
+// GetSubRequestAttempts mock.
+func (t *Mock) GetSubRequestAttempts(sagaID string) (int, time.Time) {
+	args := t.Called(sagaID)
+
+	return args.Int(0), args.Get(1).(time.Time)
+}
+
 // DeleteSaga mock.
 func (t *Mock) DeleteSaga(ctx context.Context, sagaID string) {
 	t.Called(sagaID)
 }
+
+// GetSagaMetadata mock.
+func (t *Mock) GetSagaMetadata(sagaID string) map[string]string {
+	args := t.Called(sagaID)
+
+	if args.Get(0) == nil {
+		return nil
+	}
+
+	return args.Get(0).(map[string]string)
+}
+
+// RequestSagaCancel mock.
+func (t *Mock) RequestSagaCancel(ctx context.Context, sagaID string, reason string) error {
+	return t.Called(sagaID, reason).Error(0)
+}
+
+// GetSagaCancelRequest mock.
+func (t *Mock) GetSagaCancelRequest(sagaID string) (string, bool) {
+	args := t.Called(sagaID)
+
+	return args.String(0), args.Bool(1)
+}
+
+// GetSagaCreatedAt mock.
+func (t *Mock) GetSagaCreatedAt(sagaID string) time.Time {
+	return t.Called(sagaID).Get(0).(time.Time)
+}
+
+// GetSagaDefinition mock.
+func (t *Mock) GetSagaDefinition(sagaID string) (string, int) {
+	args := t.Called(sagaID)
+
+	return args.String(0), args.Int(1)
+}
+
+// GetSagaOutputs mock.
+func (t *Mock) GetSagaOutputs(sagaID string) (json.RawMessage, map[string]json.RawMessage) {
+	args := t.Called(sagaID)
+
+	return args.Get(0).(json.RawMessage), args.Get(1).(map[string]json.RawMessage)
+}
+
+// GetSagaRecovery mock.
+func (t *Mock) GetSagaRecovery(sagaID string) string {
+	return t.Called(sagaID).String(0)
+}
+
+// MarkBranchState mock.
+func (t *Mock) MarkBranchState(ctx context.Context, sagaID string, stageID string, branchID string, state string, sagaCtx json.RawMessage) error {
+	return t.Called(sagaID, stageID, branchID, state, sagaCtx).Error(0)
+}
+
+// GetBranchStates mock.
+func (t *Mock) GetBranchStates(sagaID string, stageID string) map[string]model.EventLog {
+	args := t.Called(sagaID, stageID)
+
+	if args.Get(0) == nil {
+		return nil
+	}
+
+	return args.Get(0).(map[string]model.EventLog)
+}
+
+// GetSagaHistory mock.
+func (t *Mock) GetSagaHistory(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	args := t.Called(sagaID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]model.EventLog), args.Error(1)
+}
+
+// ListSagas mock.
+func (t *Mock) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	args := t.Called(query)
+
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+
+	return args.Get(0).([]model.Saga), args.String(1), args.Error(2)
+}
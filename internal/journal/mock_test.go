@@ -3,8 +3,11 @@ package journal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/Peltoche/gosaga/model"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,9 +16,9 @@ func Test_Mock_CreateNewSaga(t *testing.T) {
 
 	sagaCtx := json.RawMessage(`{"key": "value"}`)
 
-	mock.On("CreateNewSaga", sagaCtx).Once().Return("some-saga-id", nil)
+	mock.On("CreateNewSaga", "payment", 2, sagaCtx, map[string]string{"key": "value"}).Once().Return("some-saga-id", nil)
 
-	sagaID, err := mock.CreateNewSaga(context.Background(), sagaCtx)
+	sagaID, err := mock.CreateNewSaga(context.Background(), "payment", 2, sagaCtx, map[string]string{"key": "value"})
 
 	assert.NoError(t, err)
 	assert.Equal(t, "some-saga-id", sagaID)
@@ -23,6 +26,46 @@ func Test_Mock_CreateNewSaga(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+func Test_Mock_CreateSaga(t *testing.T) {
+	mock := new(Mock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	mock.On("CreateSaga", "some-saga-id", "payment", 2, sagaCtx, map[string]string(nil)).Once().Return(errors.New("some-error"))
+
+	err := mock.CreateSaga(context.Background(), "some-saga-id", "payment", 2, sagaCtx, nil)
+
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_Restore(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("Restore").Once().Return([]string{"some-saga-id"}, nil)
+
+	sagaIDs, err := mock.Restore(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some-saga-id"}, sagaIDs)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_Restore_with_nil(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("Restore").Once().Return(nil, errors.New("some-error"))
+
+	sagaIDs, err := mock.Restore(context.Background())
+
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, sagaIDs)
+
+	mock.AssertExpectations(t)
+}
+
 func Test_Mock_MarkSubRequestAsRunning(t *testing.T) {
 	mock := new(Mock)
 
@@ -56,15 +99,95 @@ func Test_Mock_MarkSubRequestAsAborted(t *testing.T) {
 
 	sagaCtx := json.RawMessage(`{"reason": "some-error"}`)
 
-	mock.On("MarkSubRequestAsAborted", "some-saga-id", "some-subrequest-id", sagaCtx).Once().Return(nil)
+	mock.On("MarkSubRequestAsAborted", "some-saga-id", "some-subrequest-id", sagaCtx, "some-reason").Once().Return(nil)
+
+	err := mock.MarkSubRequestAsAborted(context.Background(), "some-saga-id", "some-subrequest-id", sagaCtx, "some-reason")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_MarkSubRequestAsFailed(t *testing.T) {
+	mock := new(Mock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	mock.On("MarkSubRequestAsFailed", "some-saga-id", "some-subrequest-id", sagaCtx).Once().Return(nil)
+
+	err := mock.MarkSubRequestAsFailed(context.Background(), "some-saga-id", "some-subrequest-id", sagaCtx)
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_MarkSubRequestAsStuck(t *testing.T) {
+	mock := new(Mock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	mock.On("MarkSubRequestAsStuck", "some-saga-id", "some-subrequest-id", sagaCtx).Once().Return(nil)
 
-	err := mock.MarkSubRequestAsAborted(context.Background(), "some-saga-id", "some-subrequest-id", sagaCtx)
+	err := mock.MarkSubRequestAsStuck(context.Background(), "some-saga-id", "some-subrequest-id", sagaCtx)
 
 	assert.NoError(t, err)
 
 	mock.AssertExpectations(t)
 }
 
+func Test_Mock_MarkSubRequestAsResumed(t *testing.T) {
+	mock := new(Mock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	mock.On("MarkSubRequestAsResumed", "some-saga-id", "some-subrequest-id", sagaCtx).Once().Return(nil)
+
+	err := mock.MarkSubRequestAsResumed(context.Background(), "some-saga-id", "some-subrequest-id", sagaCtx)
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_MarkSubRequestAsSkipped(t *testing.T) {
+	mock := new(Mock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	mock.On("MarkSubRequestAsSkipped", "some-saga-id", "some-subrequest-id", sagaCtx).Once().Return(nil)
+
+	err := mock.MarkSubRequestAsSkipped(context.Background(), "some-saga-id", "some-subrequest-id", sagaCtx)
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_MarkSagaAsForcedDone(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("MarkSagaAsForcedDone", "some-saga-id").Once().Return(nil)
+
+	err := mock.MarkSagaAsForcedDone(context.Background(), "some-saga-id")
+
+	assert.NoError(t, err)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagasByStatus(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("GetSagasByStatus", "stuck").Once().Return([]string{"some-saga-id"})
+
+	sagaIDs := mock.GetSagasByStatus("stuck")
+
+	assert.Equal(t, []string{"some-saga-id"}, sagaIDs)
+
+	mock.AssertExpectations(t)
+}
+
 func Test_Mock_MarkSagaAsDone(t *testing.T) {
 	mock := new(Mock)
 
@@ -119,6 +242,21 @@ func Test_Mock_GetSagaLastEventLog_with_nil(t *testing.T) {
 	mock.AssertExpectations(t)
 }
 
+func Test_Mock_GetSubRequestAttempts(t *testing.T) {
+	mock := new(Mock)
+
+	date := time.Now()
+
+	mock.On("GetSubRequestAttempts", "some-saga-id").Once().Return(2, date)
+
+	attempts, firstAttempt := mock.GetSubRequestAttempts("some-saga-id")
+
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, date, firstAttempt)
+
+	mock.AssertExpectations(t)
+}
+
 func Test_Mock_DeleteSaga(t *testing.T) {
 	mock := new(Mock)
 
@@ -128,3 +266,160 @@ func Test_Mock_DeleteSaga(t *testing.T) {
 
 	mock.AssertExpectations(t)
 }
+
+func Test_Mock_GetSagaMetadata(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("GetSagaMetadata", "some-saga-id").Once().Return(map[string]string{"key": "value"})
+	mock.On("GetSagaMetadata", "some-unknown-saga-id").Once().Return(nil)
+
+	assert.Equal(t, map[string]string{"key": "value"}, mock.GetSagaMetadata("some-saga-id"))
+	assert.Nil(t, mock.GetSagaMetadata("some-unknown-saga-id"))
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaDefinition(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("GetSagaDefinition", "some-saga-id").Once().Return("payment", 2)
+
+	sagaType, sagaVersion := mock.GetSagaDefinition("some-saga-id")
+	assert.Equal(t, "payment", sagaType)
+	assert.Equal(t, 2, sagaVersion)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_MarkSubRequestAsPivoted(t *testing.T) {
+	mock := new(Mock)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	mock.On("MarkSubRequestAsPivoted", "some-saga-id", "some-subrequest-id", sagaCtx).Once().Return(errors.New("some-error"))
+
+	err := mock.MarkSubRequestAsPivoted(context.Background(), "some-saga-id", "some-subrequest-id", sagaCtx)
+
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaOutputs(t *testing.T) {
+	mock := new(Mock)
+
+	outputs := map[string]json.RawMessage{"step1": json.RawMessage(`{}`)}
+	mock.On("GetSagaOutputs", "some-saga-id").Once().Return(json.RawMessage(`{"key": "value"}`), outputs)
+
+	input, res := mock.GetSagaOutputs("some-saga-id")
+	assert.Equal(t, json.RawMessage(`{"key": "value"}`), input)
+	assert.Equal(t, outputs, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaRecovery(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("GetSagaRecovery", "some-saga-id").Once().Return("forward")
+
+	assert.Equal(t, "forward", mock.GetSagaRecovery("some-saga-id"))
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaCreatedAt(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("GetSagaCreatedAt", "some-saga-id").Once().Return(someDate)
+
+	assert.Equal(t, someDate, mock.GetSagaCreatedAt("some-saga-id"))
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_RequestSagaCancel(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("RequestSagaCancel", "some-saga-id", "some-reason").Once().Return(errors.New("some-error"))
+
+	assert.EqualError(t, mock.RequestSagaCancel(context.Background(), "some-saga-id", "some-reason"), "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaCancelRequest(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("GetSagaCancelRequest", "some-saga-id").Once().Return("some-reason", true)
+
+	reason, ok := mock.GetSagaCancelRequest("some-saga-id")
+	assert.Equal(t, "some-reason", reason)
+	assert.True(t, ok)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_MarkBranchState(t *testing.T) {
+	mock := new(Mock)
+
+	mock.On("MarkBranchState", "some-saga-id", "stage", "branch1", "running", json.RawMessage(`{}`)).Once().Return(errors.New("some-error"))
+
+	err := mock.MarkBranchState(context.Background(), "some-saga-id", "stage", "branch1", "running", json.RawMessage(`{}`))
+	assert.EqualError(t, err, "some-error")
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetBranchStates(t *testing.T) {
+	mock := new(Mock)
+
+	states := map[string]model.EventLog{"branch1": {Step: "stage", Branch: "branch1", State: "done"}}
+
+	mock.On("GetBranchStates", "some-saga-id", "stage").Once().Return(states)
+	mock.On("GetBranchStates", "some-saga-id", "some-other-stage").Once().Return(nil)
+
+	assert.Equal(t, states, mock.GetBranchStates("some-saga-id", "stage"))
+	assert.Nil(t, mock.GetBranchStates("some-saga-id", "some-other-stage"))
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaHistory(t *testing.T) {
+	mock := new(Mock)
+
+	eventLogs := []model.EventLog{{SagaID: "some-saga-id", Step: "_init", State: "done"}}
+
+	mock.On("GetSagaHistory", "some-saga-id").Once().Return(eventLogs, nil)
+	mock.On("GetSagaHistory", "some-unknown-saga-id").Once().Return(nil, errors.New("some-error"))
+
+	res, err := mock.GetSagaHistory(context.Background(), "some-saga-id")
+	assert.NoError(t, err)
+	assert.Equal(t, eventLogs, res)
+
+	res, err = mock.GetSagaHistory(context.Background(), "some-unknown-saga-id")
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, res)
+
+	mock.AssertExpectations(t)
+}
+
+func Test_Mock_ListSagas(t *testing.T) {
+	mock := new(Mock)
+
+	sagas := []model.Saga{{ID: "some-saga-id", Status: "running"}}
+
+	mock.On("ListSagas", model.SagaQuery{Limit: 1}).Once().Return(sagas, "some-cursor", nil)
+	mock.On("ListSagas", model.SagaQuery{}).Once().Return(nil, "", errors.New("some-error"))
+
+	res, cursor, err := mock.ListSagas(context.Background(), model.SagaQuery{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, sagas, res)
+	assert.Equal(t, "some-cursor", cursor)
+
+	res, _, err = mock.ListSagas(context.Background(), model.SagaQuery{})
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, res)
+
+	mock.AssertExpectations(t)
+}
@@ -0,0 +1,83 @@
+package gosaga
+
+import (
+	"context"
+	"log/slog"
+)
+
+// The keys of the attributes added to the log records.
+const (
+	LogKeySagaID       = "saga_id"
+	LogKeySubRequestID = "sub_request_id"
+	LogKeyPhase        = "phase"
+	LogKeyOutcome      = "outcome"
+	LogKeyError        = "error"
+)
+
+// The values of the LogKeyPhase attribute.
+const (
+	PhaseAction       = "action"
+	PhaseCompensation = "compensation"
+)
+
+// WithLogger set the logger used to report the saga executions.
+//
+// Nothing is logged by default.
+func (t *SEC) WithLogger(logger *slog.Logger) *SEC {
+	if logger == nil {
+		logger = slog.New(noopHandler{})
+	}
+
+	t.logger = logger
+
+	return t
+}
+
+// logSubRequest log an event about a Sub-Request Action or Compensation.
+func (t *SEC) logSubRequest(ctx context.Context, level slog.Level, msg string, sagaID string, subRequestID string, phase string, outcome string, err error) {
+	if t.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String(LogKeySagaID, sagaID),
+		slog.String(LogKeySubRequestID, subRequestID),
+		slog.String(LogKeyPhase, phase),
+	}
+
+	if outcome != "" {
+		attrs = append(attrs, slog.String(LogKeyOutcome, outcome))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String(LogKeyError, err.Error()))
+	}
+
+	t.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// logSaga log an event about a whole saga.
+func (t *SEC) logSaga(ctx context.Context, level slog.Level, msg string, sagaID string, outcome string, err error) {
+	if t.logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String(LogKeySagaID, sagaID),
+		slog.String(LogKeyOutcome, outcome),
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.String(LogKeyError, err.Error()))
+	}
+
+	t.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// noopHandler is a slog.Handler discarding all the records.
+type noopHandler struct{}
+
+func (noopHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (noopHandler) Handle(context.Context, slog.Record) error { return nil }
+func (t noopHandler) WithAttrs([]slog.Attr) slog.Handler      { return t }
+func (t noopHandler) WithGroup(string) slog.Handler           { return t }
@@ -0,0 +1,122 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordHandler is a slog.Handler keeping all the records in memory.
+type recordHandler struct {
+	mutex   sync.Mutex
+	records []slog.Record
+}
+
+func (t *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (t *recordHandler) WithAttrs([]slog.Attr) slog.Handler       { return t }
+func (t *recordHandler) WithGroup(string) slog.Handler            { return t }
+
+func (t *recordHandler) Handle(ctx context.Context, record slog.Record) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.records = append(t.records, record.Clone())
+
+	return nil
+}
+
+// Lines return a line per record without the saga ID which is random.
+func (t *recordHandler) Lines() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	res := []string{}
+	for _, record := range t.records {
+		line := []string{record.Level.String(), record.Message}
+		record.Attrs(func(attr slog.Attr) bool {
+			if attr.Key != LogKeySagaID {
+				line = append(line, attr.String())
+			}
+
+			return true
+		})
+
+		res = append(res, strings.Join(line, " "))
+	}
+
+	return res
+}
+
+func Test_SEC_WithLogger_success(t *testing.T) {
+	handler := new(recordHandler)
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithLogger(slog.New(handler)).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"DEBUG run sub-request sub_request_id=step1 phase=action",
+		"INFO sub-request succeeded sub_request_id=step1 phase=action outcome=success",
+		"INFO saga finished outcome=committed",
+	}, handler.Lines())
+
+	// All the records have the saga ID.
+	for _, record := range handler.records {
+		found := false
+		record.Attrs(func(attr slog.Attr) bool {
+			found = found || (attr.Key == LogKeySagaID && attr.Value.String() != "")
+			return true
+		})
+		assert.True(t, found)
+	}
+}
+
+func Test_SEC_WithLogger_with_a_stuck_saga(t *testing.T) {
+	handler := new(recordHandler)
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithLogger(slog.New(handler)).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-compensation-error"), sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 2}))
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"DEBUG run sub-request sub_request_id=step1 phase=action",
+		"WARN sub-request failed sub_request_id=step1 phase=action outcome=aborted error=some-error",
+		"DEBUG run sub-request sub_request_id=step1 phase=compensation",
+		"WARN sub-request failed sub_request_id=step1 phase=compensation outcome=retry error=some-compensation-error",
+		"DEBUG run sub-request sub_request_id=step1 phase=compensation",
+		"ERROR sub-request failed sub_request_id=step1 phase=compensation outcome=stuck error=some-compensation-error",
+		fmt.Sprintf("ERROR saga stuck outcome=stuck error=%s", err),
+	}, handler.Lines())
+}
+
+func Test_SEC_WithLogger_with_a_nil_logger(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithLogger(nil).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	assert.NoError(t, err)
+}
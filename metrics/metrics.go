@@ -0,0 +1,228 @@
+// Package metrics expose Prometheus metrics about the sagas.
+//
+// The Collector must be registered as an Observer of the SEC and into a
+// Prometheus registry:
+//
+//	collector := metrics.NewCollector()
+//	prometheus.MustRegister(collector)
+//
+//	sec := gosaga.NewSagaExecutionCoordinator(collector.WrapStorage(storage)).
+//		WithObserver(collector)
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "gosaga"
+
+// Collector is a prometheus.Collector fed by the saga events.
+type Collector struct {
+	sagasStarted    prometheus.Counter
+	sagasFinished   *prometheus.CounterVec
+	sagasInFlight   prometheus.Gauge
+	stepDuration    *prometheus.HistogramVec
+	stepFailures    *prometheus.CounterVec
+	storageDuration *prometheus.HistogramVec
+	storageFailures *prometheus.CounterVec
+	mutex           *sync.Mutex
+	inFlight        map[string]bool
+	stepStartedAt   map[string]map[string]time.Time
+	now             func() time.Time
+	collectors      []prometheus.Collector
+}
+
+// NewCollector instantiate a new Collector.
+func NewCollector() *Collector {
+	t := &Collector{
+		sagasStarted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sagas_started_total",
+			Help:      "Number of sagas created.",
+		}),
+		sagasFinished: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sagas_finished_total",
+			Help:      "Number of sagas finished by status: committed, compensated or stuck.",
+		}, []string{"status"}),
+		sagasInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "sagas_in_flight",
+			Help:      "Number of sagas currently run.",
+		}),
+		stepDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "sub_request_duration_seconds",
+			Help:      "Duration of the Action and Compensation attempts.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"sub_request", "phase"}),
+		stepFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "sub_request_failures_total",
+			Help:      "Number of failed Action and Compensation attempts.",
+		}, []string{"sub_request", "phase"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_duration_seconds",
+			Help:      "Duration of the storage calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation"}),
+		storageFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_failures_total",
+			Help:      "Number of failed storage calls.",
+		}, []string{"operation"}),
+		mutex:         new(sync.Mutex),
+		inFlight:      map[string]bool{},
+		stepStartedAt: map[string]map[string]time.Time{},
+		now:           time.Now,
+	}
+
+	t.collectors = []prometheus.Collector{
+		t.sagasStarted,
+		t.sagasFinished,
+		t.sagasInFlight,
+		t.stepDuration,
+		t.stepFailures,
+		t.storageDuration,
+		t.storageFailures,
+	}
+
+	return t
+}
+
+// Describe implements prometheus.Collector.
+func (t *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range t.collectors {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (t *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range t.collectors {
+		collector.Collect(ch)
+	}
+}
+
+// SagaCreated implements gosaga.Observer.
+func (t *Collector) SagaCreated(ctx context.Context, sagaID string, sagaCtx json.RawMessage) {
+	t.sagasStarted.Inc()
+	t.markInFlight(sagaID)
+}
+
+// SubRequestStarted implements gosaga.Observer.
+func (t *Collector) SubRequestStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) {
+	t.startStep(sagaID, subRequestID)
+}
+
+// SubRequestSucceeded implements gosaga.Observer.
+func (t *Collector) SubRequestSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) {
+	t.endStep(sagaID, subRequestID, gosaga.PhaseAction, false)
+}
+
+// SubRequestFailed implements gosaga.Observer.
+func (t *Collector) SubRequestFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error) {
+	t.endStep(sagaID, subRequestID, gosaga.PhaseAction, true)
+}
+
+// CompensationStarted implements gosaga.Observer.
+func (t *Collector) CompensationStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) {
+	t.startStep(sagaID, subRequestID)
+}
+
+// CompensationSucceeded implements gosaga.Observer.
+func (t *Collector) CompensationSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) {
+	t.endStep(sagaID, subRequestID, gosaga.PhaseCompensation, false)
+}
+
+// CompensationFailed implements gosaga.Observer.
+func (t *Collector) CompensationFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error) {
+	t.endStep(sagaID, subRequestID, gosaga.PhaseCompensation, true)
+}
+
+// SagaCommitted implements gosaga.Observer.
+func (t *Collector) SagaCommitted(ctx context.Context, sagaID string, sagaCtx json.RawMessage) {
+	t.endSaga(sagaID, "committed")
+}
+
+// SagaCompensated implements gosaga.Observer.
+func (t *Collector) SagaCompensated(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error) {
+	t.endSaga(sagaID, "compensated")
+}
+
+// SagaStuck implements gosaga.Observer.
+func (t *Collector) SagaStuck(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error) {
+	t.endSaga(sagaID, "stuck")
+}
+
+// markInFlight count the saga as run. The sagas recovered after a restart are
+// counted at their first step.
+func (t *Collector) markInFlight(sagaID string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !t.inFlight[sagaID] {
+		t.inFlight[sagaID] = true
+		t.sagasInFlight.Inc()
+	}
+}
+
+func (t *Collector) startStep(sagaID string, subRequestID string) {
+	t.markInFlight(sagaID)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// The branches of a parallel stage are run concurrently.
+	if t.stepStartedAt[sagaID] == nil {
+		t.stepStartedAt[sagaID] = map[string]time.Time{}
+	}
+
+	t.stepStartedAt[sagaID][subRequestID] = t.now()
+}
+
+func (t *Collector) endStep(sagaID string, subRequestID string, phase string, failed bool) {
+	t.mutex.Lock()
+	startedAt, ok := t.stepStartedAt[sagaID][subRequestID]
+	delete(t.stepStartedAt[sagaID], subRequestID)
+	t.mutex.Unlock()
+
+	if ok {
+		t.stepDuration.WithLabelValues(subRequestID, phase).Observe(t.now().Sub(startedAt).Seconds())
+	}
+
+	if failed {
+		t.stepFailures.WithLabelValues(subRequestID, phase).Inc()
+	}
+}
+
+func (t *Collector) endSaga(sagaID string, status string) {
+	t.sagasFinished.WithLabelValues(status).Inc()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// A step interrupted by a cancellation or a crash never end.
+	delete(t.stepStartedAt, sagaID)
+
+	if t.inFlight[sagaID] {
+		delete(t.inFlight, sagaID)
+		t.sagasInFlight.Dec()
+	}
+}
+
+// observeStorage record the duration and the result of a storage call.
+func (t *Collector) observeStorage(operation string, startedAt time.Time, err error) {
+	t.storageDuration.WithLabelValues(operation).Observe(t.now().Sub(startedAt).Seconds())
+
+	if err != nil {
+		t.storageFailures.WithLabelValues(operation).Inc()
+	}
+}
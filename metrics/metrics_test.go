@@ -0,0 +1,183 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestCollector return a Collector with a clock moving of one second at
+// each call.
+func newTestCollector() *Collector {
+	collector := NewCollector()
+
+	date := time.Date(2019, time.January, 2, 15, 4, 5, 0, time.UTC)
+	collector.now = func() time.Time {
+		date = date.Add(time.Second)
+		return date
+	}
+
+	return collector
+}
+
+// histogram return the sample count and sum of the given histogram.
+func histogram(t *testing.T, collector prometheus.Collector, name string, labels map[string]string) (uint64, float64) {
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metrics:
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue metrics
+				}
+			}
+
+			return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+		}
+	}
+
+	return 0, 0
+}
+
+func Test_Collector_should_be_registrable(t *testing.T) {
+	registry := prometheus.NewPedanticRegistry()
+
+	err := registry.Register(NewCollector())
+	assert.NoError(t, err)
+}
+
+func Test_Collector_with_a_committed_saga(t *testing.T) {
+	collector := newTestCollector()
+
+	var inFlight float64
+	scheduler := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(collector).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			inFlight = testutil.ToFloat64(collector.sagasInFlight)
+			return gosaga.Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, 1.0, inFlight)
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.sagasInFlight))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.sagasStarted))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.sagasFinished.WithLabelValues("committed")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.stepFailures.WithLabelValues("step1", gosaga.PhaseAction)))
+
+	count, sum := histogram(t, collector, "gosaga_sub_request_duration_seconds", map[string]string{"sub_request": "step1", "phase": gosaga.PhaseAction})
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 1.0, sum)
+}
+
+func Test_Collector_with_a_compensated_saga(t *testing.T) {
+	collector := newTestCollector()
+
+	compensations := 0
+	scheduler := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(collector).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Success(sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			compensations++
+			if compensations == 1 {
+				return gosaga.Failure(errors.New("some-compensation-error"), sagaCtx)
+			}
+
+			return gosaga.Success(sagaCtx)
+		}, gosaga.WithCompensationRetry(gosaga.RetryPolicy{MaxAttempts: 2})).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Success(sagaCtx)
+		})
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.sagasFinished.WithLabelValues("compensated")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.stepFailures.WithLabelValues("step2", gosaga.PhaseAction)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.stepFailures.WithLabelValues("step1", gosaga.PhaseCompensation)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.sagasInFlight))
+
+	count, _ := histogram(t, collector, "gosaga_sub_request_duration_seconds", map[string]string{"sub_request": "step1", "phase": gosaga.PhaseCompensation})
+	assert.Equal(t, uint64(2), count)
+}
+
+func Test_Collector_with_a_stuck_saga(t *testing.T) {
+	collector := newTestCollector()
+
+	scheduler := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(collector).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Failure(errors.New("some-compensation-error"), sagaCtx)
+		}, gosaga.WithCompensationRetry(gosaga.RetryPolicy{MaxAttempts: 3}))
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.sagasFinished.WithLabelValues("stuck")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(collector.stepFailures.WithLabelValues("step1", gosaga.PhaseCompensation)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.sagasInFlight))
+}
+
+func Test_Collector_with_a_recovered_saga(t *testing.T) {
+	collector := newTestCollector()
+
+	collector.CompensationStarted(context.Background(), "some-saga-id", "step1", nil)
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.sagasInFlight))
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.sagasStarted))
+
+	collector.CompensationSucceeded(context.Background(), "some-saga-id", "step1", nil)
+	collector.SagaCompensated(context.Background(), "some-saga-id", nil, errors.New("some-error"))
+	assert.Equal(t, 0.0, testutil.ToFloat64(collector.sagasInFlight))
+}
+
+func Test_Collector_with_a_step_never_ended(t *testing.T) {
+	collector := newTestCollector()
+
+	collector.SubRequestStarted(context.Background(), "some-saga-id", "step1", nil)
+	collector.SagaStuck(context.Background(), "some-saga-id", nil, errors.New("some-error"))
+
+	assert.Empty(t, collector.stepStartedAt)
+}
+
+func Test_Collector_with_the_concurrent_branches_of_a_stage(t *testing.T) {
+	collector := newTestCollector()
+	ctx := context.Background()
+
+	collector.SubRequestStarted(ctx, "some-saga-id", "stage/branch1", nil)
+	collector.SubRequestStarted(ctx, "some-saga-id", "stage/branch2", nil)
+	collector.SubRequestSucceeded(ctx, "some-saga-id", "stage/branch1", nil)
+	collector.SubRequestSucceeded(ctx, "some-saga-id", "stage/branch2", nil)
+
+	// Each branch is measured from its own start.
+	count, sum := histogram(t, collector, "gosaga_sub_request_duration_seconds", map[string]string{"sub_request": "stage/branch1", "phase": gosaga.PhaseAction})
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 2.0, sum)
+
+	count, sum = histogram(t, collector, "gosaga_sub_request_duration_seconds", map[string]string{"sub_request": "stage/branch2", "phase": gosaga.PhaseAction})
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 2.0, sum)
+}
@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/model"
+)
+
+// Storage is a storage measuring the duration of each call to the wrapped
+// storage.
+type Storage struct {
+	storage   journal.Storage
+	collector *Collector
+}
+
+// WrapStorage return a Storage measuring the calls to the given storage.
+func (t *Collector) WrapStorage(storage journal.Storage) *Storage {
+	return &Storage{
+		storage:   storage,
+		collector: t,
+	}
+}
+
+// SaveEventLog call the wrapped storage.
+func (t *Storage) SaveEventLog(ctx context.Context, event *model.EventLog) error {
+	startedAt := t.collector.now()
+
+	err := t.storage.SaveEventLog(ctx, event)
+	t.collector.observeStorage("save_event_log", startedAt, err)
+
+	return err
+}
+
+// GetUnfinishedSagaIDs call the wrapped storage.
+func (t *Storage) GetUnfinishedSagaIDs(ctx context.Context) ([]string, error) {
+	startedAt := t.collector.now()
+
+	res, err := t.storage.GetUnfinishedSagaIDs(ctx)
+	t.collector.observeStorage("get_unfinished_saga_ids", startedAt, err)
+
+	return res, err
+}
+
+// GetSagaEventLogs call the wrapped storage.
+func (t *Storage) GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	startedAt := t.collector.now()
+
+	res, err := t.storage.GetSagaEventLogs(ctx, sagaID)
+	t.collector.observeStorage("get_saga_event_logs", startedAt, err)
+
+	return res, err
+}
+
+// ListSagas call the wrapped storage.
+func (t *Storage) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	startedAt := t.collector.now()
+
+	res, cursor, err := t.storage.ListSagas(ctx, query)
+	t.collector.observeStorage("list_sagas", startedAt, err)
+
+	return res, cursor, err
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Storage_SaveEventLog(t *testing.T) {
+	collector := newTestCollector()
+	storageMock := new(storage.Mock)
+	wrapped := collector.WrapStorage(storageMock)
+
+	event := &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done"}
+	storageMock.On("SaveEventLog", event).Once().Return(nil)
+	storageMock.On("SaveEventLog", event).Once().Return(errors.New("some-error"))
+
+	assert.NoError(t, wrapped.SaveEventLog(context.Background(), event))
+	assert.EqualError(t, wrapped.SaveEventLog(context.Background(), event), "some-error")
+
+	count, sum := histogram(t, collector, "gosaga_storage_duration_seconds", map[string]string{"operation": "save_event_log"})
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, 2.0, sum)
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.storageFailures.WithLabelValues("save_event_log")))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Storage_GetUnfinishedSagaIDs(t *testing.T) {
+	collector := newTestCollector()
+	storageMock := new(storage.Mock)
+	wrapped := collector.WrapStorage(storageMock)
+
+	storageMock.On("GetUnfinishedSagaIDs").Once().Return([]string{"some-saga-id"}, nil)
+
+	res, err := wrapped.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some-saga-id"}, res)
+
+	count, _ := histogram(t, collector, "gosaga_storage_duration_seconds", map[string]string{"operation": "get_unfinished_saga_ids"})
+	assert.Equal(t, uint64(1), count)
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Storage_GetSagaEventLogs(t *testing.T) {
+	collector := newTestCollector()
+	storageMock := new(storage.Mock)
+	wrapped := collector.WrapStorage(storageMock)
+
+	storageMock.On("GetSagaEventLogs", "some-saga-id").Once().Return(nil, errors.New("some-error"))
+
+	res, err := wrapped.GetSagaEventLogs(context.Background(), "some-saga-id")
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, res)
+
+	count, _ := histogram(t, collector, "gosaga_storage_duration_seconds", map[string]string{"operation": "get_saga_event_logs"})
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.storageFailures.WithLabelValues("get_saga_event_logs")))
+
+	storageMock.AssertExpectations(t)
+}
+
+func Test_Storage_ListSagas(t *testing.T) {
+	collector := newTestCollector()
+	storageMock := new(storage.Mock)
+	wrapped := collector.WrapStorage(storageMock)
+
+	sagas := []model.Saga{{ID: "some-saga-id", Status: "running"}}
+	storageMock.On("ListSagas", model.SagaQuery{Limit: 1}).Once().Return(sagas, "some-cursor", nil)
+
+	res, cursor, err := wrapped.ListSagas(context.Background(), model.SagaQuery{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, sagas, res)
+	assert.Equal(t, "some-cursor", cursor)
+
+	count, _ := histogram(t, collector, "gosaga_storage_duration_seconds", map[string]string{"operation": "list_sagas"})
+	assert.Equal(t, uint64(1), count)
+
+	storageMock.AssertExpectations(t)
+}
@@ -2,8 +2,14 @@ package model
 
 import (
 	"encoding/json"
+	"errors"
+	"time"
 )
 
+// ErrSagaAlreadyExists is wrapped by the errors returned by the storages when
+// an "_init" eventlog is saved for an existing saga.
+var ErrSagaAlreadyExists = errors.New("saga already exists")
+
 // Saga represent a distributed transaction.
 type Saga struct {
 	ID        string
@@ -17,4 +23,74 @@ type EventLog struct {
 	Step    string
 	State   string
 	Context json.RawMessage
+
+	// CreatedAt is the date of the change.
+	CreatedAt time.Time
+
+	// Metadata contains the values attached to the saga at its creation, like
+	// a trace context. It is set only for the "_init" eventlog.
+	Metadata map[string]string
+
+	// SagaType and SagaVersion identify the definition the saga have been
+	// started with. They are set only for the "_init" eventlog and are empty
+	// for the sagas using the SEC Sub-Requests.
+	SagaType    string
+	SagaVersion int
+
+	// Reason explain why a Sub-Request have been aborted, like the error
+	// returned by its Action or a timeout.
+	Reason string
+
+	// Branch is set for the changes of a branch of a parallel stage, Step
+	// being the stage.
+	Branch string
+}
+
+// SagaQuery select the sagas listed by a storage.
+type SagaQuery struct {
+	// Status keep only the sagas with the given status: "running",
+	// "aborted", "stuck" or "done". Empty means any status.
+	Status string
+
+	// SagaType and SagaVersion keep only the sagas started with the given
+	// definition. Empty and zero mean any definition and any version.
+	SagaType    string
+	SagaVersion int
+
+	// CreatedAfter and CreatedBefore keep only the sagas created into the
+	// [CreatedAfter, CreatedBefore) range. Zero means no limit.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+
+	// Cursor is the value returned with the previous page, empty for the
+	// first one.
+	Cursor string
+
+	// Limit is the maximum number of sagas returned. Zero means no limit.
+	Limit int
+}
+
+// ComputeStatus return the status of a saga from all its eventlogs.
+//
+// A saga is "done" once finished. Otherwise it stays "aborted" as soon as one
+// of its sub-requests have been aborted and is "stuck" while waiting for a
+// manual intervention.
+func ComputeStatus(eventLogs []EventLog) string {
+	status := "running"
+	for _, eventLog := range eventLogs {
+		if eventLog.Branch != "" {
+			continue
+		}
+
+		switch {
+		case eventLog.Step == "_finish":
+			return "done"
+		case eventLog.State == "aborted", eventLog.State == "resumed", eventLog.State == "skipped":
+			status = "aborted"
+		case eventLog.State == "stuck":
+			status = "stuck"
+		}
+	}
+
+	return status
 }
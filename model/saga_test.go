@@ -0,0 +1,19 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ComputeStatus(t *testing.T) {
+	assert.Equal(t, "running", ComputeStatus([]EventLog{{Step: "_init", State: "done"}, {Step: "step1", State: "running"}}))
+	assert.Equal(t, "aborted", ComputeStatus([]EventLog{{Step: "step1", State: "aborted"}, {Step: "step1", State: "running"}}))
+	assert.Equal(t, "stuck", ComputeStatus([]EventLog{{Step: "step1", State: "aborted"}, {Step: "step1", State: "stuck"}}))
+	assert.Equal(t, "aborted", ComputeStatus([]EventLog{{Step: "step1", State: "stuck"}, {Step: "step1", State: "resumed"}}))
+	assert.Equal(t, "done", ComputeStatus([]EventLog{{Step: "step1", State: "stuck"}, {Step: "_finish", State: "forced"}}))
+}
+
+func Test_ComputeStatus_should_ignore_the_branches(t *testing.T) {
+	assert.Equal(t, "running", ComputeStatus([]EventLog{{Step: "stage", Branch: "branch1", State: "aborted"}}))
+}
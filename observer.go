@@ -0,0 +1,146 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Observer is notified of the progress of the sagas.
+//
+// The callbacks are called synchronously by the goroutine running the saga so
+// they must be fast and safe for concurrent use. Embed NopObserver in order to
+// implement only some of them.
+type Observer interface {
+	// SagaCreated is called once the saga is saved into the journal.
+	SagaCreated(ctx context.Context, sagaID string, sagaCtx json.RawMessage)
+
+	// SubRequestStarted is called before each Action attempt.
+	SubRequestStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage)
+	// SubRequestSucceeded is called with the context returned by the Action.
+	SubRequestSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage)
+	// SubRequestFailed is called for each failed Action attempt, retried or
+	// not.
+	SubRequestFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error)
+
+	// CompensationStarted is called before each Compensation attempt.
+	CompensationStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage)
+	// CompensationSucceeded is called with the context returned by the
+	// Compensation.
+	CompensationSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage)
+	// CompensationFailed is called for each failed Compensation attempt.
+	CompensationFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error)
+
+	// SagaCommitted is called once all the Actions have succeeded.
+	SagaCommitted(ctx context.Context, sagaID string, sagaCtx json.RawMessage)
+	// SagaCompensated is called once an aborted saga is rollbacked.
+	SagaCompensated(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error)
+	// SagaStuck is called when a Compensation have exhausted its
+	// RetryPolicy.
+	SagaStuck(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error)
+}
+
+// WithObserver register an Observer notified of the progress of the sagas.
+//
+// It can be called several times, the observers are called in their
+// registration order.
+func (t *SEC) WithObserver(observer Observer) *SEC {
+	t.observers = append(t.observers, observer)
+
+	return t
+}
+
+// NopObserver is an Observer doing nothing.
+type NopObserver struct{}
+
+// SagaCreated implements Observer.
+func (NopObserver) SagaCreated(context.Context, string, json.RawMessage) {}
+
+// SubRequestStarted implements Observer.
+func (NopObserver) SubRequestStarted(context.Context, string, string, json.RawMessage) {}
+
+// SubRequestSucceeded implements Observer.
+func (NopObserver) SubRequestSucceeded(context.Context, string, string, json.RawMessage) {}
+
+// SubRequestFailed implements Observer.
+func (NopObserver) SubRequestFailed(context.Context, string, string, json.RawMessage, error) {}
+
+// CompensationStarted implements Observer.
+func (NopObserver) CompensationStarted(context.Context, string, string, json.RawMessage) {}
+
+// CompensationSucceeded implements Observer.
+func (NopObserver) CompensationSucceeded(context.Context, string, string, json.RawMessage) {}
+
+// CompensationFailed implements Observer.
+func (NopObserver) CompensationFailed(context.Context, string, string, json.RawMessage, error) {}
+
+// SagaCommitted implements Observer.
+func (NopObserver) SagaCommitted(context.Context, string, json.RawMessage) {}
+
+// SagaCompensated implements Observer.
+func (NopObserver) SagaCompensated(context.Context, string, json.RawMessage, error) {}
+
+// SagaStuck implements Observer.
+func (NopObserver) SagaStuck(context.Context, string, json.RawMessage, error) {}
+
+// observers forward each event to all the registered observers.
+type observers []Observer
+
+func (t observers) SagaCreated(ctx context.Context, sagaID string, sagaCtx json.RawMessage) {
+	for _, observer := range t {
+		observer.SagaCreated(ctx, sagaID, sagaCtx)
+	}
+}
+
+func (t observers) SubRequestStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) {
+	for _, observer := range t {
+		observer.SubRequestStarted(ctx, sagaID, subRequestID, sagaCtx)
+	}
+}
+
+func (t observers) SubRequestSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) {
+	for _, observer := range t {
+		observer.SubRequestSucceeded(ctx, sagaID, subRequestID, result)
+	}
+}
+
+func (t observers) SubRequestFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error) {
+	for _, observer := range t {
+		observer.SubRequestFailed(ctx, sagaID, subRequestID, sagaCtx, err)
+	}
+}
+
+func (t observers) CompensationStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) {
+	for _, observer := range t {
+		observer.CompensationStarted(ctx, sagaID, subRequestID, sagaCtx)
+	}
+}
+
+func (t observers) CompensationSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) {
+	for _, observer := range t {
+		observer.CompensationSucceeded(ctx, sagaID, subRequestID, result)
+	}
+}
+
+func (t observers) CompensationFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error) {
+	for _, observer := range t {
+		observer.CompensationFailed(ctx, sagaID, subRequestID, sagaCtx, err)
+	}
+}
+
+func (t observers) SagaCommitted(ctx context.Context, sagaID string, sagaCtx json.RawMessage) {
+	for _, observer := range t {
+		observer.SagaCommitted(ctx, sagaID, sagaCtx)
+	}
+}
+
+func (t observers) SagaCompensated(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error) {
+	for _, observer := range t {
+		observer.SagaCompensated(ctx, sagaID, sagaCtx, err)
+	}
+}
+
+func (t observers) SagaStuck(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error) {
+	for _, observer := range t {
+		observer.SagaStuck(ctx, sagaID, sagaCtx, err)
+	}
+}
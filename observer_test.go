@@ -0,0 +1,174 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eventObserver save each event as a string.
+type eventObserver struct {
+	mutex  sync.Mutex
+	events []string
+}
+
+func (t *eventObserver) add(format string, args ...interface{}) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.events = append(t.events, fmt.Sprintf(format, args...))
+}
+
+func (t *eventObserver) SagaCreated(ctx context.Context, sagaID string, sagaCtx json.RawMessage) {
+	t.add("saga created %s", sagaCtx)
+}
+
+func (t *eventObserver) SubRequestStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) {
+	t.add("sub-request started %s %s", subRequestID, sagaCtx)
+}
+
+func (t *eventObserver) SubRequestSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) {
+	t.add("sub-request succeeded %s %s", subRequestID, result)
+}
+
+func (t *eventObserver) SubRequestFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error) {
+	t.add("sub-request failed %s %s", subRequestID, err)
+}
+
+func (t *eventObserver) CompensationStarted(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage) {
+	t.add("compensation started %s", subRequestID)
+}
+
+func (t *eventObserver) CompensationSucceeded(ctx context.Context, sagaID string, subRequestID string, result json.RawMessage) {
+	t.add("compensation succeeded %s", subRequestID)
+}
+
+func (t *eventObserver) CompensationFailed(ctx context.Context, sagaID string, subRequestID string, sagaCtx json.RawMessage, err error) {
+	t.add("compensation failed %s %s", subRequestID, err)
+}
+
+func (t *eventObserver) SagaCommitted(ctx context.Context, sagaID string, sagaCtx json.RawMessage) {
+	t.add("saga committed %s", sagaCtx)
+}
+
+func (t *eventObserver) SagaCompensated(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error) {
+	t.add("saga compensated")
+}
+
+func (t *eventObserver) SagaStuck(ctx context.Context, sagaID string, sagaCtx json.RawMessage, err error) {
+	t.add("saga stuck")
+}
+
+func Test_SEC_WithObserver_with_a_committed_saga(t *testing.T) {
+	observer := new(eventObserver)
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(observer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(json.RawMessage(`{"step1": "done"}`))
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`saga created {"key": "value"}`,
+		`sub-request started step1 {"key": "value"}`,
+		`sub-request succeeded step1 {"step1": "done"}`,
+		`saga committed {"step1": "done"}`,
+	}, observer.events)
+}
+
+func Test_SEC_WithObserver_with_a_compensated_saga(t *testing.T) {
+	observer := new(eventObserver)
+
+	compensations := 0
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(observer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			compensations++
+			if compensations == 1 {
+				return Failure(errors.New("some-compensation-error"), sagaCtx)
+			}
+
+			return Success(sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 2})).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		})
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		`saga created {"key": "value"}`,
+		`sub-request started step1 {"key": "value"}`,
+		`sub-request succeeded step1 {"key": "value"}`,
+		`sub-request started step2 {"key": "value"}`,
+		`sub-request failed step2 some-error`,
+		`compensation started step2`,
+		`compensation succeeded step2`,
+		`compensation started step1`,
+		`compensation failed step1 some-compensation-error`,
+		`compensation started step1`,
+		`compensation succeeded step1`,
+		`saga compensated`,
+	}, observer.events)
+}
+
+func Test_SEC_WithObserver_with_a_stuck_saga(t *testing.T) {
+	observer := new(eventObserver)
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(observer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-compensation-error"), sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 1}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		`saga created {"key": "value"}`,
+		`sub-request started step1 {"key": "value"}`,
+		`sub-request failed step1 some-error`,
+		`compensation started step1`,
+		`compensation failed step1 some-compensation-error`,
+		`saga stuck`,
+	}, observer.events)
+}
+
+func Test_SEC_WithObserver_with_several_observers(t *testing.T) {
+	first := new(eventObserver)
+	second := new(eventObserver)
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(first).
+		WithObserver(NopObserver{}).
+		WithObserver(second).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	assert.Len(t, first.events, 4)
+	assert.Equal(t, first.events, second.events)
+}
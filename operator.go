@@ -0,0 +1,182 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrSagaNotStuck is wrapped by the errors of the manual operations on a saga
+// not stuck, or not loaded into the SEC because Recover have not been called
+// after a restart. A concurrent operation on the same saga fails with it too.
+var ErrSagaNotStuck = errors.New("saga not stuck")
+
+// ListStuckSagas return the IDs of the sagas waiting for a manual
+// intervention.
+//
+// A saga is stuck when one of its Compensations have exhausted its
+// RetryPolicy. After a restart, the stuck sagas are listed only once Recover
+// have been called.
+func (t *SEC) ListStuckSagas() []string {
+	return t.journal.GetSagasByStatus("stuck")
+}
+
+// ResumeSaga retry the failing Compensation of a stuck saga with a fresh
+// RetryPolicy and run the saga until its end.
+//
+// A *SagaAbortedError is returned if the saga is stuck again.
+func (t *SEC) ResumeSaga(ctx context.Context, sagaID string) error {
+	err := t.resumeSaga(ctx, sagaID)
+	if err != nil {
+		return err
+	}
+
+	return t.runStuckSaga(ctx, sagaID)
+}
+
+// SubmitResume retry the failing Compensation of a stuck saga as ResumeSaga
+// but schedule the saga into the worker pool.
+//
+// It returns as soon as the saga is marked as resumed into the journal. Use
+// Wait in order to retrieve the saga Outcome.
+func (t *SEC) SubmitResume(ctx context.Context, sagaID string) error {
+	err := t.resumeSaga(ctx, sagaID)
+	if err != nil {
+		return err
+	}
+
+	t.scheduleSaga(ctx, sagaID, t.continueSaga)
+
+	return nil
+}
+
+func (t *SEC) resumeSaga(ctx context.Context, sagaID string) error {
+	step, arg, err := t.getStuckSubRequest(sagaID)
+	if err != nil {
+		return err
+	}
+
+	err = t.journal.MarkSubRequestAsResumed(ctx, sagaID, step, arg)
+	if err != nil {
+		return t.operationError(sagaID, fmt.Errorf("failed to mark the subrequest %q for saga %q as resumed: %s", step, sagaID, err))
+	}
+
+	return nil
+}
+
+// SkipCompensation consider the failing Compensation of a stuck saga as done
+// and continue the saga compensation with the previous Sub-Request.
+//
+// It should be used only once the Compensation have been applied manually.
+func (t *SEC) SkipCompensation(ctx context.Context, sagaID string) error {
+	err := t.skipCompensation(ctx, sagaID)
+	if err != nil {
+		return err
+	}
+
+	return t.runStuckSaga(ctx, sagaID)
+}
+
+// SubmitSkipCompensation skip the failing Compensation of a stuck saga as
+// SkipCompensation but schedule the saga into the worker pool.
+//
+// It returns as soon as the Compensation is marked as skipped into the
+// journal. Use Wait in order to retrieve the saga Outcome.
+func (t *SEC) SubmitSkipCompensation(ctx context.Context, sagaID string) error {
+	err := t.skipCompensation(ctx, sagaID)
+	if err != nil {
+		return err
+	}
+
+	t.scheduleSaga(ctx, sagaID, t.continueSaga)
+
+	return nil
+}
+
+func (t *SEC) skipCompensation(ctx context.Context, sagaID string) error {
+	step, arg, err := t.getStuckSubRequest(sagaID)
+	if err != nil {
+		return err
+	}
+
+	err = t.journal.MarkSubRequestAsSkipped(ctx, sagaID, step, arg)
+	if err != nil {
+		return t.operationError(sagaID, fmt.Errorf("failed to mark the subrequest %q for saga %q as skipped: %s", step, sagaID, err))
+	}
+
+	return nil
+}
+
+// ForceCompleteSaga mark a stuck saga as done without running any of its
+// remaining Compensations.
+//
+// The saga end is notified as for a compensated saga: it is logged, the
+// observers SagaCompensated is called and the run is traced by the Tracer.
+func (t *SEC) ForceCompleteSaga(ctx context.Context, sagaID string) error {
+	_, _, err := t.getStuckSubRequest(sagaID)
+	if err != nil {
+		return err
+	}
+
+	ctx, end := t.startSaga(ctx, sagaID, t.journal.GetSagaMetadata(sagaID))
+
+	err = t.journal.MarkSagaAsForcedDone(ctx, sagaID)
+	if err != nil {
+		err = t.operationError(sagaID, fmt.Errorf("failed to force the saga %q as done: %s", sagaID, err))
+		end(nil, err)
+		return err
+	}
+
+	outcome := &Outcome{SagaID: sagaID, Status: "compensated", Err: t.abortedError(sagaID, false)}
+	_, _, outcome.Context = t.journal.GetSagaLastEventLog(sagaID)
+
+	t.logSaga(ctx, slog.LevelInfo, "saga finished", sagaID, "forced", nil)
+	t.observers.SagaCompensated(ctx, sagaID, outcome.Context, outcome.Err)
+	end(outcome, nil)
+
+	t.journal.DeleteSaga(ctx, sagaID)
+	t.forgetFailure(sagaID)
+
+	return nil
+}
+
+// runStuckSaga run a saga previously stuck. An error is returned if the saga
+// is stuck again.
+func (t *SEC) runStuckSaga(ctx context.Context, sagaID string) error {
+	outcome, err := t.continueSaga(ctx, sagaID)
+	if err != nil {
+		return err
+	}
+
+	if outcome.Status == "stuck" {
+		return outcome.Err
+	}
+
+	return nil
+}
+
+// operationError return the error of a manual operation refused by the
+// journal. It wraps ErrSagaNotStuck if a concurrent operation have changed the
+// saga.
+func (t *SEC) operationError(sagaID string, err error) error {
+	if t.journal.GetSagaStatus(sagaID) != "stuck" {
+		return fmt.Errorf("%w: %s", ErrSagaNotStuck, err)
+	}
+
+	return err
+}
+
+// getStuckSubRequest return the Sub-Request blocking the given stuck saga
+// with its arguments.
+func (t *SEC) getStuckSubRequest(sagaID string) (string, json.RawMessage, error) {
+	status := t.journal.GetSagaStatus(sagaID)
+	if status != "stuck" {
+		return "", nil, fmt.Errorf("%w: expected saga %q to be \"stuck\", have %q", ErrSagaNotStuck, sagaID, status)
+	}
+
+	step, _, arg := t.journal.GetSagaLastEventLog(sagaID)
+
+	return step, arg, nil
+}
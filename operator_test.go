@@ -0,0 +1,288 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stuckSaga start a saga stuck on the "step2" Compensation. The "step2"
+// Compensation succeed once fixed is true.
+func stuckSaga(t *testing.T, memory *storage.Memory, fixed *bool, compensations *[]string) (*SEC, string) {
+	compensation := func(name string) Action {
+		return func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			if name == "step2" && !*fixed {
+				return Failure(errors.New("some-error"), sagaCtx)
+			}
+
+			*compensations = append(*compensations, name)
+			return Success(sagaCtx)
+		}
+	}
+
+	success := func(ctx context.Context, sagaCtx json.RawMessage) Result { return Success(sagaCtx) }
+	failure := func(ctx context.Context, sagaCtx json.RawMessage) Result {
+		return Failure(errors.New("some-error"), sagaCtx)
+	}
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", success, compensation("step1")).
+		AppendNewSubRequest("step2", success, compensation("step2"), WithCompensationRetry(RetryPolicy{MaxAttempts: 2})).
+		AppendNewSubRequest("step3", failure, compensation("step3"))
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.Error(t, err)
+
+	stuck := scheduler.ListStuckSagas()
+	require.Len(t, stuck, 1)
+
+	return scheduler, stuck[0]
+}
+
+func Test_SEC_ResumeSaga_success(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	fixed = true
+	err := scheduler.ResumeSaga(context.Background(), sagaID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"step3", "step2", "step1"}, compensations)
+	assert.Empty(t, scheduler.ListStuckSagas())
+
+	unfinished, err := memory.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, unfinished)
+}
+
+func Test_SEC_ResumeSaga_with_concurrent_calls(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	fixed = true
+	errs := make(chan error, 5)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			errs <- scheduler.ResumeSaga(context.Background(), sagaID)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	// The compensations are run only once.
+	assert.Equal(t, []string{"step3", "step2", "step1"}, compensations)
+}
+
+func Test_SEC_ResumeSaga_with_a_concurrent_ForceCompleteSaga(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		memory := storage.NewMemory()
+		fixed := false
+		compensations := []string{}
+		scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+		fixed = true
+		errs := make(chan error, 2)
+		var wg sync.WaitGroup
+		for _, operation := range []func(context.Context, string) error{scheduler.ResumeSaga, scheduler.ForceCompleteSaga} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				errs <- operation(context.Background(), sagaID)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			} else {
+				assert.ErrorIs(t, err, ErrSagaNotStuck)
+			}
+		}
+		assert.Equal(t, 1, succeeded)
+
+		// Nothing is saved after the end of the saga.
+		events, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+		require.NoError(t, err)
+		assert.Equal(t, "_finish", events[len(events)-1].Step)
+	}
+}
+
+func Test_SEC_ResumeSaga_with_a_compensation_still_failing(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	err := scheduler.ResumeSaga(context.Background(), sagaID)
+
+	var abortErr *SagaAbortedError
+	require.True(t, errors.As(err, &abortErr))
+	assert.True(t, abortErr.Stuck)
+	assert.Equal(t, []string{sagaID}, scheduler.ListStuckSagas())
+}
+
+func Test_SEC_SkipCompensation_success(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	err := scheduler.SkipCompensation(context.Background(), sagaID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"step3", "step1"}, compensations)
+	assert.Empty(t, scheduler.ListStuckSagas())
+
+	assert.Empty(t, scheduler.failures)
+}
+
+func Test_SEC_SubmitResume_success(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	fixed = true
+	err := scheduler.SubmitResume(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Error(t, err)
+	assert.Equal(t, "compensated", outcome.Status)
+
+	assert.Equal(t, []string{"step3", "step2", "step1"}, compensations)
+	assert.Empty(t, scheduler.ListStuckSagas())
+}
+
+func Test_SEC_SubmitResume_with_a_saga_not_stuck(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	err := scheduler.SubmitResume(context.Background(), "some-unknown-id")
+	assert.EqualError(t, err, `saga not stuck: expected saga "some-unknown-id" to be "stuck", have ""`)
+
+	_, err = scheduler.Wait(context.Background(), "some-unknown-id")
+	assert.EqualError(t, err, `saga "some-unknown-id" not submitted or already waited`)
+}
+
+func Test_SEC_SubmitSkipCompensation_success(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	err := scheduler.SubmitSkipCompensation(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Error(t, err)
+	assert.Equal(t, "compensated", outcome.Status)
+
+	assert.Equal(t, []string{"step3", "step1"}, compensations)
+	assert.Empty(t, scheduler.ListStuckSagas())
+}
+
+func Test_SEC_ForceCompleteSaga_success(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	err := scheduler.ForceCompleteSaga(context.Background(), sagaID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"step3"}, compensations)
+	assert.Empty(t, scheduler.ListStuckSagas())
+
+	unfinished, err := memory.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, unfinished)
+
+	// The recorded failure is forgotten.
+	assert.Empty(t, scheduler.failures)
+}
+
+func Test_SEC_ForceCompleteSaga_should_notify_the_saga_end(t *testing.T) {
+	observer := new(eventObserver)
+	tracer := new(fakeTracer)
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithObserver(observer).
+		WithTracer(tracer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-compensation-error"), sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 1}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.Error(t, err)
+
+	err = scheduler.ForceCompleteSaga(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, `saga compensated`, observer.events[len(observer.events)-1])
+	assert.Equal(t, []string{
+		"start saga map[trace:some-trace-id]",
+		"end saga compensated <nil>",
+	}, tracer.calls[len(tracer.calls)-2:])
+}
+
+func Test_SEC_stuck_saga_should_be_listed_after_a_Recover(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	_, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	// Simulate a restart.
+	scheduler := NewSagaExecutionCoordinator(memory)
+
+	err := scheduler.Recover(context.Background())
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{sagaID}, scheduler.ListStuckSagas())
+}
+
+func Test_SEC_operator_actions_with_a_saga_not_stuck(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	err := scheduler.ResumeSaga(context.Background(), "some-saga-id")
+	assert.ErrorIs(t, err, ErrSagaNotStuck)
+	assert.EqualError(t, err, `saga not stuck: expected saga "some-saga-id" to be "stuck", have ""`)
+
+	err = scheduler.SkipCompensation(context.Background(), "some-saga-id")
+	assert.ErrorIs(t, err, ErrSagaNotStuck)
+	assert.EqualError(t, err, `saga not stuck: expected saga "some-saga-id" to be "stuck", have ""`)
+
+	err = scheduler.ForceCompleteSaga(context.Background(), "some-saga-id")
+	assert.ErrorIs(t, err, ErrSagaNotStuck)
+	assert.EqualError(t, err, `saga not stuck: expected saga "some-saga-id" to be "stuck", have ""`)
+}
@@ -0,0 +1,50 @@
+package gosaga
+
+// AsPivot mark the Sub-Request as the pivot of the saga, its go/no-go point.
+//
+// Until the pivot succeeds, a failure compensates the saga as usual. Once it
+// succeeds, the saga is never compensated: the next Sub-Requests are
+// "retriable", they can't have a Compensation and their Action is retried
+// until its success (forward recovery). Their RetryPolicy is used only for
+// the delay between the attempts, DefaultCompensationRetry one if it has no
+// InitialInterval.
+func AsPivot() SubRequestOption {
+	return func(def *subRequestDef) {
+		def.Pivot = true
+	}
+}
+
+// isForward return true if the pivot of the given saga is done, its failures
+// being then retried instead of compensated.
+func (t *SEC) isForward(sagaID string, defs subRequestDefs) bool {
+	// Avoid to read the journal for the sagas without pivot.
+	if defs.GetPivot() == nil {
+		return false
+	}
+
+	return t.journal.GetSagaRecovery(sagaID) == "forward"
+}
+
+// actionRetry return the policy applied to the failed Action of the given
+// Sub-Request.
+func actionRetry(subReq *subRequestDef, forward bool) RetryPolicy {
+	if forward {
+		return forwardPolicy(subReq.ActionRetry)
+	}
+
+	return subReq.ActionRetry
+}
+
+// forwardPolicy return the policy of an Action following the pivot. It is
+// retried until its success, with its own backoff or the
+// DefaultCompensationRetry one.
+func forwardPolicy(policy RetryPolicy) RetryPolicy {
+	if policy.InitialInterval == 0 {
+		policy = DefaultCompensationRetry
+	}
+
+	policy.MaxAttempts = 0
+	policy.MaxElapsedTime = 0
+
+	return policy
+}
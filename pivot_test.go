@@ -0,0 +1,159 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SEC_AsPivot_with_a_pivot_failure_should_compensate(t *testing.T) {
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{}`))), calls.record("undo-step1", Success(nil))).
+		AppendNewSubRequest("pivot", calls.record("pivot", Failure(errors.New("some-error"), nil)), nil, AsPivot()).
+		AppendNewSubRequest("step3", calls.record("step3", Success(nil)), nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "pivot", abortedErr.SubRequestID)
+	assert.Equal(t, []string{"pivot", "step1", "undo-step1"}, calls.sorted())
+}
+
+func Test_SEC_AsPivot_should_retry_the_next_subrequests_until_their_success(t *testing.T) {
+	calls := newCalls()
+
+	failures := 0
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{}`))), calls.record("undo-step1", Success(nil))).
+		AppendNewSubRequest("pivot", calls.record("pivot", Success(json.RawMessage(`{}`))), calls.record("undo-pivot", Success(nil)), AsPivot()).
+		AppendNewSubRequest("step3", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			if failures < 3 {
+				failures++
+				return Failure(errors.New("some-error"), sagaCtx)
+			}
+
+			return Success(json.RawMessage(`{"key": "value"}`))
+		}, nil, WithActionRetry(RetryPolicy{MaxAttempts: 1, InitialInterval: time.Millisecond}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.JSONEq(t, `{"key": "value"}`, string(outcome.Context))
+
+	// The attempts limit is ignored after the pivot.
+	assert.Equal(t, 3, failures)
+	assert.Equal(t, []string{"pivot", "step1"}, calls.sorted())
+}
+
+func Test_SEC_AsPivot_should_retry_only_the_failed_branches_of_a_stage(t *testing.T) {
+	calls := newCalls()
+
+	failures := 0
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("pivot", calls.record("pivot", Success(json.RawMessage(`{}`))), nil, AsPivot()).
+		AppendNewStage("stage", []Branch{
+			NewBranch("a", calls.record("a", Success(json.RawMessage(`{"a": 1}`))), nil),
+			NewBranch("b", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+				if failures < 1 {
+					failures++
+					return Failure(errors.New("some-error"), sagaCtx)
+				}
+
+				return Success(json.RawMessage(`{"b": 2}`))
+			}, nil),
+		})
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.JSONEq(t, `{"a": 1, "b": 2}`, string(outcome.Context))
+
+	// The done branch is not run again by the stage retry.
+	assert.Equal(t, 1, failures)
+	assert.Equal(t, []string{"a", "pivot"}, calls.sorted())
+}
+
+func Test_SEC_AsPivot_should_ignore_the_saga_timeout_after_the_pivot(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithSagaTimeout(20*time.Millisecond).
+		AppendNewSubRequest("pivot", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, nil, AsPivot()).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			select {
+			case <-time.After(50 * time.Millisecond):
+				return Success(sagaCtx)
+			case <-ctx.Done():
+				return Failure(ctx.Err(), sagaCtx)
+			}
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+	assert.NoError(t, err)
+}
+
+func Test_SEC_Recover_with_an_interrupted_action_after_the_pivot(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	events := []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "pivot", State: "running", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "pivot", State: "pivoted", Context: json.RawMessage(`{"id": 1}`)},
+		{SagaID: "some-saga-id", Step: "step2", State: "running", Context: json.RawMessage(`{"id": 1}`)},
+	}
+	for i := range events {
+		require.NoError(t, memory.SaveEventLog(ctx, &events[i]))
+	}
+
+	var received json.RawMessage
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("pivot", nil, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			t.Error("the pivot must not be compensated")
+			return Success(sagaCtx)
+		}, AsPivot()).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			received = sagaCtx
+			return Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.Recover(ctx)
+	require.NoError(t, err)
+
+	// The interrupted action is retried with the same arguments.
+	assert.JSONEq(t, `{"id": 1}`, string(received))
+
+	unfinished, err := memory.GetUnfinishedSagaIDs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, unfinished)
+}
+
+func Test_forwardPolicy(t *testing.T) {
+	assert.Equal(t, RetryPolicy{
+		InitialInterval: DefaultCompensationRetry.InitialInterval,
+		MaxInterval:     DefaultCompensationRetry.MaxInterval,
+		Multiplier:      DefaultCompensationRetry.Multiplier,
+		Jitter:          DefaultCompensationRetry.Jitter,
+	}, forwardPolicy(NoRetry))
+
+	assert.Equal(t, RetryPolicy{InitialInterval: time.Second}, forwardPolicy(RetryPolicy{
+		MaxAttempts:     3,
+		InitialInterval: time.Second,
+		MaxElapsedTime:  time.Minute,
+	}))
+}
@@ -0,0 +1,163 @@
+package gosaga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+)
+
+// ErrSagaNotFound is returned when the given saga doesn't exist into the
+// storage.
+var ErrSagaNotFound = errors.New("saga not found")
+
+// SagaSummary is a view of a saga computed from its eventlogs.
+type SagaSummary struct {
+	ID string
+
+	// Status is "running", "aborted" while compensating, "stuck" or "done".
+	Status string
+
+	// Outcome is set once the saga is done: "committed" if it have been
+	// applied, "compensated" if it have been rolled back and "forced" if it
+	// have been forced as done by ForceCompleteSaga.
+	Outcome string
+
+	// SagaType and SagaVersion are the definition used by the saga, if any.
+	SagaType    string
+	SagaVersion int
+
+	// CurrentStep and CurrentState are the last Sub-Request changed and its
+	// state.
+	CurrentStep  string
+	CurrentState string
+
+	// Attempts is the number of attempts of the current Sub-Request
+	// action/compensation.
+	Attempts int
+
+	// Reason is the error which have aborted the saga, if any.
+	Reason string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// GetSagaHistory return all the eventlogs of the given saga, finished or not,
+// in their saving order.
+//
+// The finished sagas are available until they are compacted from the storage.
+func (t *SEC) GetSagaHistory(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	eventLogs, err := t.journal.GetSagaHistory(ctx, sagaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(eventLogs) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrSagaNotFound, sagaID)
+	}
+
+	return eventLogs, nil
+}
+
+// GetSagaSummary return the summary of the given saga.
+func (t *SEC) GetSagaSummary(ctx context.Context, sagaID string) (*SagaSummary, error) {
+	eventLogs, err := t.GetSagaHistory(ctx, sagaID)
+	if err != nil {
+		return nil, err
+	}
+
+	return summarize(model.Saga{ID: sagaID, Status: model.ComputeStatus(eventLogs), EventLogs: eventLogs}), nil
+}
+
+// ListSagas return the summary of the sagas selected by the query, ordered by
+// creation date, and the cursor of the next page. The cursor is empty for the
+// last page.
+func (t *SEC) ListSagas(ctx context.Context, query model.SagaQuery) ([]SagaSummary, string, error) {
+	sagas, cursor, err := t.journal.ListSagas(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res := make([]SagaSummary, 0, len(sagas))
+	for _, saga := range sagas {
+		res = append(res, *summarize(saga))
+	}
+
+	return res, cursor, nil
+}
+
+func summarize(saga model.Saga) *SagaSummary {
+	res := &SagaSummary{ID: saga.ID, Status: saga.Status}
+	if len(saga.EventLogs) == 0 {
+		return res
+	}
+
+	init := saga.EventLogs[0]
+	res.SagaType = init.SagaType
+	res.SagaVersion = init.SagaVersion
+	res.CreatedAt = init.CreatedAt
+	res.UpdatedAt = saga.EventLogs[len(saga.EventLogs)-1].CreatedAt
+
+	var (
+		aborted bool
+		current = -1
+	)
+	for i, eventLog := range saga.EventLogs {
+		if eventLog.Reason != "" {
+			res.Reason = eventLog.Reason
+		}
+
+		// The cancellation requests are not Sub-Request states.
+		if eventLog.Branch != "" || eventLog.Step == "_cancel" {
+			continue
+		}
+
+		if eventLog.Step == "_finish" {
+			res.Outcome = "committed"
+			if eventLog.State == "forced" {
+				res.Outcome = "forced"
+			}
+
+			continue
+		}
+
+		if eventLog.State == "aborted" {
+			aborted = true
+		}
+
+		current = i
+	}
+
+	if res.Outcome == "committed" && aborted {
+		res.Outcome = "compensated"
+	}
+
+	if current < 0 {
+		return res
+	}
+
+	res.CurrentStep = saga.EventLogs[current].Step
+	res.CurrentState = saga.EventLogs[current].State
+
+	// Count the attempts since the Sub-Request have been started or aborted,
+	// whatever its last state.
+	for i := current; i >= 0; i-- {
+		eventLog := saga.EventLogs[i]
+		if eventLog.Branch != "" || eventLog.Step == "_cancel" {
+			continue
+		}
+
+		if eventLog.Step != res.CurrentStep || (i != current && eventLog.State != "running" && eventLog.State != "failed") {
+			break
+		}
+
+		if eventLog.State == "running" {
+			res.Attempts++
+		}
+	}
+
+	return res
+}
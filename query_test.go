@@ -0,0 +1,202 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SEC_GetSagaSummary_with_a_committed_saga(t *testing.T) {
+	attempts := 0
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		RegisterSaga(NewSagaDefinition("payment", 2).
+			AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+				return Success(sagaCtx)
+			}, nil).
+			AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+				attempts++
+				if attempts == 1 {
+					return Failure(errors.New("some-error"), sagaCtx)
+				}
+
+				return Success(sagaCtx)
+			}, nil, WithActionRetry(RetryPolicy{MaxAttempts: 2})))
+
+	sagaID, err := scheduler.SubmitNamed(context.Background(), "payment", json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	summary, err := scheduler.GetSagaSummary(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, sagaID, summary.ID)
+	assert.Equal(t, "done", summary.Status)
+	assert.Equal(t, "committed", summary.Outcome)
+	assert.Equal(t, "payment", summary.SagaType)
+	assert.Equal(t, 2, summary.SagaVersion)
+	assert.Equal(t, "step2", summary.CurrentStep)
+	assert.Equal(t, "done", summary.CurrentState)
+	assert.Equal(t, 2, summary.Attempts)
+	assert.Empty(t, summary.Reason)
+	assert.False(t, summary.CreatedAt.IsZero())
+	assert.False(t, summary.UpdatedAt.Before(summary.CreatedAt))
+}
+
+func Test_SEC_GetSagaSummary_with_a_compensated_saga(t *testing.T) {
+	success := func(ctx context.Context, sagaCtx json.RawMessage) Result { return Success(sagaCtx) }
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", success, success).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.Error(t, err)
+
+	summary, err := scheduler.GetSagaSummary(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "done", summary.Status)
+	assert.Equal(t, "compensated", summary.Outcome)
+	assert.Equal(t, "step1", summary.CurrentStep)
+	assert.Equal(t, "done", summary.CurrentState)
+	assert.Equal(t, 1, summary.Attempts)
+	assert.Equal(t, "some-error", summary.Reason)
+}
+
+func Test_SEC_GetSagaSummary_with_a_stuck_saga(t *testing.T) {
+	memory := storage.NewMemory()
+	fixed := false
+	compensations := []string{}
+	scheduler, sagaID := stuckSaga(t, memory, &fixed, &compensations)
+
+	summary, err := scheduler.GetSagaSummary(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "stuck", summary.Status)
+	assert.Empty(t, summary.Outcome)
+	assert.Equal(t, "step2", summary.CurrentStep)
+	assert.Equal(t, "stuck", summary.CurrentState)
+	assert.Equal(t, 2, summary.Attempts)
+
+	err = scheduler.ForceCompleteSaga(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	summary, err = scheduler.GetSagaSummary(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, "done", summary.Status)
+	assert.Equal(t, "forced", summary.Outcome)
+}
+
+func Test_SEC_GetSagaSummary_with_an_unknown_saga(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	summary, err := scheduler.GetSagaSummary(context.Background(), "some-unknown-id")
+
+	assert.ErrorIs(t, err, ErrSagaNotFound)
+	assert.Nil(t, summary)
+}
+
+func Test_SEC_GetSagaHistory_success(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(json.RawMessage(`{"step1": true}`))
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	history, err := scheduler.GetSagaHistory(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	steps := []string{}
+	for _, eventLog := range history {
+		steps = append(steps, eventLog.Step+":"+eventLog.State)
+	}
+
+	assert.Equal(t, []string{"_init:done", "step1:running", "step1:done", "_finish:done"}, steps)
+	assert.JSONEq(t, `{"step1": true}`, string(history[2].Context))
+}
+
+func Test_SEC_GetSagaHistory_with_a_journal_error(t *testing.T) {
+	journal := new(journal.Mock)
+	scheduler := &SEC{journal: journal}
+
+	journal.On("GetSagaHistory", "some-saga-id").Once().Return(nil, errors.New("some-error"))
+
+	history, err := scheduler.GetSagaHistory(context.Background(), "some-saga-id")
+
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, history)
+
+	journal.AssertExpectations(t)
+}
+
+func Test_SEC_ListSagas_success(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, nil)
+
+	sagaIDs := []string{}
+	for i := 0; i < 3; i++ {
+		sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+		require.NoError(t, err)
+
+		_, err = scheduler.Wait(context.Background(), sagaID)
+		require.NoError(t, err)
+
+		sagaIDs = append(sagaIDs, sagaID)
+	}
+
+	page, cursor, err := scheduler.ListSagas(context.Background(), model.SagaQuery{Status: "done", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	require.NotEmpty(t, cursor)
+
+	last, cursor, err := scheduler.ListSagas(context.Background(), model.SagaQuery{Status: "done", Limit: 2, Cursor: cursor})
+	require.NoError(t, err)
+	require.Len(t, last, 1)
+	assert.Empty(t, cursor)
+
+	listed := []string{}
+	for _, summary := range append(page, last...) {
+		assert.Equal(t, "committed", summary.Outcome)
+		listed = append(listed, summary.ID)
+	}
+
+	assert.ElementsMatch(t, sagaIDs, listed)
+}
+
+func Test_SEC_ListSagas_with_a_journal_error(t *testing.T) {
+	journal := new(journal.Mock)
+	scheduler := &SEC{journal: journal}
+
+	journal.On("ListSagas", model.SagaQuery{}).Once().Return(nil, "", errors.New("some-error"))
+
+	res, cursor, err := scheduler.ListSagas(context.Background(), model.SagaQuery{})
+
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, res)
+	assert.Empty(t, cursor)
+
+	journal.AssertExpectations(t)
+}
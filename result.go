@@ -6,15 +6,15 @@ import (
 
 // SuccessResponse response returned after a successful Action.
 //
-// - When it is used as a result for an Action method, the next SubRequest will
-//	 be called with the given result parameter. If one of the following SubRequest
-//	 fail, the Compensation action will also be called with this result so the
-//	 datas inside the result paramter should allows to execute either the next
-//	 SubRequest and the current SubRequest Compensation method.
+//   - When it is used as a result for an Action method, the next SubRequest will
+//     be called with the given result parameter. If one of the following SubRequest
+//     fail, the Compensation action will also be called with this result so the
+//     datas inside the result paramter should allows to execute either the next
+//     SubRequest and the current SubRequest Compensation method.
 //
-// - When it is used as a result for a Compensation method, the next
-//   Componsensation method will be called but the result parameter will not be
-//	 used.
+//   - When it is used as a result for a Compensation method, the next
+//     Componsensation method will be called but the result parameter will not be
+//     used.
 type SuccessResponse struct {
 	status  string
 	context json.RawMessage
@@ -26,14 +26,15 @@ func (t *SuccessResponse) IsSuccess() bool { return true }
 // Context return
 func (t *SuccessResponse) Context() json.RawMessage { return t.context }
 
-// FailureResponse response returned after a errored Action.
+// FailureResponse response returned after an errored Action.
 //
-// - When it is used as a result for an Action, it change the saga state to
-//	 "aborted". An "aborted" saga will trigger all the required Compensation
-//	 Actions in order to revert all the change previously make by the Saga.
+//   - When it is used as a result for an Action, it change the saga state to
+//     "aborted" once the Action RetryPolicy is exhausted. An "aborted" saga
+//     will trigger all the required Compensation Actions in order to revert
+//     all the change previously make by the Saga.
 //
-// - When it is used as a result for a Compensation, it will be only logged
-//	 and the Compensation method will retry.
+//   - When it is used as a result for a Compensation, it will be only logged
+//     and the Compensation method will retry, according to its RetryPolicy.
 type FailureResponse struct {
 	status  string
 	context json.RawMessage
@@ -46,6 +47,9 @@ func (t *FailureResponse) IsSuccess() bool { return false }
 // Context return
 func (t *FailureResponse) Context() json.RawMessage { return t.context }
 
+// Err return the error given to Failure.
+func (t *FailureResponse) Err() error { return t.err }
+
 // Success generate a Success response.
 func Success(context json.RawMessage) *SuccessResponse {
 	return &SuccessResponse{
@@ -31,3 +31,10 @@ func Test_Failure(t *testing.T) {
 		err:     err,
 	}, res)
 }
+
+func Test_FailureResponse_Err(t *testing.T) {
+	err := errors.New("some-error")
+	res := Failure(err, nil)
+
+	assert.Equal(t, err, res.Err())
+}
@@ -0,0 +1,97 @@
+package gosaga
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy define how a failing Action or Compensation is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, the first one included.
+	// Zero means no limit.
+	MaxAttempts int
+
+	// InitialInterval is the delay before the first retry.
+	InitialInterval time.Duration
+
+	// MaxInterval is the maximum delay between two attempts.
+	MaxInterval time.Duration
+
+	// Multiplier is applied to the delay after each attempt.
+	Multiplier float64
+
+	// Jitter randomize each delay by plus or minus the given ratio (between 0
+	// and 1) in order to avoid retry storms.
+	Jitter float64
+
+	// MaxElapsedTime is the maximum duration since the first attempt after
+	// which no more retry is done. Zero means no limit.
+	MaxElapsedTime time.Duration
+}
+
+// NoRetry never retry a failure. This is the default policy for the Actions.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// DefaultCompensationRetry retry forever with an exponential backoff. This is
+// the default policy for the Compensations as they must eventually succeed.
+var DefaultCompensationRetry = RetryPolicy{
+	InitialInterval: 100 * time.Millisecond,
+	MaxInterval:     time.Minute,
+	Multiplier:      2,
+	Jitter:          0.2,
+}
+
+// canRetry return if a new attempt is allowed after the given attempts.
+func (t RetryPolicy) canRetry(attempts int, firstAttempt time.Time, now time.Time) bool {
+	if t.MaxAttempts > 0 && attempts >= t.MaxAttempts {
+		return false
+	}
+
+	if t.MaxElapsedTime > 0 && now.Sub(firstAttempt) >= t.MaxElapsedTime {
+		return false
+	}
+
+	return true
+}
+
+// delay return the delay to wait after the given number of failed attempts.
+func (t RetryPolicy) delay(failures int) time.Duration {
+	if failures < 1 {
+		return 0
+	}
+
+	multiplier := t.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(t.InitialInterval) * math.Pow(multiplier, float64(failures-1))
+	if t.MaxInterval > 0 && delay > float64(t.MaxInterval) {
+		delay = float64(t.MaxInterval)
+	}
+
+	if t.Jitter > 0 {
+		delay = delay * (1 + t.Jitter*(2*rand.Float64()-1))
+	}
+
+	return time.Duration(delay)
+}
+
+// sleep wait for the given delay or until the context is canceled.
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
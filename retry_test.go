@@ -0,0 +1,156 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RetryPolicy_delay(t *testing.T) {
+	policy := RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+
+	assert.Equal(t, time.Duration(0), policy.delay(0))
+	assert.Equal(t, 100*time.Millisecond, policy.delay(1))
+	assert.Equal(t, 200*time.Millisecond, policy.delay(2))
+	assert.Equal(t, 400*time.Millisecond, policy.delay(3))
+	assert.Equal(t, time.Second, policy.delay(10))
+}
+
+func Test_RetryPolicy_delay_with_jitter(t *testing.T) {
+	policy := RetryPolicy{
+		InitialInterval: 100 * time.Millisecond,
+		Multiplier:      2,
+		Jitter:          0.5,
+	}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.delay(2)
+
+		assert.True(t, delay >= 100*time.Millisecond, "delay %s too short", delay)
+		assert.True(t, delay <= 300*time.Millisecond, "delay %s too long", delay)
+	}
+}
+
+func Test_RetryPolicy_canRetry(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, NoRetry.canRetry(1, now, now))
+	assert.True(t, DefaultCompensationRetry.canRetry(1000, now.Add(-time.Hour), now))
+
+	policy := RetryPolicy{MaxAttempts: 3, MaxElapsedTime: time.Minute}
+	assert.True(t, policy.canRetry(2, now, now))
+	assert.False(t, policy.canRetry(3, now, now))
+	assert.False(t, policy.canRetry(1, now.Add(-time.Minute), now))
+}
+
+func Test_sleep_with_a_canceled_context(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := sleep(ctx, time.Hour)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_SEC_with_an_action_retried_until_success(t *testing.T) {
+	memory := storage.NewMemory()
+
+	attempts := 0
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			attempts++
+			if attempts < 3 {
+				return Failure(errors.New("some-error"), nil)
+			}
+
+			return Success(sagaCtx)
+		}, nil, WithActionRetry(RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.Equal(t, 3, attempts)
+
+	// The failed attempts are saved with the action arguments.
+	eventLogs, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	states := []string{}
+	for _, eventLog := range eventLogs {
+		states = append(states, eventLog.Step+"/"+eventLog.State)
+		if eventLog.State == "failed" {
+			assert.Equal(t, json.RawMessage(`{"key": "value"}`), eventLog.Context)
+		}
+	}
+	assert.Equal(t, []string{
+		"_init/done",
+		"step1/running", "step1/failed",
+		"step1/running", "step1/failed",
+		"step1/running", "step1/done",
+		"_finish/done",
+	}, states)
+}
+
+func Test_SEC_with_an_action_exhausting_its_retries(t *testing.T) {
+	attempts := 0
+	compensations := 0
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			attempts++
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			compensations++
+			return Success(sagaCtx)
+		}, WithActionRetry(RetryPolicy{MaxAttempts: 2}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.EqualError(t, err, fmt.Sprintf(`saga %q have been compensated: sub-request "step1" failed: some-error`, sagaID))
+	assert.Equal(t, "compensated", outcome.Status)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 1, compensations)
+}
+
+func Test_SEC_with_a_compensation_exhausting_its_retries(t *testing.T) {
+	compensations := 0
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			compensations++
+			return Failure(errors.New("some-compensation-error"), sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 3, InitialInterval: time.Millisecond}))
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	assert.Regexp(t, `saga ".*" is stuck and needs a manual intervention`, err.Error())
+	assert.Equal(t, 3, compensations)
+	assert.Len(t, scheduler.ListStuckSagas(), 1)
+}
+
+func Test_SEC_with_a_context_canceled_between_two_retries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			cancel()
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, nil, WithActionRetry(RetryPolicy{InitialInterval: time.Hour}))
+
+	err := scheduler.StartSaga(ctx, json.RawMessage(`{"key": "value"}`))
+	assert.EqualError(t, err, "interrupted while waiting before the next attempt: context canceled")
+}
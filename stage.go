@@ -0,0 +1,402 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// The states of a parallel stage branch.
+const (
+	branchRunning            = "running"
+	branchDone               = "done"
+	branchFailed             = "failed"
+	branchAborted            = "aborted"
+	branchInterrupted        = "interrupted"
+	branchCompensating       = "compensating"
+	branchCompensated        = "compensated"
+	branchCompensationFailed = "compensation_failed"
+)
+
+// JoinFunc merge the contexts returned by the branches of a parallel stage,
+// by branch name, into the context given to the next Sub-Request.
+type JoinFunc func(results map[string]json.RawMessage) (json.RawMessage, error)
+
+// Branch is a Sub-Request run concurrently with the other branches of a
+// parallel stage.
+type Branch struct {
+	def subRequestDef
+}
+
+// NewBranch instantiate a new Branch. The WithActionRetry and
+// WithCompensationRetry options are supported.
+func NewBranch(name string, action Action, compensation Action, opts ...SubRequestOption) Branch {
+	def := subRequestDef{
+		SubRequestID:      name,
+		Action:            action,
+		Compensation:      compensation,
+		ActionRetry:       NoRetry,
+		CompensationRetry: NoRetry,
+	}
+
+	for _, opt := range opts {
+		opt(&def)
+	}
+
+	return Branch{def: def}
+}
+
+// AppendNewStage append a parallel stage to the Saga.
+//
+// All the branches receive the same context and are run concurrently. Their
+// results are merged by the JoinFunc set with WithJoin, by default the JSON
+// objects returned by the branches are merged into a single object. If any
+// branch fails, once all the branches are finished, the stage is aborted and
+// only the branches having run their Action are compensated. A branch stopped
+// by a timeout or a cancellation may have applied its Action so it is
+// compensated too. The state of each branch is saved into the journal so a
+// recovered saga knows which branches must be compensated.
+//
+// A failed branch Compensation is retried with the branch RetryPolicy then
+// with the stage one (WithCompensationRetry), the stage retries skipping the
+// branches already compensated. The stage Action is never retried, only its
+// branches, except after the pivot where it is retried without the branches
+// already done.
+func (t *SEC) AppendNewStage(name string, branches []Branch, opts ...SubRequestOption) *SEC {
+	t.subRequestDefs = t.subRequestDefs.append(newStageDef(name, branches, opts))
+
+	return t
+}
+
+// newStageDef instantiate the definition of a parallel stage appended with
+// AppendNewStage.
+func newStageDef(name string, branches []Branch, opts []SubRequestOption) subRequestDef {
+	def := subRequestDef{
+		SubRequestID:      name,
+		CompensationRetry: DefaultCompensationRetry,
+		Join:              mergeJSONObjects,
+	}
+
+	for _, opt := range opts {
+		opt(&def)
+	}
+
+	def.ActionRetry = NoRetry
+	for _, branch := range branches {
+		def.Branches = append(def.Branches, branch.def)
+	}
+
+	return def
+}
+
+// WithJoin set the JoinFunc of a parallel stage.
+func WithJoin(join JoinFunc) SubRequestOption {
+	return func(def *subRequestDef) {
+		def.Join = join
+	}
+}
+
+// mergeJSONObjects merge the JSON objects returned by the branches. The
+// branches returning a nil context are ignored. The keys present in several
+// objects must have the same value.
+func mergeJSONObjects(results map[string]json.RawMessage) (json.RawMessage, error) {
+	merged := map[string]json.RawMessage{}
+	origins := map[string]string{}
+
+	for branch, result := range results {
+		if result == nil {
+			continue
+		}
+
+		var object map[string]json.RawMessage
+		err := json.Unmarshal(result, &object)
+		if err != nil {
+			return nil, fmt.Errorf("the branch %q didn't return a JSON object: %s", branch, err)
+		}
+
+		for key, value := range object {
+			if previous, ok := merged[key]; ok && !jsonEqual(previous, value) {
+				return nil, fmt.Errorf("the branches %q and %q returned different values for %q", origins[key], branch, key)
+			}
+
+			merged[key] = value
+			origins[key] = branch
+		}
+	}
+
+	return json.Marshal(merged)
+}
+
+func jsonEqual(a json.RawMessage, b json.RawMessage) bool {
+	var va, vb interface{}
+
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+
+	ra, _ := json.Marshal(va)
+	rb, _ := json.Marshal(vb)
+
+	return string(ra) == string(rb)
+}
+
+// execAction run the Action of the given Sub-Request or the branches of a
+// parallel stage. An error is returned only in case of journal failure.
+func (t *SEC) execAction(ctx context.Context, sagaID string, subReq *subRequestDef, arg json.RawMessage) (Result, error) {
+	ctx, cancel := withTimeout(ctx, subReq.ActionTimeout, "action")
+	defer cancel()
+
+	if len(subReq.Branches) == 0 {
+		return t.runAction(ctx, sagaID, subReq.SubRequestID, PhaseAction, subReq.Action, arg), nil
+	}
+
+	states := t.journal.GetBranchStates(sagaID, subReq.SubRequestID)
+
+	results := make([]Result, len(subReq.Branches))
+	errs := make([]error, len(subReq.Branches))
+
+	var wg sync.WaitGroup
+	for i := range subReq.Branches {
+		// The branches done by a previous run of the stage, retried after the
+		// pivot or recovered, are not run again.
+		state, ok := states[subReq.Branches[i].SubRequestID]
+		if ok && state.State == branchDone {
+			results[i] = Success(state.Context)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			results[i], errs[i] = t.execBranchAction(ctx, sagaID, subReq.SubRequestID, &subReq.Branches[i], arg)
+		}(i)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	contexts := map[string]json.RawMessage{}
+	failures := []error{}
+	for i, result := range results {
+		branch := subReq.Branches[i].SubRequestID
+		if !result.IsSuccess() {
+			failures = append(failures, fmt.Errorf("branch %q failed: %w", branch, resultErr(result)))
+			continue
+		}
+
+		contexts[branch] = result.Context()
+	}
+
+	if len(failures) > 0 {
+		return Failure(errors.Join(failures...), arg), nil
+	}
+
+	joined, err := subReq.Join(contexts)
+	if err != nil {
+		return Failure(fmt.Errorf("failed to join the branch results: %w", err), arg), nil
+	}
+
+	return Success(joined), nil
+}
+
+// execCompensation run the Compensation of the given Sub-Request or of the
+// branches of a parallel stage. An error is returned only in case of journal
+// failure.
+func (t *SEC) execCompensation(ctx context.Context, sagaID string, subReq *subRequestDef, arg json.RawMessage) (Result, error) {
+	ctx, cancel := withTimeout(ctx, subReq.CompensationTimeout, "compensation")
+	defer cancel()
+
+	if len(subReq.Branches) == 0 {
+		if subReq.Compensation == nil {
+			return Success(arg), nil
+		}
+
+		return t.runAction(ctx, sagaID, subReq.SubRequestID, PhaseCompensation, subReq.Compensation, arg), nil
+	}
+
+	states := t.journal.GetBranchStates(sagaID, subReq.SubRequestID)
+
+	results := make([]Result, len(subReq.Branches))
+	errs := make([]error, len(subReq.Branches))
+
+	var wg sync.WaitGroup
+	for i := range subReq.Branches {
+		state, ok := states[subReq.Branches[i].SubRequestID]
+
+		// Only the branches which may have applied their Action are
+		// compensated. An Action interrupted by a crash, a timeout or a
+		// cancellation is considered as applied.
+		switch {
+		case !ok, state.State == branchFailed, state.State == branchAborted, state.State == branchCompensated:
+			results[i] = Success(nil)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			results[i], errs[i] = t.execBranchCompensation(ctx, sagaID, subReq.SubRequestID, &subReq.Branches[i], state.Context)
+		}(i)
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	failures := []error{}
+	for i, result := range results {
+		if !result.IsSuccess() {
+			failures = append(failures, fmt.Errorf("branch %q failed: %w", subReq.Branches[i].SubRequestID, resultErr(result)))
+		}
+	}
+
+	if len(failures) > 0 {
+		return Failure(errors.Join(failures...), arg), nil
+	}
+
+	return Success(arg), nil
+}
+
+// execBranchAction run the Action of a branch until its success or the end of
+// its RetryPolicy.
+func (t *SEC) execBranchAction(ctx context.Context, sagaID string, stageID string, branch *subRequestDef, arg json.RawMessage) (Result, error) {
+	subRequestID := stageID + "/" + branch.SubRequestID
+
+	var firstAttempt time.Time
+	for attempts := 1; ; attempts++ {
+		err := t.journal.MarkBranchState(ctx, sagaID, stageID, branch.SubRequestID, branchRunning, arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark the branch %q for saga %q as running: %s", subRequestID, sagaID, err)
+		}
+
+		if attempts == 1 {
+			firstAttempt = time.Now()
+		}
+
+		t.logSubRequest(ctx, slog.LevelDebug, "run sub-request", sagaID, subRequestID, PhaseAction, "", nil)
+		t.observers.SubRequestStarted(ctx, sagaID, subRequestID, arg)
+
+		// An attempt made once the stage is stopped is not run.
+		started := !isStopped(ctx)
+
+		branchCtx, end := t.startStep(ctx, sagaID, subRequestID, PhaseAction)
+		result, _ := t.execAction(branchCtx, sagaID, branch, arg)
+		end(result)
+
+		if result.IsSuccess() {
+			t.logSubRequest(ctx, slog.LevelInfo, "sub-request succeeded", sagaID, subRequestID, PhaseAction, "success", nil)
+			t.observers.SubRequestSucceeded(ctx, sagaID, subRequestID, result.Context())
+
+			err = t.journal.MarkBranchState(ctx, sagaID, stageID, branch.SubRequestID, branchDone, result.Context())
+			if err != nil {
+				return nil, fmt.Errorf("failed to mark the branch %q for saga %q as done: %s", subRequestID, sagaID, err)
+			}
+
+			return result, nil
+		}
+
+		t.observers.SubRequestFailed(ctx, sagaID, subRequestID, result.Context(), resultErr(result))
+
+		if isStopError(resultErr(result)) || !branch.ActionRetry.canRetry(attempts, firstAttempt, time.Now()) {
+			t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, subRequestID, PhaseAction, "aborted", resultErr(result))
+
+			state := branchAborted
+			if started && isStopError(resultErr(result)) {
+				state = branchInterrupted
+			}
+
+			err = t.journal.MarkBranchState(ctx, sagaID, stageID, branch.SubRequestID, state, result.Context())
+			if err != nil {
+				return nil, fmt.Errorf("failed to mark the branch %q for saga %q as aborted: %s", subRequestID, sagaID, err)
+			}
+
+			return result, nil
+		}
+
+		t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, subRequestID, PhaseAction, "retry", resultErr(result))
+
+		// The next attempt is made with the same arguments.
+		err = t.journal.MarkBranchState(ctx, sagaID, stageID, branch.SubRequestID, branchFailed, arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark the branch %q for saga %q as failed: %s", subRequestID, sagaID, err)
+		}
+
+		// Once the stage timeout is reached or the saga is cancelled the
+		// attempt fails immediately.
+		err = sleep(ctx, branch.ActionRetry.delay(attempts))
+		if err != nil && !isStopped(ctx) {
+			return nil, fmt.Errorf("interrupted while waiting before the next attempt: %s", err)
+		}
+	}
+}
+
+// execBranchCompensation run the Compensation of a branch until its success or
+// the end of its RetryPolicy.
+func (t *SEC) execBranchCompensation(ctx context.Context, sagaID string, stageID string, branch *subRequestDef, arg json.RawMessage) (Result, error) {
+	subRequestID := stageID + "/" + branch.SubRequestID
+
+	var firstAttempt time.Time
+	for attempts := 1; ; attempts++ {
+		err := t.journal.MarkBranchState(ctx, sagaID, stageID, branch.SubRequestID, branchCompensating, arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark the branch %q for saga %q as compensating: %s", subRequestID, sagaID, err)
+		}
+
+		if attempts == 1 {
+			firstAttempt = time.Now()
+		}
+
+		t.logSubRequest(ctx, slog.LevelDebug, "run sub-request", sagaID, subRequestID, PhaseCompensation, "", nil)
+		t.observers.CompensationStarted(ctx, sagaID, subRequestID, arg)
+
+		branchCtx, end := t.startStep(ctx, sagaID, subRequestID, PhaseCompensation)
+		result, _ := t.execCompensation(branchCtx, sagaID, branch, arg)
+		end(result)
+
+		if result.IsSuccess() {
+			t.logSubRequest(ctx, slog.LevelInfo, "sub-request succeeded", sagaID, subRequestID, PhaseCompensation, "success", nil)
+			t.observers.CompensationSucceeded(ctx, sagaID, subRequestID, result.Context())
+
+			err = t.journal.MarkBranchState(ctx, sagaID, stageID, branch.SubRequestID, branchCompensated, result.Context())
+			if err != nil {
+				return nil, fmt.Errorf("failed to mark the branch %q for saga %q as compensated: %s", subRequestID, sagaID, err)
+			}
+
+			return result, nil
+		}
+
+		t.observers.CompensationFailed(ctx, sagaID, subRequestID, result.Context(), resultErr(result))
+		t.logSubRequest(ctx, slog.LevelWarn, "sub-request failed", sagaID, subRequestID, PhaseCompensation, "retry", resultErr(result))
+
+		// The next attempt is made with the same arguments.
+		err = t.journal.MarkBranchState(ctx, sagaID, stageID, branch.SubRequestID, branchCompensationFailed, arg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark the branch %q for saga %q as failed: %s", subRequestID, sagaID, err)
+		}
+
+		if !branch.CompensationRetry.canRetry(attempts, firstAttempt, time.Now()) {
+			return result, nil
+		}
+
+		err = sleep(ctx, branch.CompensationRetry.delay(attempts))
+		if isTimeout(ctx) {
+			// The stage timeout is reached, the stage is retried with its own
+			// policy.
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("interrupted while waiting before the next attempt: %s", err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// calls record concurrently the Actions and Compensations run.
+type calls struct {
+	mutex *sync.Mutex
+	names []string
+}
+
+func newCalls() *calls {
+	return &calls{mutex: new(sync.Mutex)}
+}
+
+func (t *calls) record(name string, result Result) Action {
+	return func(ctx context.Context, sagaCtx json.RawMessage) Result {
+		t.mutex.Lock()
+		t.names = append(t.names, name)
+		t.mutex.Unlock()
+
+		return result
+	}
+}
+
+func (t *calls) sorted() []string {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	res := append([]string{}, t.names...)
+	sort.Strings(res)
+
+	return res
+}
+
+func Test_SEC_AppendNewStage_run_the_branches_concurrently(t *testing.T) {
+	barrier := new(sync.WaitGroup)
+	barrier.Add(2)
+
+	// Each branch wait for the other one to be started.
+	branch := func(key string) Action {
+		return func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			barrier.Done()
+
+			started := make(chan struct{})
+			go func() {
+				barrier.Wait()
+				close(started)
+			}()
+
+			select {
+			case <-started:
+			case <-time.After(time.Second):
+				return Failure(errors.New("the branches are not concurrent"), nil)
+			}
+
+			return Success(json.RawMessage(`{"` + key + `": true}`))
+		}
+	}
+
+	var received json.RawMessage
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", branch("key1"), nil),
+			NewBranch("branch2", branch("key2"), nil),
+		}).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			received = sagaCtx
+			return Success(sagaCtx)
+		}, nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"key1": true, "key2": true}`, string(received))
+}
+
+func Test_SEC_AppendNewStage_with_a_custom_join(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+				return Success(json.RawMessage(`1`))
+			}, nil),
+			NewBranch("branch2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+				return Success(json.RawMessage(`2`))
+			}, nil),
+		}, WithJoin(func(results map[string]json.RawMessage) (json.RawMessage, error) {
+			return json.Marshal(results)
+		}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+	assert.JSONEq(t, `{"branch1": 1, "branch2": 2}`, string(outcome.Context))
+}
+
+func Test_SEC_AppendNewStage_with_a_join_error_should_abort(t *testing.T) {
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", calls.record("branch1", Success(json.RawMessage(`{"key": 1}`))), calls.record("undo-branch1", Success(nil))),
+			NewBranch("branch2", calls.record("branch2", Success(json.RawMessage(`{"key": 2}`))), calls.record("undo-branch2", Success(nil))),
+		})
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "stage", abortedErr.SubRequestID)
+	assert.Contains(t, abortedErr.Err.Error(), `returned different values for "key"`)
+	assert.Equal(t, []string{"branch1", "branch2", "undo-branch1", "undo-branch2"}, calls.sorted())
+}
+
+func Test_SEC_AppendNewStage_with_a_failed_branch_should_compensate_the_completed_branches(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+	failure := Failure(errors.New("some-error"), nil)
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{}`))), calls.record("undo-step1", Success(nil))).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", calls.record("branch1", Success(json.RawMessage(`{}`))), calls.record("undo-branch1", Success(nil))),
+			NewBranch("branch2", calls.record("branch2", failure), calls.record("undo-branch2", Success(nil)),
+				WithActionRetry(RetryPolicy{MaxAttempts: 2})),
+		})
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Equal(t, "compensated", outcome.Status)
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "stage", abortedErr.SubRequestID)
+	assert.ErrorContains(t, abortedErr, `branch "branch2" failed: some-error`)
+
+	// The failed branch is retried but never compensated.
+	assert.Equal(t, []string{"branch1", "branch2", "branch2", "step1", "undo-branch1", "undo-step1"}, calls.sorted())
+}
+
+func Test_SEC_AppendNewStage_with_a_compensation_failure_should_retry_only_the_failed_branches(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+
+	fixed := false
+	compensation := func(ctx context.Context, sagaCtx json.RawMessage) Result {
+		if !fixed {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}
+
+		return calls.record("undo-branch2", Success(nil))(ctx, sagaCtx)
+	}
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", calls.record("branch1", Success(nil)), calls.record("undo-branch1", Success(nil))),
+			NewBranch("branch2", calls.record("branch2", Success(nil)), compensation),
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 2})).
+		AppendNewSubRequest("step2", calls.record("step2", Failure(errors.New("some-error"), nil)), nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+	require.Error(t, err)
+
+	stuck := scheduler.ListStuckSagas()
+	require.Len(t, stuck, 1)
+
+	fixed = true
+	err = scheduler.ResumeSaga(context.Background(), stuck[0])
+	require.NoError(t, err)
+
+	// The "branch1" Compensation is run only once.
+	assert.Equal(t, []string{"branch1", "branch2", "step2", "undo-branch1", "undo-branch2"}, calls.sorted())
+}
+
+func Test_SEC_Recover_with_an_interrupted_stage(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	events := []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "stage", State: "running", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "running", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch2", State: "running", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch3", State: "running", Context: json.RawMessage(`{}`)},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch1", State: "done", Context: json.RawMessage(`{"id": 1}`)},
+		{SagaID: "some-saga-id", Step: "stage", Branch: "branch3", State: "aborted", Context: json.RawMessage(`{}`)},
+	}
+	for i := range events {
+		require.NoError(t, memory.SaveEventLog(ctx, &events[i]))
+	}
+
+	compensated := map[string]string{}
+	mutex := new(sync.Mutex)
+	compensation := func(name string) Action {
+		return func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			compensated[name] = string(sagaCtx)
+			return Success(nil)
+		}
+	}
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", nil, compensation("branch1")),
+			NewBranch("branch2", nil, compensation("branch2")),
+			NewBranch("branch3", nil, compensation("branch3")),
+		})
+
+	err := scheduler.Recover(ctx)
+	require.NoError(t, err)
+
+	// The interrupted "branch2" may have been applied, it is compensated
+	// with its arguments.
+	assert.Equal(t, map[string]string{
+		"branch1": `{"id": 1}`,
+		"branch2": `{}`,
+	}, compensated)
+
+	unfinished, err := memory.GetUnfinishedSagaIDs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, unfinished)
+}
+
+func Test_mergeJSONObjects(t *testing.T) {
+	res, err := mergeJSONObjects(map[string]json.RawMessage{
+		"branch1": json.RawMessage(`{"key1": 1, "shared": {"a": 1, "b": 2}}`),
+		"branch2": json.RawMessage(`{"key2": 2, "shared": {"b": 2, "a": 1}}`),
+		"branch3": nil,
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"key1": 1, "key2": 2, "shared": {"a": 1, "b": 2}}`, string(res))
+}
+
+func Test_mergeJSONObjects_with_an_invalid_object(t *testing.T) {
+	res, err := mergeJSONObjects(map[string]json.RawMessage{
+		"branch1": json.RawMessage(`[1, 2]`),
+	})
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, `the branch "branch1" didn't return a JSON object`)
+}
@@ -0,0 +1,50 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// SagaState is the accumulated state of a saga: its input and the outputs of
+// all its succeeded Actions.
+type SagaState struct {
+	// Input is the context the saga have been started with.
+	Input json.RawMessage
+
+	// Outputs are the contexts returned by the succeeded Actions, by
+	// Sub-Request ID. The branches of a parallel stage are identified by
+	// "stage/branch".
+	Outputs map[string]json.RawMessage
+}
+
+type sagaStateKey struct{}
+
+// sagaStateLoader read the state of a saga from the journal, only when asked.
+type sagaStateLoader struct {
+	journal Journal
+	sagaID  string
+}
+
+// withSagaState return a context giving access to the state of the given
+// saga with SagaStateFromContext.
+func withSagaState(ctx context.Context, journal Journal, sagaID string) context.Context {
+	return context.WithValue(ctx, sagaStateKey{}, &sagaStateLoader{journal: journal, sagaID: sagaID})
+}
+
+// SagaStateFromContext return the state of the saga running the Action or
+// the Compensation with the given context. A late Sub-Request can so use the
+// saga input or the output of any previous Sub-Request and not only the
+// context given by the previous one.
+//
+// The state is rebuilt from the journal, so it is the same after a Recover.
+// It returns false if the context is not given by a SEC.
+func SagaStateFromContext(ctx context.Context) (*SagaState, bool) {
+	loader, ok := ctx.Value(sagaStateKey{}).(*sagaStateLoader)
+	if !ok {
+		return nil, false
+	}
+
+	input, outputs := loader.journal.GetSagaOutputs(loader.sagaID)
+
+	return &SagaState{Input: input, Outputs: outputs}, true
+}
@@ -0,0 +1,104 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordState return an Action saving the saga state into the given pointer.
+func recordState(state **SagaState, result Result) Action {
+	return func(ctx context.Context, sagaCtx json.RawMessage) Result {
+		*state, _ = SagaStateFromContext(ctx)
+
+		return result
+	}
+}
+
+func Test_SagaStateFromContext_success(t *testing.T) {
+	var state *SagaState
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(json.RawMessage(`{"id": 1}`))
+		}, nil).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+				return Success(json.RawMessage(`{"key1": 1}`))
+			}, nil),
+		}).
+		AppendNewSubRequest("step3", recordState(&state, Success(nil)), nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"amount": 10}`))
+	require.NoError(t, err)
+
+	require.NotNil(t, state)
+	assert.JSONEq(t, `{"amount": 10}`, string(state.Input))
+	assert.Equal(t, map[string]json.RawMessage{
+		"step1":         json.RawMessage(`{"id": 1}`),
+		"stage":         json.RawMessage(`{"key1":1}`),
+		"stage/branch1": json.RawMessage(`{"key1": 1}`),
+	}, state.Outputs)
+}
+
+func Test_SagaStateFromContext_from_a_compensation(t *testing.T) {
+	var state *SagaState
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(json.RawMessage(`{"id": 1}`))
+		}, recordState(&state, Success(json.RawMessage(`{"compensated": true}`)))).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(json.RawMessage(`{"compensated": true}`))
+		})
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"amount": 10}`))
+	require.Error(t, err)
+
+	// The Compensation results and the failed Actions are not part of the
+	// state.
+	require.NotNil(t, state)
+	assert.JSONEq(t, `{"amount": 10}`, string(state.Input))
+	assert.Equal(t, map[string]json.RawMessage{"step1": json.RawMessage(`{"id": 1}`)}, state.Outputs)
+}
+
+func Test_SagaStateFromContext_after_a_Recover(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	events := []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{"amount": 10}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "running", Context: json.RawMessage(`{"amount": 10}`)},
+		{SagaID: "some-saga-id", Step: "step1", State: "done", Context: json.RawMessage(`{"id": 1}`)},
+	}
+	for i := range events {
+		require.NoError(t, memory.SaveEventLog(ctx, &events[i]))
+	}
+
+	var state *SagaState
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", nil, nil).
+		AppendNewSubRequest("step2", recordState(&state, Success(nil)), nil)
+
+	err := scheduler.Recover(ctx)
+	require.NoError(t, err)
+
+	require.NotNil(t, state)
+	assert.JSONEq(t, `{"amount": 10}`, string(state.Input))
+	assert.Equal(t, map[string]json.RawMessage{"step1": json.RawMessage(`{"id": 1}`)}, state.Outputs)
+}
+
+func Test_SagaStateFromContext_without_saga(t *testing.T) {
+	state, ok := SagaStateFromContext(context.Background())
+
+	assert.Nil(t, state)
+	assert.False(t, ok)
+}
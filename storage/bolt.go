@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// boltSagasBucket contains one nested bucket per saga with its eventlogs
+	// keyed by sequence number.
+	boltSagasBucket = []byte("sagas")
+
+	// boltActiveBucket is the index of the unfinished saga IDs.
+	boltActiveBucket = []byte("active_sagas")
+
+	// boltDatesBucket is the index of the sagas by creation date, keyed by
+	// boltDateKey, in order to page the sagas listing.
+	boltDatesBucket = []byte("sagas_by_date")
+)
+
+// Bolt eventlog storage using an embedded bbolt key-value file as storage.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// NewBolt open or create the bbolt database at the given path.
+//
+// The database is locked by bbolt while it is opened. An error wrapping
+// ErrFileLocked is returned if it is already opened by another process.
+func NewBolt(path string) (*Bolt, error) {
+	db, err := openBolt(path, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltSagasBucket)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.CreateBucketIfNotExists(boltActiveBucket)
+		if err != nil {
+			return err
+		}
+
+		if tx.Bucket(boltDatesBucket) == nil {
+			return indexBoltDates(tx)
+		}
+
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create the buckets: %s", err)
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+// NewReadOnlyBolt open the existing bbolt database at the given path in
+// read-only mode. The database is not modified, the SaveEventLog and Compact
+// methods return an error.
+//
+// The lock taken by bbolt is shared between the readers but it can't be taken
+// while the database is opened with NewBolt: an error wrapping ErrFileLocked
+// is returned in this case.
+func NewReadOnlyBolt(path string) (*Bolt, error) {
+	// bbolt try to initialize a missing database.
+	_, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %s", err)
+	}
+
+	db, err := openBolt(path, &bolt.Options{Timeout: time.Second, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltSagasBucket) == nil || tx.Bucket(boltActiveBucket) == nil {
+			return errors.New("the buckets are missing")
+		}
+
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("invalid database: %s", err)
+	}
+
+	return &Bolt{db: db}, nil
+}
+
+func openBolt(path string, opts *bolt.Options) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, opts)
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("failed to open the database: %w", ErrFileLocked)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the database: %s", err)
+	}
+
+	return db, nil
+}
+
+// Close the underlying database.
+func (t *Bolt) Close() error {
+	return t.db.Close()
+}
+
+// SaveEventLog append a new eventlog about a saga Change.
+//
+// An "_init" eventlog for an existing saga is refused with an error wrapping
+// model.ErrSagaAlreadyExists.
+func (t *Bolt) SaveEventLog(ctx context.Context, event *model.EventLog) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode the eventlog: %s", err)
+	}
+
+	err = t.db.Update(func(tx *bolt.Tx) error {
+		sagas := tx.Bucket(boltSagasBucket)
+		if event.Step == "_init" && sagas.Bucket([]byte(event.SagaID)) != nil {
+			return fmt.Errorf("%w: %q", model.ErrSagaAlreadyExists, event.SagaID)
+		}
+
+		saga, err := sagas.CreateBucketIfNotExists([]byte(event.SagaID))
+		if err != nil {
+			return err
+		}
+
+		seq, err := saga.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		err = saga.Put(boltKey(seq), value)
+		if err != nil {
+			return err
+		}
+
+		// The sagas are listed by the date of their first eventlog.
+		if seq == 1 {
+			err = tx.Bucket(boltDatesBucket).Put(boltDateKey(unixNano(event.CreatedAt), event.SagaID), []byte{})
+			if err != nil {
+				return err
+			}
+		}
+
+		active := tx.Bucket(boltActiveBucket)
+		if event.Step == "_finish" {
+			return active.Delete([]byte(event.SagaID))
+		}
+
+		if event.Step == "_init" {
+			return active.Put([]byte(event.SagaID), []byte{})
+		}
+
+		return nil
+	})
+	if errors.Is(err, model.ErrSagaAlreadyExists) {
+		return err
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to save the eventlog: %s", err)
+	}
+
+	return nil
+}
+
+// GetUnfinishedSagaIDs return the IDs of all the sagas without a "_finish"
+// eventlog, ordered by saga ID.
+func (t *Bolt) GetUnfinishedSagaIDs(ctx context.Context) ([]string, error) {
+	res := []string{}
+
+	err := t.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltActiveBucket).ForEach(func(k, v []byte) error {
+			res = append(res, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the active sagas: %s", err)
+	}
+
+	return res, nil
+}
+
+// GetSagaEventLogs return all the eventlogs saved for the given saga, in
+// their saving order.
+func (t *Bolt) GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	res := []model.EventLog{}
+
+	err := t.db.View(func(tx *bolt.Tx) error {
+		saga := tx.Bucket(boltSagasBucket).Bucket([]byte(sagaID))
+		if saga == nil {
+			return nil
+		}
+
+		return saga.ForEach(func(k, v []byte) error {
+			event, err := decodeBoltEventLog(v)
+			if err != nil {
+				return err
+			}
+
+			res = append(res, event)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the eventlogs: %s", err)
+	}
+
+	return res, nil
+}
+
+// ListSagas return the page of sagas selected by the query with all their
+// eventlogs, ordered by creation date, and the cursor of the next page.
+//
+// The sagas are read from the cursor position until the page is full. A
+// database opened with NewReadOnlyBolt without the creation date index, as
+// written by the previous versions, is entirely read for each page.
+func (t *Bolt) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	var after sagaCursor
+	if query.Cursor != "" {
+		var err error
+		after, err = parseCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	var (
+		res    []model.Saga
+		cursor string
+	)
+
+	err := t.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(boltDatesBucket) == nil {
+			sagas := []model.Saga{}
+			err := tx.Bucket(boltSagasBucket).ForEachBucket(func(sagaID []byte) error {
+				saga, err := readBoltSaga(tx, sagaID)
+				sagas = append(sagas, saga)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			res, cursor, err = querySagas(sagas, query)
+			return err
+		}
+
+		res = []model.Saga{}
+		dates := tx.Bucket(boltDatesBucket).Cursor()
+
+		key, _ := dates.First()
+		if !query.CreatedAfter.IsZero() {
+			key, _ = dates.Seek(boltDateKey(query.CreatedAfter.UnixNano(), ""))
+		}
+
+		if query.Cursor != "" {
+			afterKey := boltDateKey(after.createdAt, after.sagaID)
+			if key != nil && bytes.Compare(afterKey, key) >= 0 {
+				key, _ = dates.Seek(afterKey)
+				if bytes.Equal(key, afterKey) {
+					key, _ = dates.Next()
+				}
+			}
+		}
+
+		for ; key != nil; key, _ = dates.Next() {
+			if !query.CreatedBefore.IsZero() && decodeBoltDate(key) >= query.CreatedBefore.UnixNano() {
+				return nil
+			}
+
+			saga, err := readBoltSaga(tx, key[8:])
+			if err != nil {
+				return err
+			}
+
+			if !matchSaga(saga, query) {
+				continue
+			}
+
+			if query.Limit > 0 && len(res) == query.Limit {
+				cursor = newSagaCursor(res[len(res)-1]).String()
+				return nil
+			}
+
+			res = append(res, saga)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read the sagas: %s", err)
+	}
+
+	return res, cursor, nil
+}
+
+// readBoltSaga return the given saga with all its eventlogs.
+func readBoltSaga(tx *bolt.Tx, sagaID []byte) (model.Saga, error) {
+	saga := model.Saga{ID: string(sagaID)}
+
+	bucket := tx.Bucket(boltSagasBucket).Bucket(sagaID)
+	if bucket == nil {
+		return saga, fmt.Errorf("saga %q not found", sagaID)
+	}
+
+	err := bucket.ForEach(func(k, v []byte) error {
+		event, err := decodeBoltEventLog(v)
+		if err != nil {
+			return err
+		}
+
+		saga.EventLogs = append(saga.EventLogs, event)
+		return nil
+	})
+	if err != nil {
+		return saga, err
+	}
+
+	saga.Status = model.ComputeStatus(saga.EventLogs)
+
+	return saga, nil
+}
+
+// indexBoltDates create the creation date index of the existing sagas.
+func indexBoltDates(tx *bolt.Tx) error {
+	dates, err := tx.CreateBucket(boltDatesBucket)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(boltSagasBucket).ForEachBucket(func(sagaID []byte) error {
+		key, err := boltSagaDateKey(tx, sagaID)
+		if err != nil || key == nil {
+			return err
+		}
+
+		return dates.Put(key, []byte{})
+	})
+}
+
+// boltSagaDateKey return the creation date index key of the given saga, nil
+// if it doesn't have any eventlog.
+func boltSagaDateKey(tx *bolt.Tx, sagaID []byte) ([]byte, error) {
+	_, value := tx.Bucket(boltSagasBucket).Bucket(sagaID).Cursor().First()
+	if value == nil {
+		return nil, nil
+	}
+
+	event, err := decodeBoltEventLog(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return boltDateKey(unixNano(event.CreatedAt), string(sagaID)), nil
+}
+
+// boltDateKey return the creation date index key: the date as an unsigned
+// big endian integer, sorted as the signed one, followed by the saga ID.
+func boltDateKey(createdAt int64, sagaID string) []byte {
+	key := make([]byte, 8+len(sagaID))
+	binary.BigEndian.PutUint64(key, uint64(createdAt)^(1<<63))
+	copy(key[8:], sagaID)
+
+	return key
+}
+
+func decodeBoltDate(key []byte) int64 {
+	return int64(binary.BigEndian.Uint64(key) ^ (1 << 63))
+}
+
+// Compact remove the eventlogs of all the finished sagas.
+func (t *Bolt) Compact(ctx context.Context) error {
+	err := t.db.Update(func(tx *bolt.Tx) error {
+		sagas := tx.Bucket(boltSagasBucket)
+		active := tx.Bucket(boltActiveBucket)
+
+		finished := [][]byte{}
+		err := sagas.ForEach(func(k, v []byte) error {
+			if active.Get(k) == nil {
+				finished = append(finished, append([]byte{}, k...))
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, sagaID := range finished {
+			key, err := boltSagaDateKey(tx, sagaID)
+			if err != nil {
+				return err
+			}
+
+			if key != nil {
+				err = tx.Bucket(boltDatesBucket).Delete(key)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = sagas.DeleteBucket(sagaID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compact the finished sagas: %s", err)
+	}
+
+	return nil
+}
+
+func boltKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+
+	return key
+}
+
+func decodeBoltEventLog(value []byte) (model.EventLog, error) {
+	var event model.EventLog
+
+	err := json.Unmarshal(value, &event)
+	if err != nil {
+		return event, fmt.Errorf("failed to decode the eventlog: %s", err)
+	}
+
+	// A nil context is encoded as "null", keep it nil.
+	if string(event.Context) == "null" {
+		event.Context = nil
+	}
+
+	return event, nil
+}
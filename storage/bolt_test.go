@@ -0,0 +1,249 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func newTestBolt(t *testing.T) (*Bolt, string) {
+	path := filepath.Join(t.TempDir(), "saga.db")
+
+	storage, err := NewBolt(path)
+	require.NoError(t, err)
+
+	return storage, path
+}
+
+func Test_Bolt_SaveEventLog_success(t *testing.T) {
+	storage, _ := newTestBolt(t)
+	defer storage.Close()
+
+	events := []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", Context: json.RawMessage(`{"key":"value"}`), Metadata: map[string]string{"traceparent": "some-trace"}, SagaType: "payment", SagaVersion: 2},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "step1", State: "aborted", Context: json.RawMessage(`{"key":"value"}`), Branch: "branch1", Reason: "some-reason"},
+	}
+
+	for _, event := range events {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{events[0], events[2]}, res)
+}
+
+func Test_Bolt_should_reload_the_eventlogs_on_reopen(t *testing.T) {
+	storage, path := newTestBolt(t)
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-3", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_finish", State: "done"},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+	require.NoError(t, storage.Close())
+
+	storage, err := NewBolt(path)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	res, err := storage.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saga-2", "saga-3"}, res)
+}
+
+func Test_NewBolt_with_a_database_already_opened(t *testing.T) {
+	storage, path := newTestBolt(t)
+	defer storage.Close()
+
+	res, err := NewBolt(path)
+	assert.ErrorIs(t, err, ErrFileLocked)
+	assert.Nil(t, res)
+
+	res, err = NewReadOnlyBolt(path)
+	assert.ErrorIs(t, err, ErrFileLocked)
+	assert.Nil(t, res)
+}
+
+func Test_NewReadOnlyBolt_success(t *testing.T) {
+	storage, path := newTestBolt(t)
+
+	err := storage.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	storage, err = NewReadOnlyBolt(path)
+	require.NoError(t, err)
+	defer storage.Close()
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Len(t, res, 1)
+
+	err = storage.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "_init", State: "done"})
+	assert.Error(t, err)
+
+	// The database is not modified.
+	res2, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, info.ModTime(), res2.ModTime())
+}
+
+func Test_NewReadOnlyBolt_with_a_missing_database(t *testing.T) {
+	res, err := NewReadOnlyBolt(filepath.Join(t.TempDir(), "saga.db"))
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
+
+func Test_Bolt_GetSagaEventLogs_with_an_unknown_saga(t *testing.T) {
+	storage, _ := newTestBolt(t)
+	defer storage.Close()
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "some-unknown-id")
+
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func Test_Bolt_Compact_should_remove_only_the_finished_sagas(t *testing.T) {
+	storage, _ := newTestBolt(t)
+	defer storage.Close()
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_finish", State: "done"},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	err := storage.Compact(context.Background())
+	require.NoError(t, err)
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	res, err = storage.GetSagaEventLogs(context.Background(), "saga-2")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{{SagaID: "saga-2", Step: "_init", State: "done"}}, res)
+}
+
+func Test_Bolt_Compact_should_remove_the_sagas_from_the_listing(t *testing.T) {
+	storage, _ := newTestBolt(t)
+	defer storage.Close()
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", CreatedAt: queryDate},
+		{SagaID: "saga-2", Step: "_init", State: "done", CreatedAt: queryDate},
+		{SagaID: "saga-1", Step: "_finish", State: "done", CreatedAt: queryDate},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	err := storage.Compact(context.Background())
+	require.NoError(t, err)
+
+	res, cursor, err := storage.ListSagas(context.Background(), model.SagaQuery{Limit: 1})
+	require.NoError(t, err)
+	assert.Empty(t, cursor)
+	require.Len(t, res, 1)
+	assert.Equal(t, "saga-2", res[0].ID)
+}
+
+func Test_Bolt_SaveEventLog_with_an_existing_saga(t *testing.T) {
+	storage, _ := newTestBolt(t)
+	defer storage.Close()
+
+	testSaveEventLogWithAnExistingSaga(t, storage)
+}
+
+func Test_Bolt_ListSagas(t *testing.T) {
+	storage, _ := newTestBolt(t)
+	defer storage.Close()
+
+	testListSagas(t, storage)
+}
+
+func Test_Bolt_ListSagas_without_the_date_index(t *testing.T) {
+	storage, path := newTestBolt(t)
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-2", Step: "_init", State: "done", CreatedAt: queryDate},
+		{SagaID: "saga-1", Step: "_init", State: "done", CreatedAt: queryDate.Add(time.Second)},
+		{SagaID: "saga-3", Step: "_init", State: "done", CreatedAt: queryDate},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	// As written by the previous versions.
+	err := storage.db.Update(func(tx *bolt.Tx) error { return tx.DeleteBucket(boltDatesBucket) })
+	require.NoError(t, err)
+	require.NoError(t, storage.Close())
+
+	ids := func(storage *Bolt) []string {
+		res := []string{}
+		cursor := ""
+		for {
+			sagas, next, err := storage.ListSagas(context.Background(), model.SagaQuery{Limit: 2, Cursor: cursor})
+			require.NoError(t, err)
+
+			for _, saga := range sagas {
+				res = append(res, saga.ID)
+			}
+
+			if next == "" {
+				return res
+			}
+			cursor = next
+		}
+	}
+
+	// The read-only databases are read entirely.
+	storage, err = NewReadOnlyBolt(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"saga-2", "saga-3", "saga-1"}, ids(storage))
+	require.NoError(t, storage.Close())
+
+	// The index is created at the opening.
+	storage, err = NewBolt(path)
+	require.NoError(t, err)
+	defer storage.Close()
+	assert.Equal(t, []string{"saga-2", "saga-3", "saga-1"}, ids(storage))
+}
+
+func Test_boltDateKey_should_keep_the_dates_order(t *testing.T) {
+	keys := [][]byte{
+		boltDateKey(-1, "saga-1"),
+		boltDateKey(0, "saga-1"),
+		boltDateKey(0, "saga-2"),
+		boltDateKey(queryDate.UnixNano(), "saga-1"),
+	}
+
+	for i := 1; i < len(keys); i++ {
+		assert.Equal(t, -1, bytes.Compare(keys[i-1], keys[i]))
+	}
+
+	assert.Equal(t, queryDate.UnixNano(), decodeBoltDate(keys[3]))
+}
@@ -0,0 +1,569 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Peltoche/gosaga/model"
+)
+
+// SyncPolicy define when the File storage flush its writes to the disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsync the file after each saved eventlog. A saved eventlog
+	// is guaranteed to survive a power loss.
+	SyncAlways SyncPolicy = iota
+
+	// SyncNever let the OS flush the writes to the disk. A saved eventlog
+	// survives a process crash but can be lost in case of power loss.
+	SyncNever
+)
+
+// recordHeaderSize is the size of the header written before each record:
+// the payload length and the payload CRC32 checksum.
+const recordHeaderSize = 8
+
+// maxRecordSize is the biggest record accepted. Anything bigger is a corrupted
+// header.
+const maxRecordSize = 64 << 20
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errTornRecord is returned for a record ending after the end of the file.
+var errTornRecord = errors.New("torn record")
+
+// errCorruptedRecord is returned for a complete record with an invalid
+// checksum or length.
+var errCorruptedRecord = errors.New("corrupted record")
+
+// ErrFileLocked is returned by NewFile when the file is already opened by
+// another process.
+var ErrFileLocked = errors.New("file locked by another process")
+
+var errReadOnly = errors.New("the file is opened in read-only mode")
+
+// File eventlog storage using an append-only file as storage.
+//
+// Each eventlog is saved as a record prefixed by its length and its checksum.
+// A record partially written during a crash is detected and removed when the
+// file is reopened.
+//
+// The file is locked while it is opened so only one process can write into
+// it. It can be read in the same time via NewReadOnlyFile.
+type File struct {
+	mutex      *sync.Mutex
+	path       string
+	file       *os.File
+	syncPolicy SyncPolicy
+	size       int64
+	readOnly   bool
+
+	// sagaIDs is the set of the sagas saved into the file.
+	sagaIDs map[string]struct{}
+}
+
+// NewFile open or create the log file at the given path.
+//
+// An error wrapping ErrFileLocked is returned if the file is already opened by
+// another process.
+func NewFile(path string, syncPolicy SyncPolicy) (*File, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the file: %s", err)
+	}
+
+	err = lockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock the file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat the file: %s", err)
+	}
+
+	// Find the end of the last valid record and drop everything after it.
+	sagaIDs := map[string]struct{}{}
+	size, err := readRecords(file, info.Size(), func(event model.EventLog) {
+		sagaIDs[event.SagaID] = struct{}{}
+	})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read the file: %s", err)
+	}
+
+	err = file.Truncate(size)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to truncate the torn records: %s", err)
+	}
+
+	_, err = file.Seek(size, io.SeekStart)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to seek the end of the file: %s", err)
+	}
+
+	return &File{
+		mutex:      new(sync.Mutex),
+		path:       path,
+		file:       file,
+		syncPolicy: syncPolicy,
+		size:       size,
+		sagaIDs:    sagaIDs,
+	}, nil
+}
+
+// NewReadOnlyFile open the existing log file at the given path in read-only
+// mode. The file is neither locked nor modified, it can be opened while
+// another process is writing into it.
+//
+// Only the records written when the file is opened are read. The
+// SaveEventLog and Compact methods return an error.
+func NewReadOnlyFile(path string) (*File, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the file: %s", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to stat the file: %s", err)
+	}
+
+	sagaIDs := map[string]struct{}{}
+	size, err := readRecords(file, info.Size(), func(event model.EventLog) {
+		sagaIDs[event.SagaID] = struct{}{}
+	})
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read the file: %s", err)
+	}
+
+	return &File{
+		mutex:    new(sync.Mutex),
+		path:     path,
+		file:     file,
+		size:     size,
+		readOnly: true,
+		sagaIDs:  sagaIDs,
+	}, nil
+}
+
+// Close the underlying file.
+func (t *File) Close() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.file.Close()
+}
+
+// Sync flush all the writes to the disk.
+func (t *File) Sync() error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.file.Sync()
+}
+
+// SaveEventLog append a new eventlog about a saga Change.
+//
+// An "_init" eventlog for an existing saga is refused with an error wrapping
+// model.ErrSagaAlreadyExists.
+func (t *File) SaveEventLog(ctx context.Context, event *model.EventLog) error {
+	if t.readOnly {
+		return errReadOnly
+	}
+
+	record, err := encodeRecord(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode the eventlog: %s", err)
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if _, exists := t.sagaIDs[event.SagaID]; exists && event.Step == "_init" {
+		return fmt.Errorf("%w: %q", model.ErrSagaAlreadyExists, event.SagaID)
+	}
+
+	_, err = t.file.Write(record)
+	if err != nil {
+		// Remove the partial write in order to keep the file valid.
+		t.rollback()
+		return fmt.Errorf("failed to write the record: %s", err)
+	}
+
+	if t.syncPolicy == SyncAlways {
+		err = t.file.Sync()
+		if err != nil {
+			// The eventlog is reported as not saved, it must not be read
+			// back.
+			t.rollback()
+			return fmt.Errorf("failed to sync the file: %s", err)
+		}
+	}
+
+	t.size += int64(len(record))
+	t.sagaIDs[event.SagaID] = struct{}{}
+
+	return nil
+}
+
+// rollback remove everything written after the last saved record. The mutex
+// must be held.
+func (t *File) rollback() {
+	_ = t.file.Truncate(t.size)
+	_, _ = t.file.Seek(t.size, io.SeekStart)
+}
+
+// GetUnfinishedSagaIDs return the IDs of all the sagas without a "_finish"
+// eventlog, in their creation order.
+func (t *File) GetUnfinishedSagaIDs(ctx context.Context) ([]string, error) {
+	sagaIDs := []string{}
+	finished := map[string]bool{}
+	seen := map[string]bool{}
+
+	err := t.scan(func(event model.EventLog) {
+		if event.Step == "_finish" {
+			finished[event.SagaID] = true
+		}
+
+		if !seen[event.SagaID] {
+			seen[event.SagaID] = true
+			sagaIDs = append(sagaIDs, event.SagaID)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for _, sagaID := range sagaIDs {
+		if !finished[sagaID] {
+			res = append(res, sagaID)
+		}
+	}
+
+	return res, nil
+}
+
+// GetSagaEventLogs return all the eventlogs saved for the given saga, in
+// their saving order.
+func (t *File) GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	res := []model.EventLog{}
+
+	err := t.scan(func(event model.EventLog) {
+		if event.SagaID == sagaID {
+			res = append(res, event)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ListSagas return the page of sagas selected by the query with all their
+// eventlogs, ordered by creation date, and the cursor of the next page.
+//
+// The whole file is read for each page: a page costs O(total eventlogs). Use
+// the Bolt or SQL storages in order to list many sagas.
+func (t *File) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	events := []model.EventLog{}
+
+	err := t.scan(func(event model.EventLog) {
+		events = append(events, event)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return querySagas(groupSagas(events), query)
+}
+
+// Compact rewrite the file without the eventlogs of the finished sagas.
+//
+// The file is replaced atomically so a crash during the compaction keeps
+// either the old or the new file. The files opened with NewReadOnlyFile keep
+// reading the old file.
+func (t *File) Compact(ctx context.Context) error {
+	if t.readOnly {
+		return errReadOnly
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	events := []model.EventLog{}
+	finished := map[string]bool{}
+	_, err := readRecords(t.file, t.size, func(event model.EventLog) {
+		if event.Step == "_finish" {
+			finished[event.SagaID] = true
+		}
+
+		events = append(events, event)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read the file: %s", err)
+	}
+
+	var size int64
+	tmp, err := os.OpenFile(t.path+".compact", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create the compacted file: %s", err)
+	}
+
+	// The lock is moved to the compacted file as the old one is closed.
+	err = lockFile(tmp)
+	if err == nil {
+		size, err = writeRecords(tmp, events, finished)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), t.path)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write the compacted file: %s", err)
+	}
+
+	t.file.Close()
+	t.file = tmp
+	t.size = size
+
+	for sagaID := range finished {
+		delete(t.sagaIDs, sagaID)
+	}
+
+	// The rename is durable only once the directory is synced.
+	err = syncDir(filepath.Dir(t.path))
+	if err != nil {
+		return fmt.Errorf("failed to sync the directory: %s", err)
+	}
+
+	return nil
+}
+
+// syncDir fsync the given directory in order to persist its entries.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+// writeRecords write and sync the eventlogs of the sagas not finished and
+// return the size written.
+func writeRecords(file *os.File, events []model.EventLog, finished map[string]bool) (int64, error) {
+	writer := bufio.NewWriter(file)
+
+	var size int64
+	for _, event := range events {
+		if finished[event.SagaID] {
+			continue
+		}
+
+		record, err := encodeRecord(&event)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = writer.Write(record)
+		if err != nil {
+			return 0, err
+		}
+
+		size += int64(len(record))
+	}
+
+	err := writer.Flush()
+	if err != nil {
+		return 0, err
+	}
+
+	return size, file.Sync()
+}
+
+// scan call fn for each eventlog saved into the file.
+func (t *File) scan(fn func(model.EventLog)) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	_, err := readRecords(t.file, t.size, fn)
+	if err != nil {
+		return fmt.Errorf("failed to read the file: %s", err)
+	}
+
+	return nil
+}
+
+// readRecords call fn for each valid record found into the size first bytes
+// of r and return the offset of the end of the last valid record.
+//
+// An invalid record is dropped only if it is the last one of r, as left by a
+// crash during its write. An invalid record followed by other records is a
+// corruption and an error is returned in order to keep them.
+func readRecords(r io.ReaderAt, size int64, fn func(model.EventLog)) (int64, error) {
+	reader := bufio.NewReader(io.NewSectionReader(r, 0, size))
+
+	var offset int64
+	for {
+		event, recordSize, err := readRecord(reader)
+		if err == io.EOF {
+			return offset, nil
+		}
+
+		if err == errTornRecord {
+			// A length corrupted in the middle of the file reach its end as
+			// a torn record.
+			followed, err := hasValidRecord(r, offset+recordHeaderSize, size)
+			if err != nil {
+				return offset, err
+			}
+
+			if followed {
+				return offset, fmt.Errorf("corrupted record at offset %d", offset)
+			}
+
+			return offset, nil
+		}
+
+		if err == errCorruptedRecord {
+			_, err = reader.Peek(1)
+			if err == io.EOF {
+				return offset, nil
+			}
+
+			return offset, fmt.Errorf("corrupted record at offset %d", offset)
+		}
+
+		if err != nil {
+			return offset, err
+		}
+
+		fn(event)
+		offset += recordSize
+	}
+}
+
+// hasValidRecord return true if a valid record starts anywhere between the
+// start and end offsets of r.
+func hasValidRecord(r io.ReaderAt, start int64, end int64) (bool, error) {
+	if start >= end {
+		return false, nil
+	}
+
+	tail, err := io.ReadAll(io.NewSectionReader(r, start, end-start))
+	if err != nil {
+		return false, err
+	}
+
+	for i := 0; i+recordHeaderSize < len(tail); i++ {
+		length := binary.BigEndian.Uint32(tail[i : i+4])
+
+		// The payloads are JSON objects.
+		if length > maxRecordSize || int64(i+recordHeaderSize)+int64(length) > int64(len(tail)) || tail[i+recordHeaderSize] != '{' {
+			continue
+		}
+
+		payload := tail[i+recordHeaderSize : i+recordHeaderSize+int(length)]
+		if crc32.Checksum(payload, crcTable) == binary.BigEndian.Uint32(tail[i+4:i+8]) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// encodeRecord return the record of the given eventlog: its header followed
+// by its JSON payload.
+func encodeRecord(event *model.EventLog) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	record := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.Checksum(payload, crcTable))
+	copy(record[recordHeaderSize:], payload)
+
+	return record, nil
+}
+
+func readRecord(r io.Reader) (model.EventLog, int64, error) {
+	var event model.EventLog
+
+	header := make([]byte, recordHeaderSize)
+	n, err := io.ReadFull(r, header)
+	if err == io.EOF {
+		return event, 0, io.EOF
+	}
+
+	if err == io.ErrUnexpectedEOF {
+		return event, 0, errTornRecord
+	}
+
+	if err != nil {
+		return event, 0, err
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	if length > maxRecordSize {
+		// Skip the payload without loading it.
+		_, err = io.CopyN(io.Discard, r, int64(length))
+		if err == io.EOF {
+			return event, 0, errTornRecord
+		}
+
+		if err != nil {
+			return event, 0, err
+		}
+
+		return event, 0, errCorruptedRecord
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return event, 0, errTornRecord
+	}
+
+	if err != nil {
+		return event, 0, err
+	}
+
+	if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return event, 0, errCorruptedRecord
+	}
+
+	err = json.Unmarshal(payload, &event)
+	if err != nil {
+		return event, 0, fmt.Errorf("failed to decode the record: %s", err)
+	}
+
+	// A nil context is encoded as "null", keep it nil.
+	if string(event.Context) == "null" {
+		event.Context = nil
+	}
+
+	return event, int64(n + len(payload)), nil
+}
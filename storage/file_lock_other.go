@@ -0,0 +1,11 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// lockFile is a no-op on the platforms without flock, the file must not be
+// opened by several processes.
+func lockFile(file *os.File) error {
+	return nil
+}
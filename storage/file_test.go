@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestFile(t *testing.T) (*File, string) {
+	path := filepath.Join(t.TempDir(), "saga.log")
+
+	file, err := NewFile(path, SyncAlways)
+	require.NoError(t, err)
+
+	return file, path
+}
+
+func Test_File_SaveEventLog_success(t *testing.T) {
+	file, _ := newTestFile(t)
+	defer file.Close()
+
+	event := &model.EventLog{
+		SagaID:      "some-id",
+		Step:        "_init",
+		State:       "done",
+		Context:     json.RawMessage(`{"key":"value"}`),
+		Metadata:    map[string]string{"traceparent": "some-trace"},
+		SagaType:    "payment",
+		SagaVersion: 2,
+	}
+
+	err := file.SaveEventLog(context.Background(), event)
+	require.NoError(t, err)
+
+	res, err := file.GetSagaEventLogs(context.Background(), "some-id")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{*event}, res)
+}
+
+func Test_File_SaveEventLog_with_an_existing_saga(t *testing.T) {
+	file, path := newTestFile(t)
+
+	testSaveEventLogWithAnExistingSaga(t, file)
+
+	require.NoError(t, file.Close())
+
+	// The existing sagas are reloaded on reopen.
+	file, err := NewFile(path, SyncAlways)
+	require.NoError(t, err)
+	defer file.Close()
+
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "some-key", Step: "_init", State: "done"})
+	assert.ErrorIs(t, err, model.ErrSagaAlreadyExists)
+}
+
+func Test_File_should_reload_the_eventlogs_on_reopen(t *testing.T) {
+	file, path := newTestFile(t)
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_finish", State: "done"},
+	} {
+		err := file.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+	require.NoError(t, file.Close())
+
+	file, err := NewFile(path, SyncNever)
+	require.NoError(t, err)
+	defer file.Close()
+
+	res, err := file.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saga-2"}, res)
+
+	// New eventlogs are appended after the existing ones.
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "step1", State: "running"})
+	require.NoError(t, err)
+
+	logs, err := file.GetSagaEventLogs(context.Background(), "saga-2")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "step1", State: "running"},
+	}, logs)
+}
+
+func Test_File_should_drop_a_torn_trailing_record_on_reopen(t *testing.T) {
+	file, path := newTestFile(t)
+
+	err := file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "step1", State: "running"})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	// Simulate a crash in the middle of the last write.
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, os.Truncate(path, info.Size()-3))
+
+	file, err = NewFile(path, SyncAlways)
+	require.NoError(t, err)
+	defer file.Close()
+
+	logs, err := file.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{{SagaID: "saga-1", Step: "_init", State: "done"}}, logs)
+
+	// The torn record have been removed from the file.
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "step1", State: "running"})
+	require.NoError(t, err)
+
+	logs, err = file.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Len(t, logs, 2)
+}
+
+func Test_File_should_drop_a_record_with_an_invalid_checksum_on_reopen(t *testing.T) {
+	file, path := newTestFile(t)
+
+	err := file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	// Corrupt the last byte of the payload.
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	raw[len(raw)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, raw, 0600))
+
+	file, err = NewFile(path, SyncAlways)
+	require.NoError(t, err)
+	defer file.Close()
+
+	res, err := file.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func Test_NewFile_with_a_corrupted_record_followed_by_valid_records(t *testing.T) {
+	file, path := newTestFile(t)
+
+	err := file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-3", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	// Corrupt the payload of the second record.
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	raw[info.Size()+recordHeaderSize] ^= 0xff
+	require.NoError(t, os.WriteFile(path, raw, 0600))
+
+	file, err = NewFile(path, SyncAlways)
+	assert.EqualError(t, err, fmt.Sprintf("failed to read the file: corrupted record at offset %d", info.Size()))
+	assert.Nil(t, file)
+
+	// The valid records are kept.
+	res, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, raw, res)
+}
+
+func Test_NewFile_with_a_corrupted_length_followed_by_valid_records(t *testing.T) {
+	file, path := newTestFile(t)
+
+	err := file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-3", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	valid, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Flip a bit of the second record length so it reach past the end of
+	// the file, with a length valid or above maxRecordSize.
+	for _, i := range []int64{1, 0} {
+		raw := append([]byte{}, valid...)
+		raw[info.Size()+i] ^= 0x80
+		require.NoError(t, os.WriteFile(path, raw, 0600))
+
+		file, err = NewFile(path, SyncAlways)
+		assert.EqualError(t, err, fmt.Sprintf("failed to read the file: corrupted record at offset %d", info.Size()))
+		assert.Nil(t, file)
+
+		// The valid records are kept.
+		res, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, raw, res)
+	}
+}
+
+func Test_NewFile_with_a_file_already_opened(t *testing.T) {
+	file, path := newTestFile(t)
+	defer file.Close()
+
+	res, err := NewFile(path, SyncAlways)
+	assert.ErrorIs(t, err, ErrFileLocked)
+	assert.Nil(t, res)
+
+	// The lock is moved to the compacted file.
+	require.NoError(t, file.Compact(context.Background()))
+
+	res, err = NewFile(path, SyncAlways)
+	assert.ErrorIs(t, err, ErrFileLocked)
+	assert.Nil(t, res)
+}
+
+func Test_NewReadOnlyFile_success(t *testing.T) {
+	file, path := newTestFile(t)
+	defer file.Close()
+
+	err := file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+
+	// The file is read while it is opened for writing.
+	readOnly, err := NewReadOnlyFile(path)
+	require.NoError(t, err)
+	defer readOnly.Close()
+
+	res, err := readOnly.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saga-1"}, res)
+
+	err = readOnly.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "_init", State: "done"})
+	assert.EqualError(t, err, "the file is opened in read-only mode")
+
+	err = readOnly.Compact(context.Background())
+	assert.EqualError(t, err, "the file is opened in read-only mode")
+}
+
+func Test_NewReadOnlyFile_with_a_missing_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saga.log")
+
+	file, err := NewReadOnlyFile(path)
+	assert.Error(t, err)
+	assert.Nil(t, file)
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_NewFile_with_an_invalid_path(t *testing.T) {
+	file, err := NewFile(filepath.Join(t.TempDir(), "unknown-dir", "saga.log"), SyncAlways)
+
+	assert.Error(t, err)
+	assert.Nil(t, file)
+}
+
+func Test_File_ListSagas(t *testing.T) {
+	file, _ := newTestFile(t)
+	defer file.Close()
+
+	testListSagas(t, file)
+}
+
+func Test_File_Compact_should_remove_only_the_finished_sagas(t *testing.T) {
+	file, path := newTestFile(t)
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_finish", State: "done"},
+	} {
+		err := file.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	err := file.Compact(context.Background())
+	require.NoError(t, err)
+
+	// The file is still usable after the compaction.
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "step1", State: "running"})
+	require.NoError(t, err)
+
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "_init", State: "done"})
+	assert.ErrorIs(t, err, model.ErrSagaAlreadyExists)
+
+	require.NoError(t, file.Close())
+
+	file, err = NewFile(path, SyncAlways)
+	require.NoError(t, err)
+	defer file.Close()
+
+	res, err := file.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	res, err = file.GetSagaEventLogs(context.Background(), "saga-2")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "step1", State: "running"},
+	}, res)
+
+	_, err = os.Stat(path + ".compact")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func Test_File_Compact_twice_should_keep_the_original_path(t *testing.T) {
+	file, path := newTestFile(t)
+
+	err := file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+
+	require.NoError(t, file.Compact(context.Background()))
+	require.NoError(t, file.Compact(context.Background()))
+
+	err = file.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-2", Step: "_init", State: "done"})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	file, err = NewFile(path, SyncAlways)
+	require.NoError(t, err)
+	defer file.Close()
+
+	res, err := file.GetUnfinishedSagaIDs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saga-1", "saga-2"}, res)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
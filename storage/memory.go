@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/Peltoche/gosaga/model"
@@ -25,11 +26,81 @@ func NewMemory() *Memory {
 }
 
 // SaveEventLog save a new eventlog about a saga Change.
+//
+// An "_init" eventlog for an existing saga is refused with an error wrapping
+// model.ErrSagaAlreadyExists.
 func (t *Memory) SaveEventLog(ctx context.Context, event *model.EventLog) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
+	if event.Step == "_init" {
+		for _, existing := range t.journal {
+			if existing.SagaID == event.SagaID {
+				return fmt.Errorf("%w: %q", model.ErrSagaAlreadyExists, event.SagaID)
+			}
+		}
+	}
+
 	t.journal = append(t.journal, *event)
 
 	return nil
 }
+
+// GetUnfinishedSagaIDs return the IDs of all the sagas without a "_finish"
+// eventlog, in their creation order.
+func (t *Memory) GetUnfinishedSagaIDs(ctx context.Context) ([]string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	sagaIDs := []string{}
+	finished := map[string]bool{}
+	seen := map[string]bool{}
+	for _, event := range t.journal {
+		if event.Step == "_finish" {
+			finished[event.SagaID] = true
+		}
+
+		if !seen[event.SagaID] {
+			seen[event.SagaID] = true
+			sagaIDs = append(sagaIDs, event.SagaID)
+		}
+	}
+
+	res := []string{}
+	for _, sagaID := range sagaIDs {
+		if !finished[sagaID] {
+			res = append(res, sagaID)
+		}
+	}
+
+	return res, nil
+}
+
+// GetSagaEventLogs return all the eventlogs saved for the given saga, in
+// their saving order.
+func (t *Memory) GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	res := []model.EventLog{}
+	for _, event := range t.journal {
+		if event.SagaID == sagaID {
+			res = append(res, event)
+		}
+	}
+
+	return res, nil
+}
+
+// ListSagas return the page of sagas selected by the query with all their
+// eventlogs, ordered by creation date, and the cursor of the next page.
+//
+// All the sagas are grouped, filtered and sorted for each page: a page costs
+// O(total sagas).
+func (t *Memory) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	t.mutex.Lock()
+	sagas := groupSagas(t.journal)
+	t.mutex.Unlock()
+
+	return querySagas(sagas, query)
+}
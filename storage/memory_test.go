@@ -26,3 +26,94 @@ func Test_Memory_SaveEventLog_should_success(t *testing.T) {
 
 	assert.EqualValues(t, &memory.journal[0], event)
 }
+
+type eventLogSaver interface {
+	SaveEventLog(ctx context.Context, event *model.EventLog) error
+}
+
+// testSaveEventLogWithAnExistingSaga check that the given storage refuses a
+// second "_init" eventlog for a saga, even saved concurrently.
+func testSaveEventLogWithAnExistingSaga(t *testing.T, storage eventLogSaver) {
+	ctx := context.Background()
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func() {
+			errs <- storage.SaveEventLog(ctx, &model.EventLog{SagaID: "some-key", Step: "_init", State: "done"})
+		}()
+	}
+
+	created := 0
+	for i := 0; i < 5; i++ {
+		err := <-errs
+		if err == nil {
+			created++
+			continue
+		}
+
+		assert.ErrorIs(t, err, model.ErrSagaAlreadyExists)
+		assert.EqualError(t, err, `saga already exists: "some-key"`)
+	}
+	assert.Equal(t, 1, created)
+
+	// The next eventlogs of the saga are accepted.
+	err := storage.SaveEventLog(ctx, &model.EventLog{SagaID: "some-key", Step: "step1", State: "running"})
+	assert.NoError(t, err)
+}
+
+func Test_Memory_SaveEventLog_with_an_existing_saga(t *testing.T) {
+	testSaveEventLogWithAnExistingSaga(t, NewMemory())
+}
+
+func Test_Memory_GetUnfinishedSagaIDs_success(t *testing.T) {
+	memory := NewMemory()
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_finish", State: "done"},
+		{SagaID: "saga-3", Step: "_init", State: "done"},
+	} {
+		err := memory.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	res, err := memory.GetUnfinishedSagaIDs(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saga-2", "saga-3"}, res)
+}
+
+func Test_Memory_GetSagaEventLogs_success(t *testing.T) {
+	memory := NewMemory()
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "step1", State: "running"},
+	} {
+		err := memory.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	res, err := memory.GetSagaEventLogs(context.Background(), "saga-1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "step1", State: "running"},
+	}, res)
+}
+
+func Test_Memory_GetSagaEventLogs_with_an_unknown_saga(t *testing.T) {
+	memory := NewMemory()
+
+	res, err := memory.GetSagaEventLogs(context.Background(), "some-unknown-id")
+
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func Test_Memory_ListSagas(t *testing.T) {
+	testListSagas(t, NewMemory())
+}
@@ -16,3 +16,36 @@ type Mock struct {
 func (t *Mock) SaveEventLog(ctx context.Context, event *model.EventLog) error {
 	return t.Called(event).Error(0)
 }
+
+// GetUnfinishedSagaIDs mock implementation.
+func (t *Mock) GetUnfinishedSagaIDs(ctx context.Context) ([]string, error) {
+	args := t.Called()
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// GetSagaEventLogs mock implementation.
+func (t *Mock) GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	args := t.Called(sagaID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]model.EventLog), args.Error(1)
+}
+
+// ListSagas mock implementation.
+func (t *Mock) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	args := t.Called(query)
+
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+
+	return args.Get(0).([]model.Saga), args.String(1), args.Error(2)
+}
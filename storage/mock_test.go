@@ -3,9 +3,11 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 
 	"github.com/Peltoche/gosaga/model"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_Mock_SaveEventLog(t *testing.T) {
@@ -23,3 +25,73 @@ func Test_Mock_SaveEventLog(t *testing.T) {
 
 	eventlog.AssertExpectations(t)
 }
+
+func Test_Mock_GetUnfinishedSagaIDs(t *testing.T) {
+	eventlog := new(Mock)
+
+	eventlog.On("GetUnfinishedSagaIDs").Return([]string{"some-id"}, nil)
+
+	res, err := eventlog.GetUnfinishedSagaIDs(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"some-id"}, res)
+
+	eventlog.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaEventLogs(t *testing.T) {
+	eventlog := new(Mock)
+
+	events := []model.EventLog{{SagaID: "some-id", Step: "_init", State: "done"}}
+
+	eventlog.On("GetSagaEventLogs", "some-id").Return(events, nil)
+
+	res, err := eventlog.GetSagaEventLogs(context.Background(), "some-id")
+
+	assert.NoError(t, err)
+	assert.Equal(t, events, res)
+
+	eventlog.AssertExpectations(t)
+}
+
+func Test_Mock_GetSagaEventLogs_with_nil(t *testing.T) {
+	eventlog := new(Mock)
+
+	eventlog.On("GetSagaEventLogs", "some-id").Return(nil, errors.New("some-error"))
+
+	res, err := eventlog.GetSagaEventLogs(context.Background(), "some-id")
+
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, res)
+
+	eventlog.AssertExpectations(t)
+}
+
+func Test_Mock_ListSagas(t *testing.T) {
+	eventlog := new(Mock)
+
+	sagas := []model.Saga{{ID: "some-id", Status: "running"}}
+	eventlog.On("ListSagas", model.SagaQuery{Status: "running"}).Return(sagas, "some-cursor", nil)
+
+	res, cursor, err := eventlog.ListSagas(context.Background(), model.SagaQuery{Status: "running"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, sagas, res)
+	assert.Equal(t, "some-cursor", cursor)
+
+	eventlog.AssertExpectations(t)
+}
+
+func Test_Mock_ListSagas_with_nil(t *testing.T) {
+	eventlog := new(Mock)
+
+	eventlog.On("ListSagas", model.SagaQuery{}).Return(nil, "", errors.New("some-error"))
+
+	res, cursor, err := eventlog.ListSagas(context.Background(), model.SagaQuery{})
+
+	assert.EqualError(t, err, "some-error")
+	assert.Nil(t, res)
+	assert.Empty(t, cursor)
+
+	eventlog.AssertExpectations(t)
+}
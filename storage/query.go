@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Peltoche/gosaga/model"
+)
+
+// sagaCursor is the position of a saga into the listing order: its creation
+// date then its ID.
+type sagaCursor struct {
+	createdAt int64
+	sagaID    string
+}
+
+func newSagaCursor(saga model.Saga) sagaCursor {
+	if len(saga.EventLogs) == 0 {
+		return sagaCursor{sagaID: saga.ID}
+	}
+
+	return sagaCursor{createdAt: unixNano(saga.EventLogs[0].CreatedAt), sagaID: saga.ID}
+}
+
+// parseCursor decode a cursor returned by formatCursor.
+func parseCursor(cursor string) (sagaCursor, error) {
+	rawDate, sagaID, found := strings.Cut(cursor, ":")
+	if !found || sagaID == "" {
+		return sagaCursor{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+
+	createdAt, err := strconv.ParseInt(rawDate, 10, 64)
+	if err != nil {
+		return sagaCursor{}, fmt.Errorf("invalid cursor %q", cursor)
+	}
+
+	return sagaCursor{createdAt: createdAt, sagaID: sagaID}, nil
+}
+
+func (t sagaCursor) String() string {
+	return strconv.FormatInt(t.createdAt, 10) + ":" + t.sagaID
+}
+
+func (t sagaCursor) before(other sagaCursor) bool {
+	if t.createdAt != other.createdAt {
+		return t.createdAt < other.createdAt
+	}
+
+	return t.sagaID < other.sagaID
+}
+
+// groupSagas group the given eventlogs by saga, keeping their saving order.
+func groupSagas(events []model.EventLog) []model.Saga {
+	res := []model.Saga{}
+	index := map[string]int{}
+	for _, event := range events {
+		idx, ok := index[event.SagaID]
+		if !ok {
+			idx = len(res)
+			index[event.SagaID] = idx
+			res = append(res, model.Saga{ID: event.SagaID})
+		}
+
+		res[idx].EventLogs = append(res[idx].EventLogs, event)
+	}
+
+	for i := range res {
+		res[i].Status = model.ComputeStatus(res[i].EventLogs)
+	}
+
+	return res
+}
+
+// querySagas return the page of sagas selected by the query, ordered by
+// creation date, and the cursor of the next page if any.
+func querySagas(sagas []model.Saga, query model.SagaQuery) ([]model.Saga, string, error) {
+	var after sagaCursor
+	if query.Cursor != "" {
+		var err error
+		after, err = parseCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	sort.SliceStable(sagas, func(i, j int) bool {
+		return newSagaCursor(sagas[i]).before(newSagaCursor(sagas[j]))
+	})
+
+	res := []model.Saga{}
+	for _, saga := range sagas {
+		if query.Cursor != "" && !after.before(newSagaCursor(saga)) {
+			continue
+		}
+
+		if !matchSaga(saga, query) {
+			continue
+		}
+
+		if query.Limit > 0 && len(res) == query.Limit {
+			return res, newSagaCursor(res[len(res)-1]).String(), nil
+		}
+
+		res = append(res, saga)
+	}
+
+	return res, "", nil
+}
+
+func matchSaga(saga model.Saga, query model.SagaQuery) bool {
+	if query.Status != "" && saga.Status != query.Status {
+		return false
+	}
+
+	if len(saga.EventLogs) == 0 {
+		return query.SagaType == "" && query.SagaVersion == 0 && query.CreatedAfter.IsZero() && query.CreatedBefore.IsZero()
+	}
+
+	init := saga.EventLogs[0]
+	if query.SagaType != "" && init.SagaType != query.SagaType {
+		return false
+	}
+
+	if query.SagaVersion != 0 && init.SagaVersion != query.SagaVersion {
+		return false
+	}
+
+	if !query.CreatedAfter.IsZero() && init.CreatedAt.Before(query.CreatedAfter) {
+		return false
+	}
+
+	if !query.CreatedBefore.IsZero() && !init.CreatedAt.Before(query.CreatedBefore) {
+		return false
+	}
+
+	return true
+}
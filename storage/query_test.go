@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sagaLister interface {
+	SaveEventLog(ctx context.Context, event *model.EventLog) error
+	ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error)
+}
+
+var queryDate = time.Date(2019, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+// testListSagas run the ListSagas scenarios shared by all the storages.
+func testListSagas(t *testing.T, storage sagaLister) {
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", CreatedAt: queryDate, SagaType: "payment", SagaVersion: 1},
+		{SagaID: "saga-2", Step: "_init", State: "done", CreatedAt: queryDate.Add(time.Second), SagaType: "payment", SagaVersion: 2},
+		{SagaID: "saga-1", Step: "step1", State: "running", CreatedAt: queryDate},
+		{SagaID: "saga-4", Step: "_init", State: "done", CreatedAt: queryDate, SagaType: "refund", SagaVersion: 1},
+		{SagaID: "saga-3", Step: "_init", State: "done", CreatedAt: queryDate.Add(2 * time.Second)},
+		{SagaID: "saga-1", Step: "step1", State: "done", CreatedAt: queryDate},
+		{SagaID: "saga-2", Step: "step1", State: "running", CreatedAt: queryDate.Add(time.Second)},
+		{SagaID: "saga-2", Step: "step1", State: "aborted", CreatedAt: queryDate.Add(time.Second), Reason: "some-reason"},
+		{SagaID: "saga-4", Step: "step1", State: "stuck", CreatedAt: queryDate},
+		{SagaID: "saga-1", Step: "_finish", State: "done", CreatedAt: queryDate},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	ids := func(sagas []model.Saga) []string {
+		res := []string{}
+		for _, saga := range sagas {
+			res = append(res, saga.ID)
+		}
+
+		return res
+	}
+
+	t.Run("without filter", func(t *testing.T) {
+		res, cursor, err := storage.ListSagas(context.Background(), model.SagaQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, cursor)
+
+		assert.Equal(t, []string{"saga-1", "saga-4", "saga-2", "saga-3"}, ids(res))
+		assert.Equal(t, "done", res[0].Status)
+		assert.Equal(t, "stuck", res[1].Status)
+		assert.Equal(t, "aborted", res[2].Status)
+		assert.Equal(t, "running", res[3].Status)
+
+		require.Len(t, res[0].EventLogs, 4)
+		assert.Equal(t, "_init", res[0].EventLogs[0].Step)
+		assert.Equal(t, "_finish", res[0].EventLogs[3].Step)
+	})
+
+	t.Run("by status", func(t *testing.T) {
+		res, _, err := storage.ListSagas(context.Background(), model.SagaQuery{Status: "aborted"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-2"}, ids(res))
+	})
+
+	t.Run("by definition", func(t *testing.T) {
+		res, _, err := storage.ListSagas(context.Background(), model.SagaQuery{SagaType: "payment"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-1", "saga-2"}, ids(res))
+
+		res, _, err = storage.ListSagas(context.Background(), model.SagaQuery{SagaType: "payment", SagaVersion: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-2"}, ids(res))
+	})
+
+	t.Run("by creation date", func(t *testing.T) {
+		res, _, err := storage.ListSagas(context.Background(), model.SagaQuery{CreatedAfter: queryDate.Add(time.Second)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-2", "saga-3"}, ids(res))
+
+		res, _, err = storage.ListSagas(context.Background(), model.SagaQuery{CreatedBefore: queryDate.Add(time.Second)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-1", "saga-4"}, ids(res))
+	})
+
+	t.Run("with pagination", func(t *testing.T) {
+		res, cursor, err := storage.ListSagas(context.Background(), model.SagaQuery{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-1", "saga-4"}, ids(res))
+		require.NotEmpty(t, cursor)
+
+		res, cursor, err = storage.ListSagas(context.Background(), model.SagaQuery{Limit: 2, Cursor: cursor})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-2", "saga-3"}, ids(res))
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("with pagination and a status", func(t *testing.T) {
+		res, cursor, err := storage.ListSagas(context.Background(), model.SagaQuery{Status: "done", Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"saga-1"}, ids(res))
+		assert.Empty(t, cursor)
+	})
+
+	t.Run("with an invalid cursor", func(t *testing.T) {
+		res, cursor, err := storage.ListSagas(context.Background(), model.SagaQuery{Cursor: "some-invalid-cursor"})
+		assert.EqualError(t, err, `invalid cursor "some-invalid-cursor"`)
+		assert.Empty(t, cursor)
+		assert.Nil(t, res)
+	})
+}
+
+func Test_parseCursor(t *testing.T) {
+	cursor := sagaCursor{createdAt: queryDate.UnixNano(), sagaID: "saga:1"}
+
+	res, err := parseCursor(cursor.String())
+	require.NoError(t, err)
+	assert.Equal(t, cursor, res)
+
+	_, err = parseCursor("12:")
+	assert.EqualError(t, err, `invalid cursor "12:"`)
+
+	_, err = parseCursor("foo:saga-1")
+	assert.EqualError(t, err, `invalid cursor "foo:saga-1"`)
+}
+
+func Test_groupSagas(t *testing.T) {
+	res := groupSagas([]model.EventLog{
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "step1", State: "stuck"},
+	})
+
+	assert.Equal(t, []model.Saga{
+		{ID: "saga-2", Status: "stuck", EventLogs: []model.EventLog{
+			{SagaID: "saga-2", Step: "_init", State: "done"},
+			{SagaID: "saga-2", Step: "step1", State: "stuck"},
+		}},
+		{ID: "saga-1", Status: "running", EventLogs: []model.EventLog{
+			{SagaID: "saga-1", Step: "_init", State: "done"},
+		}},
+	}, res)
+}
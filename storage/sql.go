@@ -0,0 +1,516 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+)
+
+// SQLDialect is the SQL flavor spoken by the database.
+type SQLDialect int
+
+const (
+	// SQLite use the "?" placeholders.
+	SQLite SQLDialect = iota
+
+	// Postgres use the "$1" placeholders.
+	Postgres
+)
+
+// sqlMigrations contains the schema changes, one entry per version. The
+// already applied entries must never be modified, only new ones appended.
+var sqlMigrations = [][]string{
+	{
+		`CREATE TABLE event_logs (
+			saga_id VARCHAR(255) NOT NULL,
+			seq BIGINT NOT NULL,
+			step VARCHAR(255) NOT NULL,
+			state VARCHAR(32) NOT NULL,
+			context TEXT,
+			PRIMARY KEY (saga_id, seq)
+		)`,
+		`CREATE INDEX event_logs_step_idx ON event_logs (step)`,
+	},
+	{
+		// Unix timestamp in nanoseconds, 0 for the unknown dates.
+		`ALTER TABLE event_logs ADD COLUMN created_at BIGINT NOT NULL DEFAULT 0`,
+	},
+	{
+		// JSON object, NULL for the eventlogs without metadata.
+		`ALTER TABLE event_logs ADD COLUMN metadata TEXT`,
+	},
+	{
+		// Empty for the eventlogs not related to a parallel stage branch.
+		`ALTER TABLE event_logs ADD COLUMN branch VARCHAR(255) NOT NULL DEFAULT ''`,
+	},
+	{
+		// Empty for the eventlogs other than "_init" and for the sagas without
+		// definition.
+		`ALTER TABLE event_logs ADD COLUMN saga_type VARCHAR(255) NOT NULL DEFAULT ''`,
+		`ALTER TABLE event_logs ADD COLUMN saga_version INTEGER NOT NULL DEFAULT 0`,
+	},
+	{
+		// Empty for the eventlogs other than "aborted".
+		`ALTER TABLE event_logs ADD COLUMN reason TEXT NOT NULL DEFAULT ''`,
+	},
+}
+
+// SQL eventlog storage using a SQL database as storage.
+//
+// The eventlogs are saved into the "event_logs" table, keyed by saga ID and a
+// sequence number incremented for each eventlog of the saga.
+type SQL struct {
+	db      *sql.DB
+	dialect SQLDialect
+}
+
+// NewSQL instantiate a new SQL storage.
+//
+// Migrate must be called before any use.
+func NewSQL(db *sql.DB, dialect SQLDialect) *SQL {
+	return &SQL{
+		db:      db,
+		dialect: dialect,
+	}
+}
+
+// Migrate create or update the database schema.
+func (t *SQL) Migrate(ctx context.Context) error {
+	_, err := t.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS gosaga_migrations (version INTEGER NOT NULL PRIMARY KEY)`)
+	if err != nil {
+		return fmt.Errorf("failed to create the migrations table: %s", err)
+	}
+
+	var version int
+	err = t.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM gosaga_migrations`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve the schema version: %s", err)
+	}
+
+	for ; version < len(sqlMigrations); version++ {
+		err = t.applyMigration(ctx, version+1, sqlMigrations[version])
+		if err != nil {
+			return fmt.Errorf("failed to apply the migration %d: %s", version+1, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *SQL) applyMigration(ctx context.Context, version int, statements []string) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, t.rebind(`INSERT INTO gosaga_migrations (version) VALUES (?)`), version)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SaveEventLog save a new eventlog about a saga Change.
+//
+// An "_init" eventlog for an existing saga is refused with an error wrapping
+// model.ErrSagaAlreadyExists.
+func (t *SQL) SaveEventLog(ctx context.Context, event *model.EventLog) error {
+	metadata, err := encodeMetadata(event.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode the metadata: %s", err)
+	}
+
+	if event.Step == "_init" {
+		return t.createSaga(ctx, event, metadata)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start a transaction: %s", err)
+	}
+	defer tx.Rollback()
+
+	// The next sequence number is computed from the saved eventlogs so the
+	// concurrent saves for a saga, as for the branches of a parallel stage,
+	// must be serialized. Postgres lock the "_init" eventlog of the saga until
+	// the commit while SQLite serialize all the writes.
+	if t.dialect == Postgres {
+		_, err = tx.ExecContext(ctx, `SELECT seq FROM event_logs WHERE saga_id = $1 AND seq = 1 FOR UPDATE`, event.SagaID)
+		if err != nil {
+			return fmt.Errorf("failed to lock the saga: %s", err)
+		}
+	}
+
+	// The casts are required by Postgres in order to type the placeholders
+	// used outside of a VALUES clause.
+	_, err = tx.ExecContext(ctx, t.rebind(`
+		INSERT INTO event_logs (saga_id, seq, step, state, context, created_at, metadata, branch, saga_type, saga_version, reason)
+		SELECT CAST(? AS VARCHAR(255)), COALESCE(MAX(seq), 0) + 1, CAST(? AS VARCHAR(255)), CAST(? AS VARCHAR(32)), CAST(? AS TEXT), CAST(? AS BIGINT), CAST(? AS TEXT), CAST(? AS VARCHAR(255)), CAST(? AS VARCHAR(255)), CAST(? AS INTEGER), CAST(? AS TEXT)
+		FROM event_logs
+		WHERE saga_id = ?`),
+		event.SagaID, event.Step, event.State, nullableContext(event.Context), unixNano(event.CreatedAt), metadata, event.Branch, event.SagaType, event.SagaVersion, event.Reason, event.SagaID)
+	if err != nil {
+		return fmt.Errorf("failed to insert the eventlog: %s", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("failed to commit the eventlog: %s", err)
+	}
+
+	return nil
+}
+
+// createSaga insert the "_init" eventlog with the first sequence number so the
+// primary key refuses a second saga with the same ID, even concurrently.
+func (t *SQL) createSaga(ctx context.Context, event *model.EventLog, metadata sql.NullString) error {
+	_, err := t.db.ExecContext(ctx, t.rebind(`
+		INSERT INTO event_logs (saga_id, seq, step, state, context, created_at, metadata, branch, saga_type, saga_version, reason)
+		VALUES (?, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?)`),
+		event.SagaID, event.Step, event.State, nullableContext(event.Context), unixNano(event.CreatedAt), metadata, event.Branch, event.SagaType, event.SagaVersion, event.Reason)
+	if err == nil {
+		return nil
+	}
+
+	// The constraint violation errors are specific to each driver, the
+	// conflict is detected by looking for the saga.
+	var count int
+	countErr := t.db.QueryRowContext(ctx, t.rebind(`SELECT COUNT(*) FROM event_logs WHERE saga_id = ?`), event.SagaID).Scan(&count)
+	if countErr == nil && count > 0 {
+		return fmt.Errorf("%w: %q", model.ErrSagaAlreadyExists, event.SagaID)
+	}
+
+	return fmt.Errorf("failed to insert the eventlog: %s", err)
+}
+
+// GetUnfinishedSagaIDs return the IDs of all the sagas without a "_finish"
+// eventlog, ordered by saga ID.
+func (t *SQL) GetUnfinishedSagaIDs(ctx context.Context) ([]string, error) {
+	rows, err := t.db.QueryContext(ctx, `
+		SELECT saga_id
+		FROM event_logs
+		GROUP BY saga_id
+		HAVING SUM(CASE WHEN step = '_finish' THEN 1 ELSE 0 END) = 0
+		ORDER BY saga_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the unfinished sagas: %s", err)
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var sagaID string
+		err = rows.Scan(&sagaID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan the saga id: %s", err)
+		}
+
+		res = append(res, sagaID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query the unfinished sagas: %s", err)
+	}
+
+	return res, nil
+}
+
+// GetSagaEventLogs return all the eventlogs saved for the given saga, in
+// their saving order.
+func (t *SQL) GetSagaEventLogs(ctx context.Context, sagaID string) ([]model.EventLog, error) {
+	rows, err := t.db.QueryContext(ctx, t.rebind(`
+		SELECT `+eventLogColumns+`
+		FROM event_logs
+		WHERE saga_id = ?
+		ORDER BY seq`), sagaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the eventlogs: %s", err)
+	}
+	defer rows.Close()
+
+	return scanEventLogs(rows)
+}
+
+// sqlSagaStatus compute the status of the saga of the "_init" eventlog i as
+// model.ComputeStatus: "done" once finished, else the status set by its last
+// status change.
+const sqlSagaStatus = `
+	CASE
+		WHEN EXISTS (SELECT 1 FROM event_logs f WHERE f.saga_id = i.saga_id AND f.branch = '' AND f.step = '_finish') THEN 'done'
+		ELSE COALESCE((
+			SELECT CASE WHEN s.state = 'stuck' THEN 'stuck' ELSE 'aborted' END
+			FROM event_logs s
+			WHERE s.saga_id = i.saga_id AND s.branch = '' AND s.state IN ('aborted', 'resumed', 'skipped', 'stuck')
+			ORDER BY s.seq DESC
+			LIMIT 1
+		), 'running')
+	END`
+
+// ListSagas return the page of sagas selected by the query with all their
+// eventlogs, ordered by creation date, and the cursor of the next page.
+//
+// The page is selected by a single query on the "_init" eventlogs, the
+// eventlogs of its sagas are then retrieved with a second query.
+func (t *SQL) ListSagas(ctx context.Context, query model.SagaQuery) ([]model.Saga, string, error) {
+	conditions := []string{"i.step = '_init'"}
+	args := []any{}
+
+	if query.SagaType != "" {
+		conditions = append(conditions, "i.saga_type = ?")
+		args = append(args, query.SagaType)
+	}
+
+	if query.SagaVersion != 0 {
+		conditions = append(conditions, "i.saga_version = ?")
+		args = append(args, query.SagaVersion)
+	}
+
+	if !query.CreatedAfter.IsZero() {
+		conditions = append(conditions, "i.created_at >= ?")
+		args = append(args, unixNano(query.CreatedAfter))
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		conditions = append(conditions, "i.created_at < ?")
+		args = append(args, unixNano(query.CreatedBefore))
+	}
+
+	if query.Status != "" {
+		conditions = append(conditions, "("+sqlSagaStatus+") = ?")
+		args = append(args, query.Status)
+	}
+
+	if query.Cursor != "" {
+		after, err := parseCursor(query.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+
+		conditions = append(conditions, "(i.created_at > ? OR (i.created_at = ? AND i.saga_id > ?))")
+		args = append(args, after.createdAt, after.createdAt, after.sagaID)
+	}
+
+	// One more saga is selected in order to know if there is a next page.
+	limit := ""
+	if query.Limit > 0 {
+		limit = "LIMIT " + strconv.Itoa(query.Limit+1)
+	}
+
+	sagaIDs, err := t.querySagaIDs(ctx, `
+		SELECT i.saga_id
+		FROM event_logs i
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY i.created_at, i.saga_id
+		`+limit, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	hasNext := query.Limit > 0 && len(sagaIDs) > query.Limit
+	if hasNext {
+		sagaIDs = sagaIDs[:query.Limit]
+	}
+
+	events, err := t.getSagasEventLogs(ctx, sagaIDs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	res := make([]model.Saga, 0, len(sagaIDs))
+	for _, sagaID := range sagaIDs {
+		res = append(res, model.Saga{ID: sagaID, Status: model.ComputeStatus(events[sagaID]), EventLogs: events[sagaID]})
+	}
+
+	if hasNext {
+		return res, newSagaCursor(res[len(res)-1]).String(), nil
+	}
+
+	return res, "", nil
+}
+
+func (t *SQL) querySagaIDs(ctx context.Context, query string, args ...any) ([]string, error) {
+	rows, err := t.db.QueryContext(ctx, t.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the sagas: %s", err)
+	}
+	defer rows.Close()
+
+	res := []string{}
+	for rows.Next() {
+		var sagaID string
+		err = rows.Scan(&sagaID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan the saga id: %s", err)
+		}
+
+		res = append(res, sagaID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query the sagas: %s", err)
+	}
+
+	return res, nil
+}
+
+// maxSagasPerQuery is the maximum of saga IDs given to a single query, under
+// the parameters limit of the databases.
+const maxSagasPerQuery = 500
+
+// getSagasEventLogs return the eventlogs of the given sagas by saga ID, in
+// their saving order.
+func (t *SQL) getSagasEventLogs(ctx context.Context, sagaIDs []string) (map[string][]model.EventLog, error) {
+	res := map[string][]model.EventLog{}
+
+	for start := 0; start < len(sagaIDs); start += maxSagasPerQuery {
+		chunk := sagaIDs[start:min(start+maxSagasPerQuery, len(sagaIDs))]
+
+		args := make([]any, len(chunk))
+		for i, sagaID := range chunk {
+			args[i] = sagaID
+		}
+
+		rows, err := t.db.QueryContext(ctx, t.rebind(`
+			SELECT `+eventLogColumns+`
+			FROM event_logs
+			WHERE saga_id IN (?`+strings.Repeat(", ?", len(chunk)-1)+`)
+			ORDER BY saga_id, seq`), args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query the eventlogs: %s", err)
+		}
+
+		events, err := scanEventLogs(rows)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range events {
+			res[event.SagaID] = append(res[event.SagaID], event)
+		}
+	}
+
+	return res, nil
+}
+
+// eventLogColumns are the columns read by scanEventLogs.
+const eventLogColumns = `saga_id, step, state, context, created_at, metadata, branch, saga_type, saga_version, reason`
+
+func scanEventLogs(rows *sql.Rows) ([]model.EventLog, error) {
+	res := []model.EventLog{}
+	for rows.Next() {
+		var (
+			event     model.EventLog
+			context   sql.NullString
+			createdAt int64
+			metadata  sql.NullString
+		)
+
+		err := rows.Scan(&event.SagaID, &event.Step, &event.State, &context, &createdAt, &metadata, &event.Branch, &event.SagaType, &event.SagaVersion, &event.Reason)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan the eventlog: %s", err)
+		}
+
+		if context.Valid {
+			event.Context = json.RawMessage(context.String)
+		}
+
+		if createdAt != 0 {
+			event.CreatedAt = time.Unix(0, createdAt).UTC()
+		}
+
+		if metadata.Valid {
+			err = json.Unmarshal([]byte(metadata.String), &event.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode the metadata: %s", err)
+			}
+		}
+
+		res = append(res, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query the eventlogs: %s", err)
+	}
+
+	return res, nil
+}
+
+// Compact remove the eventlogs of all the finished sagas.
+func (t *SQL) Compact(ctx context.Context) error {
+	_, err := t.db.ExecContext(ctx, `
+		DELETE FROM event_logs
+		WHERE saga_id IN (SELECT saga_id FROM event_logs WHERE step = '_finish')`)
+	if err != nil {
+		return fmt.Errorf("failed to compact the finished sagas: %s", err)
+	}
+
+	return nil
+}
+
+// rebind replace the "?" placeholders by the ones used by the dialect.
+func (t *SQL) rebind(query string) string {
+	if t.dialect != Postgres {
+		return query
+	}
+
+	var (
+		builder strings.Builder
+		n       int
+	)
+	for _, r := range query {
+		if r != '?' {
+			builder.WriteRune(r)
+			continue
+		}
+
+		n++
+		builder.WriteString("$" + strconv.Itoa(n))
+	}
+
+	return builder.String()
+}
+
+func nullableContext(context json.RawMessage) sql.NullString {
+	if context == nil {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: string(context), Valid: true}
+}
+
+func encodeMetadata(metadata map[string]string) (sql.NullString, error) {
+	if len(metadata) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(raw), Valid: true}, nil
+}
+
+func unixNano(date time.Time) int64 {
+	if date.IsZero() {
+		return 0
+	}
+
+	return date.UnixNano()
+}
@@ -0,0 +1,212 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSQL(t *testing.T) *SQL {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+
+	// Each connection to ":memory:" open a new database.
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	storage := NewSQL(db, SQLite)
+
+	err = storage.Migrate(context.Background())
+	require.NoError(t, err)
+
+	return storage
+}
+
+func Test_SQL_Migrate_should_be_idempotent(t *testing.T) {
+	storage := newTestSQL(t)
+
+	err := storage.Migrate(context.Background())
+	assert.NoError(t, err)
+
+	var version int
+	err = storage.db.QueryRow(`SELECT MAX(version) FROM gosaga_migrations`).Scan(&version)
+	require.NoError(t, err)
+	assert.Equal(t, len(sqlMigrations), version)
+}
+
+func Test_SQL_SaveEventLog_success(t *testing.T) {
+	storage := newTestSQL(t)
+
+	events := []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", Context: json.RawMessage(`{"key":"value"}`), CreatedAt: time.Date(2019, time.January, 2, 15, 4, 5, 6, time.UTC), Metadata: map[string]string{"traceparent": "some-trace"}, SagaType: "payment", SagaVersion: 2},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "step1", State: "aborted", Context: json.RawMessage(`{"key":"value"}`), Branch: "branch1", Reason: "some-reason"},
+	}
+
+	for _, event := range events {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{events[0], events[2]}, res)
+
+	res, err = storage.GetSagaEventLogs(context.Background(), "saga-2")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{events[1]}, res)
+}
+
+func Test_SQL_SaveEventLog_with_concurrent_writes(t *testing.T) {
+	storage := newTestSQL(t)
+
+	err := storage.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "_init", State: "done"})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			err := storage.SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1", Step: "stage", Branch: fmt.Sprintf("branch%d", i), State: "done"})
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Len(t, res, 21)
+}
+
+func Test_SQL_GetUnfinishedSagaIDs_success(t *testing.T) {
+	storage := newTestSQL(t)
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-3", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_finish", State: "done"},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	res, err := storage.GetUnfinishedSagaIDs(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"saga-2", "saga-3"}, res)
+}
+
+func Test_SQL_GetSagaEventLogs_with_an_unknown_saga(t *testing.T) {
+	storage := newTestSQL(t)
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "some-unknown-id")
+
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func Test_SQL_SaveEventLog_without_migration(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	err = NewSQL(db, SQLite).SaveEventLog(context.Background(), &model.EventLog{SagaID: "saga-1"})
+
+	assert.Error(t, err)
+}
+
+func Test_SQL_rebind(t *testing.T) {
+	query := `SELECT * FROM event_logs WHERE saga_id = ? AND step = ?`
+
+	assert.Equal(t, query, NewSQL(nil, SQLite).rebind(query))
+	assert.Equal(t, `SELECT * FROM event_logs WHERE saga_id = $1 AND step = $2`, NewSQL(nil, Postgres).rebind(query))
+}
+
+func Test_SQL_SaveEventLog_with_an_existing_saga(t *testing.T) {
+	testSaveEventLogWithAnExistingSaga(t, newTestSQL(t))
+}
+
+func Test_SQL_ListSagas(t *testing.T) {
+	testListSagas(t, newTestSQL(t))
+}
+
+func Test_SQL_ListSagas_should_compute_the_status_as_the_journal(t *testing.T) {
+	storage := newTestSQL(t)
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done", CreatedAt: queryDate},
+		{SagaID: "saga-1", Step: "step1", State: "stuck", CreatedAt: queryDate},
+		{SagaID: "saga-1", Step: "step1", State: "resumed", CreatedAt: queryDate},
+		{SagaID: "saga-2", Step: "_init", State: "done", CreatedAt: queryDate.Add(time.Second)},
+		{SagaID: "saga-2", Step: "step1", State: "stuck", CreatedAt: queryDate, Branch: "branch1"},
+		{SagaID: "saga-3", Step: "_init", State: "done", CreatedAt: queryDate.Add(2 * time.Second)},
+		{SagaID: "saga-3", Step: "step1", State: "aborted", CreatedAt: queryDate},
+		{SagaID: "saga-3", Step: "step1", State: "stuck", CreatedAt: queryDate},
+		{SagaID: "saga-4", Step: "_init", State: "done", CreatedAt: queryDate.Add(3 * time.Second)},
+		{SagaID: "saga-4", Step: "step1", State: "stuck", CreatedAt: queryDate},
+		{SagaID: "saga-4", Step: "_finish", State: "forced", CreatedAt: queryDate},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	for _, status := range []string{"aborted", "running", "stuck", "done"} {
+		res, _, err := storage.ListSagas(context.Background(), model.SagaQuery{Status: status})
+		require.NoError(t, err)
+
+		// The status filtered by the database match the computed one.
+		require.Len(t, res, 1, status)
+		assert.Equal(t, status, res[0].Status)
+	}
+}
+
+func Test_SQL_ListSagas_with_more_sagas_than_a_query_accept(t *testing.T) {
+	storage := newTestSQL(t)
+
+	for i := 0; i < maxSagasPerQuery+1; i++ {
+		err := storage.SaveEventLog(context.Background(), &model.EventLog{SagaID: fmt.Sprintf("saga-%04d", i), Step: "_init", State: "done", CreatedAt: queryDate})
+		require.NoError(t, err)
+	}
+
+	res, cursor, err := storage.ListSagas(context.Background(), model.SagaQuery{})
+	require.NoError(t, err)
+	assert.Empty(t, cursor)
+	require.Len(t, res, maxSagasPerQuery+1)
+	assert.Len(t, res[maxSagasPerQuery].EventLogs, 1)
+}
+
+func Test_SQL_Compact_should_remove_only_the_finished_sagas(t *testing.T) {
+	storage := newTestSQL(t)
+
+	for _, event := range []model.EventLog{
+		{SagaID: "saga-1", Step: "_init", State: "done"},
+		{SagaID: "saga-2", Step: "_init", State: "done"},
+		{SagaID: "saga-1", Step: "_finish", State: "done"},
+	} {
+		err := storage.SaveEventLog(context.Background(), &event)
+		require.NoError(t, err)
+	}
+
+	err := storage.Compact(context.Background())
+	require.NoError(t, err)
+
+	res, err := storage.GetSagaEventLogs(context.Background(), "saga-1")
+	assert.NoError(t, err)
+	assert.Empty(t, res)
+
+	res, err = storage.GetSagaEventLogs(context.Background(), "saga-2")
+	assert.NoError(t, err)
+	assert.Equal(t, []model.EventLog{{SagaID: "saga-2", Step: "_init", State: "done"}}, res)
+}
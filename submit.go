@@ -0,0 +1,167 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// defaultWorkers is the number of submitted sagas run concurrently by default.
+const defaultWorkers = 10
+
+// defaultOutcomeRetention is the duration the Outcome of a finished saga is
+// kept for Wait by default.
+const defaultOutcomeRetention = 10 * time.Minute
+
+// Outcome is the final result of a saga.
+type Outcome struct {
+	SagaID string
+
+	// Status is "committed" if all the Sub-Requests succeeded, "compensated"
+	// if the saga have been aborted and rollbacked or "stuck" if one of its
+	// Compensations have exhausted its RetryPolicy.
+	Status string
+
+	// Context returned by the last executed Action or Compensation.
+	Context json.RawMessage
+
+	// Err is a *SagaAbortedError if the saga have been compensated or is
+	// stuck, nil otherwise.
+	Err error
+}
+
+type pendingOutcome struct {
+	done    chan struct{}
+	outcome *Outcome
+	err     error
+}
+
+// WithWorkers set the number of submitted sagas run concurrently. It panics if
+// workers is lower than 1.
+func (t *SEC) WithWorkers(workers int) *SEC {
+	if workers < 1 {
+		panic(fmt.Sprintf("gosaga: the workers count must be at least 1, have %d", workers))
+	}
+
+	t.workers = make(chan struct{}, workers)
+
+	return t
+}
+
+// WithOutcomeRetention set the duration the Outcome of a finished submitted
+// saga is kept in memory if it is not retrieved with Wait, 10 minutes by
+// default. Zero keeps the Outcomes until they are retrieved.
+func (t *SEC) WithOutcomeRetention(retention time.Duration) *SEC {
+	t.outcomeRetention = retention
+
+	return t
+}
+
+// Submit create a new Saga with the given sagaCtx and schedule it into the
+// worker pool.
+//
+// It returns as soon as the saga is saved into the journal. Use Wait in order
+// to retrieve the saga Outcome.
+func (t *SEC) Submit(ctx context.Context, sagaCtx json.RawMessage) (string, error) {
+	return t.submitNewSaga(ctx, "", "", 0, sagaCtx)
+}
+
+// submitNewSaga create a new saga and schedule it. The saga ID is generated
+// if sagaID is empty.
+func (t *SEC) submitNewSaga(ctx context.Context, sagaID string, sagaType string, sagaVersion int, sagaCtx json.RawMessage) (string, error) {
+	metadata := map[string]string{}
+	ctx, end := t.startSaga(ctx, "", metadata)
+
+	sagaID, err := t.createSaga(ctx, sagaID, sagaType, sagaVersion, sagaCtx, metadata)
+	if err != nil {
+		end(nil, err)
+		return "", err
+	}
+
+	t.scheduleSaga(ctx, sagaID, func(ctx context.Context, sagaID string) (*Outcome, error) {
+		outcome, err := t.runSaga(ctx, sagaID)
+		end(outcome, err)
+
+		return outcome, err
+	})
+
+	return sagaID, nil
+}
+
+// scheduleSaga run the given saga with run into the worker pool. Its Outcome
+// is kept for Wait.
+func (t *SEC) scheduleSaga(ctx context.Context, sagaID string, run func(context.Context, string) (*Outcome, error)) {
+	pending := &pendingOutcome{done: make(chan struct{})}
+
+	t.mutex.Lock()
+	if t.outcomes == nil {
+		t.outcomes = map[string]*pendingOutcome{}
+	}
+	t.outcomes[sagaID] = pending
+	t.mutex.Unlock()
+
+	// The saga must outlive the submitter request.
+	runCtx := context.WithoutCancel(ctx)
+
+	go func() {
+		t.workers <- struct{}{}
+		defer func() { <-t.workers }()
+
+		pending.outcome, pending.err = run(runCtx, sagaID)
+		if pending.err != nil {
+			t.logSaga(runCtx, slog.LevelError, "failed to run the saga", sagaID, "error", pending.err)
+		}
+		close(pending.done)
+
+		// Nobody may ever wait the saga.
+		if t.outcomeRetention > 0 {
+			time.AfterFunc(t.outcomeRetention, func() { t.forgetOutcome(sagaID, pending) })
+		}
+	}()
+}
+
+// Wait block until the end of the given submitted saga and return its Outcome.
+//
+// The Outcome Err is also returned if the saga have been compensated or is
+// stuck.
+//
+// The Outcome is kept in memory until it is retrieved so it can be retrieved
+// only once. An Outcome not retrieved is dropped after the retention set with
+// WithOutcomeRetention.
+func (t *SEC) Wait(ctx context.Context, sagaID string) (*Outcome, error) {
+	t.mutex.Lock()
+	pending, ok := t.outcomes[sagaID]
+	t.mutex.Unlock()
+
+	if !ok {
+		return nil, fmt.Errorf("saga %q not submitted or already waited", sagaID)
+	}
+
+	select {
+	case <-pending.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	t.mutex.Lock()
+	delete(t.outcomes, sagaID)
+	t.mutex.Unlock()
+
+	if pending.err != nil {
+		return nil, pending.err
+	}
+
+	return pending.outcome, pending.outcome.Err
+}
+
+// forgetOutcome remove the given Outcome if it have not been retrieved yet.
+func (t *SEC) forgetOutcome(sagaID string, pending *pendingOutcome) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.outcomes[sagaID] == pending {
+		delete(t.outcomes, sagaID)
+	}
+}
@@ -0,0 +1,164 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/internal/journal"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SEC_Submit_success(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithWorkers(2).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(json.RawMessage(`{"step1": "done"}`))
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+	assert.NotEmpty(t, sagaID)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.NoError(t, err)
+	assert.Equal(t, &Outcome{
+		SagaID:  sagaID,
+		Status:  "committed",
+		Context: json.RawMessage(`{"step1": "done"}`),
+	}, outcome)
+
+	// The outcome can be retrieved only once.
+	outcome, err = scheduler.Wait(context.Background(), sagaID)
+	assert.EqualError(t, err, `saga "`+sagaID+`" not submitted or already waited`)
+	assert.Nil(t, outcome)
+}
+
+func Test_SEC_Submit_with_a_compensated_saga(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(json.RawMessage(`{"step1": "reverted"}`))
+		})
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	expectedErr := &SagaAbortedError{SagaID: sagaID, SubRequestID: "step1", Err: errors.New("some-error")}
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Equal(t, expectedErr, err)
+	assert.Equal(t, &Outcome{
+		SagaID:  sagaID,
+		Status:  "compensated",
+		Context: json.RawMessage(`{"step1": "reverted"}`),
+		Err:     expectedErr,
+	}, outcome)
+}
+
+func Test_SEC_Submit_should_outlive_the_submitter_context(t *testing.T) {
+	release := make(chan struct{})
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			<-release
+			if ctx.Err() != nil {
+				return Failure(ctx.Err(), sagaCtx)
+			}
+
+			return Success(sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sagaID, err := scheduler.Submit(ctx, json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+	cancel()
+	close(release)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.NoError(t, err)
+	assert.Equal(t, "committed", outcome.Status)
+}
+
+func Test_SEC_Submit_with_journal_CreateNewSaga_error_should_fail(t *testing.T) {
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+
+	journal := new(journal.Mock)
+	subRequest := new(SubRequestMock)
+	scheduler := &SEC{subRequestDefs: []subRequestDef{}, journal: journal, workers: make(chan struct{}, 1)}
+	scheduler.AppendNewSubRequest("step1", subRequest.Action, subRequest.Compensation)
+
+	journal.On("CreateNewSaga", "", 0, sagaCtx, map[string]string{}).Return("", errors.New("some-error")).Once()
+
+	sagaID, err := scheduler.Submit(context.Background(), sagaCtx)
+	assert.EqualError(t, err, "failed to create a new saga: some-error")
+	assert.Empty(t, sagaID)
+
+	journal.AssertExpectations(t)
+	subRequest.AssertExpectations(t)
+}
+
+func Test_SEC_Wait_with_an_unknown_saga(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory())
+
+	outcome, err := scheduler.Wait(context.Background(), "some-unknown-id")
+	assert.EqualError(t, err, `saga "some-unknown-id" not submitted or already waited`)
+	assert.Nil(t, outcome)
+}
+
+func Test_SEC_WithOutcomeRetention_should_drop_the_outcomes_not_waited(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithOutcomeRetention(10*time.Millisecond).
+		AppendNewSubRequest("step1", newCalls().record("step1", Success(nil)), nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		scheduler.mutex.Lock()
+		defer scheduler.mutex.Unlock()
+
+		return len(scheduler.outcomes) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	assert.EqualError(t, err, `saga "`+sagaID+`" not submitted or already waited`)
+}
+
+func Test_SEC_WithWorkers_with_an_invalid_count_should_panic(t *testing.T) {
+	assert.PanicsWithValue(t, "gosaga: the workers count must be at least 1, have 0", func() {
+		NewSagaExecutionCoordinator(storage.NewMemory()).WithWorkers(0)
+	})
+
+	assert.PanicsWithValue(t, "gosaga: the workers count must be at least 1, have -1", func() {
+		NewSagaExecutionCoordinator(storage.NewMemory()).WithWorkers(-1)
+	})
+}
+
+func Test_SEC_Wait_with_a_canceled_context(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			<-release
+			return Success(sagaCtx)
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	outcome, err := scheduler.Wait(ctx, sagaID)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, outcome)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // Result returned at the end of an Action.
@@ -13,6 +14,10 @@ type Result interface {
 }
 
 // Action used for a SubRequest Action or Compensation.
+//
+// The Action must return once ctx is canceled, by a timeout or a saga
+// cancellation. The next Action or Compensation of the saga is run only once
+// it has returned.
 type Action func(ctx context.Context, cmd json.RawMessage) Result
 
 // SubRequestDef is the definition for an ACID Sub-Request.
@@ -26,11 +31,110 @@ type subRequestDef struct {
 	//
 	// **THE COMPENSATION SUBREQUEST NEED TO BE IDEMPOTENT**.
 	Compensation Action
+
+	// ActionRetry is the retry policy applied when the Action fails.
+	ActionRetry RetryPolicy
+
+	// CompensationRetry is the retry policy applied when the Compensation
+	// fails.
+	CompensationRetry RetryPolicy
+
+	// ActionTimeout and CompensationTimeout are the maximum durations of an
+	// attempt. Zero means no timeout.
+	ActionTimeout       time.Duration
+	CompensationTimeout time.Duration
+
+	// Branches are the Sub-Requests run concurrently by a parallel stage. A
+	// stage has no Action nor Compensation.
+	Branches []subRequestDef
+
+	// Join merge the contexts returned by the Branches of a stage.
+	Join JoinFunc
+
+	// Pivot is set for the Sub-Request after which the saga can't be
+	// compensated anymore.
+	Pivot bool
+}
+
+// SubRequestOption customize a Sub-Request appended with AppendNewSubRequest.
+type SubRequestOption func(def *subRequestDef)
+
+// WithActionRetry set the retry policy applied when the Action fails. Once
+// the attempts are exhausted the saga is aborted.
+//
+// By default the Actions are not retried.
+func WithActionRetry(policy RetryPolicy) SubRequestOption {
+	return func(def *subRequestDef) {
+		def.ActionRetry = policy
+	}
+}
+
+// WithCompensationRetry set the retry policy applied when the Compensation
+// fails.
+//
+// By default the Compensations are retried forever with an exponential
+// backoff, see DefaultCompensationRetry.
+func WithCompensationRetry(policy RetryPolicy) SubRequestOption {
+	return func(def *subRequestDef) {
+		def.CompensationRetry = policy
+	}
+}
+
+// newSubRequestDef instantiate the definition of a Sub-Request appended with
+// AppendNewSubRequest.
+func newSubRequestDef(name string, action Action, compensation Action, opts []SubRequestOption) subRequestDef {
+	def := subRequestDef{
+		SubRequestID:      name,
+		Action:            action,
+		Compensation:      compensation,
+		ActionRetry:       NoRetry,
+		CompensationRetry: DefaultCompensationRetry,
+	}
+
+	for _, opt := range opts {
+		opt(&def)
+	}
+
+	return def
 }
 
 // SubRequestDefs is the ordered collection of SubRequest.
 type subRequestDefs []subRequestDef
 
+// append return the Sub-Requests with the given one appended. It panics if
+// the new Sub-Request is a second pivot or if it follows the pivot with a
+// Compensation.
+func (t subRequestDefs) append(def subRequestDef) subRequestDefs {
+	if pivot := t.GetPivot(); pivot != nil {
+		if def.Pivot {
+			panic(fmt.Sprintf("gosaga: the saga already have the pivot %q", pivot.SubRequestID))
+		}
+
+		if def.Compensation != nil {
+			panic(fmt.Sprintf("gosaga: the sub-request %q follows the pivot and can't have a compensation", def.SubRequestID))
+		}
+
+		for _, branch := range def.Branches {
+			if branch.Compensation != nil {
+				panic(fmt.Sprintf("gosaga: the branch %q follows the pivot and can't have a compensation", def.SubRequestID+"/"+branch.SubRequestID))
+			}
+		}
+	}
+
+	return append(t, def)
+}
+
+// GetPivot return the pivot Sub-Request, nil if the saga has no pivot.
+func (t subRequestDefs) GetPivot() *subRequestDef {
+	for i := range t {
+		if t[i].Pivot {
+			return &t[i]
+		}
+	}
+
+	return nil
+}
+
 // GetFirstSubRequest return the first Sub-Request to execute.
 func (t subRequestDefs) GetFirstSubRequest() *subRequestDef {
 	return &t[0]
@@ -202,3 +202,44 @@ func Test_GetSubRequestBefore_success_with_an_invalid_subrequest_id(t *testing.T
 
 	subRequestMock.AssertExpectations(t)
 }
+
+func Test_SubRequestOptions(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5}
+
+	scheduler := &SEC{subRequestDefs: []subRequestDef{}}
+	scheduler.AppendNewSubRequest("step1", nil, nil)
+	scheduler.AppendNewSubRequest("step2", nil, nil, WithActionRetry(policy), WithCompensationRetry(policy))
+
+	assert.Equal(t, NoRetry, scheduler.subRequestDefs[0].ActionRetry)
+	assert.Equal(t, DefaultCompensationRetry, scheduler.subRequestDefs[0].CompensationRetry)
+	assert.Equal(t, policy, scheduler.subRequestDefs[1].ActionRetry)
+	assert.Equal(t, policy, scheduler.subRequestDefs[1].CompensationRetry)
+}
+
+func Test_subRequestDefs_append_with_a_second_pivot_should_panic(t *testing.T) {
+	defs := subRequestDefs{}.append(newSubRequestDef("step1", nil, nil, []SubRequestOption{AsPivot()}))
+
+	assert.Equal(t, "step1", defs.GetPivot().SubRequestID)
+	assert.PanicsWithValue(t, `gosaga: the saga already have the pivot "step1"`, func() {
+		defs.append(newSubRequestDef("step2", nil, nil, []SubRequestOption{AsPivot()}))
+	})
+}
+
+func Test_subRequestDefs_append_with_a_compensation_after_the_pivot_should_panic(t *testing.T) {
+	subRequestMock := new(SubRequestMock)
+
+	defs := subRequestDefs{}.
+		append(newSubRequestDef("step1", subRequestMock.Action, subRequestMock.Compensation, nil)).
+		append(newSubRequestDef("step2", subRequestMock.Action, subRequestMock.Compensation, []SubRequestOption{AsPivot()}))
+
+	assert.PanicsWithValue(t, `gosaga: the sub-request "step3" follows the pivot and can't have a compensation`, func() {
+		defs.append(newSubRequestDef("step3", subRequestMock.Action, subRequestMock.Compensation, nil))
+	})
+
+	assert.PanicsWithValue(t, `gosaga: the branch "stage/branch2" follows the pivot and can't have a compensation`, func() {
+		defs.append(newStageDef("stage", []Branch{
+			NewBranch("branch1", subRequestMock.Action, nil),
+			NewBranch("branch2", subRequestMock.Action, subRequestMock.Compensation),
+		}, nil))
+	})
+}
@@ -0,0 +1,141 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrTimeout is wrapped by the errors of the Actions and Compensations
+// stopped by a timeout.
+var ErrTimeout = errors.New("timeout")
+
+// defaultStopGracePeriod is the delay after which an Action still running
+// once its context is canceled is reported in the logs.
+const defaultStopGracePeriod = 10 * time.Second
+
+// WithActionTimeout set the maximum duration of each Action attempt. The
+// context given to the Action is canceled once the timeout is reached and the
+// saga is aborted without retrying it.
+//
+// The Action must return once its context is canceled: it is waited before
+// the compensation in order to never run two Actions of a saga concurrently.
+// An Action still running 10s after the timeout is reported in the logs, an
+// Action succeeding despite the timeout is considered as succeeded.
+//
+// For a parallel stage the timeout applies to all its branches.
+func WithActionTimeout(timeout time.Duration) SubRequestOption {
+	return func(def *subRequestDef) {
+		def.ActionTimeout = timeout
+	}
+}
+
+// WithCompensationTimeout set the maximum duration of each Compensation
+// attempt. A Compensation reaching the timeout is considered as failed and is
+// retried according to its RetryPolicy, once it has returned.
+//
+// For a parallel stage the timeout applies to all its branches.
+func WithCompensationTimeout(timeout time.Duration) SubRequestOption {
+	return func(def *subRequestDef) {
+		def.CompensationTimeout = timeout
+	}
+}
+
+// WithSagaTimeout set the maximum duration of the sagas run with the SEC
+// Sub-Requests, from their creation. Once reached the running Action is
+// stopped, as with WithActionTimeout, and the saga is compensated. The
+// Compensations are not limited.
+func (t *SEC) WithSagaTimeout(timeout time.Duration) *SEC {
+	t.sagaTimeout = timeout
+
+	return t
+}
+
+// withTimeout return a context canceled with an ErrTimeout cause once the
+// given timeout is reached. Zero means no timeout.
+func withTimeout(ctx context.Context, timeout time.Duration, phase string) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeoutCause(ctx, timeout, fmt.Errorf("%w: the %s took more than %s", ErrTimeout, phase, timeout))
+}
+
+// withSagaDeadline return the context given to the saga Actions, canceled
+// once the saga timeout is reached.
+func (t *SEC) withSagaDeadline(ctx context.Context, sagaID string, def *SagaDefinition) (context.Context, context.CancelFunc) {
+	if def.timeout == 0 {
+		return ctx, func() {}
+	}
+
+	createdAt := t.journal.GetSagaCreatedAt(sagaID)
+	if createdAt.IsZero() {
+		return ctx, func() {}
+	}
+
+	return context.WithDeadlineCause(ctx, createdAt.Add(def.timeout), fmt.Errorf("%w: the saga took more than %s", ErrTimeout, def.timeout))
+}
+
+// runAction run the given Action or Compensation and return its Result.
+//
+// If the context is canceled by a timeout or a saga cancellation the Action
+// is waited and its failure is reported as a Failure wrapping ErrTimeout or
+// ErrCancelled. An Action not returning after the grace period is reported in
+// the logs and still waited.
+func (t *SEC) runAction(ctx context.Context, sagaID string, subRequestID string, phase string, action Action, arg json.RawMessage) Result {
+	if ctx.Done() == nil {
+		return action(ctx, arg)
+	}
+
+	if isStopped(ctx) {
+		return Failure(context.Cause(ctx), arg)
+	}
+
+	done := make(chan Result, 1)
+	go func() {
+		done <- action(ctx, arg)
+	}()
+
+	var result Result
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		grace := time.NewTimer(t.stopGracePeriod)
+		defer grace.Stop()
+
+		select {
+		case result = <-done:
+		case <-grace.C:
+			t.logSubRequest(ctx, slog.LevelWarn, "sub-request still running after its context cancellation", sagaID, subRequestID, phase, "", context.Cause(ctx))
+			result = <-done
+		}
+	}
+
+	// An Action failing because of the timeout or the cancellation, like
+	// with the context error, report the cause.
+	if isStopped(ctx) && (result == nil || !result.IsSuccess()) {
+		return Failure(context.Cause(ctx), arg)
+	}
+
+	return result
+}
+
+// isTimeout return true if the given context have been canceled by a timeout.
+func isTimeout(ctx context.Context) bool {
+	return ctx.Err() != nil && errors.Is(context.Cause(ctx), ErrTimeout)
+}
+
+// isStopped return true if the given context have been canceled by a timeout
+// or a saga cancellation.
+func isStopped(ctx context.Context) bool {
+	return ctx.Err() != nil && isStopError(context.Cause(ctx))
+}
+
+// isStopError return true if the given error is caused by a timeout or a saga
+// cancellation. Such errors are never retried.
+func isStopError(err error) bool {
+	return errors.Is(err, ErrTimeout) || errors.Is(err, ErrCancelled)
+}
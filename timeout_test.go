@@ -0,0 +1,215 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hang is an Action blocked until its context is canceled.
+func hang(t *testing.T) Action {
+	return func(ctx context.Context, sagaCtx json.RawMessage) Result {
+		<-ctx.Done()
+		return Failure(ctx.Err(), sagaCtx)
+	}
+}
+
+func Test_SEC_WithActionTimeout_should_abort_without_retry(t *testing.T) {
+	memory := storage.NewMemory()
+	calls := newCalls()
+
+	attempts := new(atomic.Int32)
+	scheduler := NewSagaExecutionCoordinator(memory).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{}`))), calls.record("undo-step1", Success(nil))).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			attempts.Add(1)
+			<-ctx.Done()
+			return Failure(ctx.Err(), sagaCtx)
+		}, nil, WithActionTimeout(10*time.Millisecond), WithActionRetry(RetryPolicy{MaxAttempts: 3}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	outcome, err := scheduler.Wait(context.Background(), sagaID)
+	assert.Equal(t, "compensated", outcome.Status)
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "step2", abortedErr.SubRequestID)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.EqualError(t, abortedErr.Err, "timeout: the action took more than 10ms")
+
+	assert.Equal(t, int32(1), attempts.Load())
+	assert.Equal(t, []string{"step1", "undo-step1"}, calls.sorted())
+
+	events, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	reasons := []string{}
+	for _, event := range events {
+		if event.State == "aborted" {
+			reasons = append(reasons, event.Reason)
+		}
+	}
+	assert.Equal(t, []string{"timeout: the action took more than 10ms"}, reasons)
+}
+
+func Test_SEC_WithActionTimeout_should_wait_the_action_before_compensating(t *testing.T) {
+	handler := new(recordHandler)
+
+	returned := new(atomic.Bool)
+	compensated := new(atomic.Bool)
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithLogger(slog.New(handler)).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			compensated.Store(returned.Load())
+			return Success(sagaCtx)
+		}).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			// The context is checked too late.
+			time.Sleep(50 * time.Millisecond)
+			returned.Store(true)
+			return Failure(ctx.Err(), sagaCtx)
+		}, nil, WithActionTimeout(10*time.Millisecond))
+	scheduler.stopGracePeriod = 10 * time.Millisecond
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	// The compensation is run once the Action has returned.
+	assert.True(t, compensated.Load())
+	assert.Contains(t, handler.Lines(), "WARN sub-request still running after its context cancellation sub_request_id=step2 phase=action error=timeout: the action took more than 10ms")
+}
+
+func Test_SEC_WithActionTimeout_on_a_stage(t *testing.T) {
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewStage("stage", []Branch{
+			NewBranch("branch1", calls.record("branch1", Success(nil)), calls.record("undo-branch1", Success(nil))),
+			NewBranch("branch2", hang(t), calls.record("undo-branch2", Success(nil))),
+		}, WithActionTimeout(10*time.Millisecond))
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorContains(t, err, `branch "branch2" failed: timeout: the action took more than 10ms`)
+
+	// The timed out branch may have applied its Action so it is
+	// compensated.
+	assert.Equal(t, []string{"branch1", "undo-branch1", "undo-branch2"}, calls.sorted())
+}
+
+func Test_SEC_WithCompensationTimeout_should_retry_the_compensation(t *testing.T) {
+	attempts := new(atomic.Int32)
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			if attempts.Add(1) == 1 {
+				<-ctx.Done()
+				return Failure(ctx.Err(), sagaCtx)
+			}
+
+			return Success(sagaCtx)
+		}, WithCompensationTimeout(10*time.Millisecond)).
+		AppendNewSubRequest("step2", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{}`))
+	require.NoError(t, err)
+
+	outcome, _ := scheduler.Wait(context.Background(), sagaID)
+	assert.Equal(t, "compensated", outcome.Status)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func Test_SEC_WithSagaTimeout_should_compensate_the_saga(t *testing.T) {
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithSagaTimeout(20*time.Millisecond).
+		AppendNewSubRequest("step1", calls.record("step1", Success(json.RawMessage(`{}`))), func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			// The Compensations are not limited by the saga timeout.
+			if ctx.Err() != nil {
+				return Failure(ctx.Err(), sagaCtx)
+			}
+
+			return calls.record("undo-step1", Success(nil))(ctx, sagaCtx)
+		}).
+		AppendNewSubRequest("step2", hang(t), nil)
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{}`))
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "step2", abortedErr.SubRequestID)
+	assert.EqualError(t, abortedErr.Err, "timeout: the saga took more than 20ms")
+	assert.Equal(t, []string{"step1", "undo-step1"}, calls.sorted())
+}
+
+func Test_SEC_WithSagaTimeout_with_a_recovered_saga_already_expired(t *testing.T) {
+	ctx := context.Background()
+	memory := storage.NewMemory()
+
+	err := memory.SaveEventLog(ctx, &model.EventLog{SagaID: "some-saga-id", Step: "_init", State: "done", Context: json.RawMessage(`{}`), CreatedAt: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+
+	calls := newCalls()
+	scheduler := NewSagaExecutionCoordinator(memory).
+		WithSagaTimeout(time.Minute).
+		AppendNewSubRequest("step1", calls.record("step1", Success(nil)), nil)
+
+	err = scheduler.Recover(ctx)
+	require.NoError(t, err)
+
+	// The deadline is computed from the saga creation.
+	assert.Empty(t, calls.sorted())
+}
+
+func Test_SagaDefinition_WithTimeout(t *testing.T) {
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithSagaTimeout(time.Hour).
+		RegisterSaga(NewSagaDefinition("payment", 1).
+			WithTimeout(10*time.Millisecond).
+			AppendNewSubRequest("step1", hang(t), nil))
+
+	err := scheduler.StartNamedSaga(context.Background(), "payment", json.RawMessage(`{}`))
+
+	assert.EqualError(t, errors.Unwrap(err), "timeout: the saga took more than 10ms")
+}
+
+func Test_runAction_with_a_canceled_context_should_wait_the_action(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := NewSagaExecutionCoordinator(storage.NewMemory()).runAction(ctx, "some-saga-id", "step1", PhaseAction, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+		return Success(sagaCtx)
+	}, json.RawMessage(`{}`))
+
+	assert.True(t, result.IsSuccess())
+}
+
+func Test_runAction_with_a_cancelled_saga_should_wait_the_action(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	result := NewSagaExecutionCoordinator(storage.NewMemory()).runAction(ctx, "some-saga-id", "step1", PhaseAction, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+		cancel(fmt.Errorf("%w: some-reason", ErrCancelled))
+		<-ctx.Done()
+		return Failure(ctx.Err(), sagaCtx)
+	}, json.RawMessage(`{}`))
+
+	assert.False(t, result.IsSuccess())
+	assert.EqualError(t, resultErr(result), "cancelled: some-reason")
+}
@@ -0,0 +1,74 @@
+package gosaga
+
+import (
+	"context"
+)
+
+// Tracer wrap the runs of the sagas and of their Actions and Compensations,
+// typically into tracing spans. The tracing package contains an OpenTelemetry
+// implementation.
+type Tracer interface {
+	// StartSaga is called before each run of a saga: the first one and the
+	// ones following a Recover or a manual intervention.
+	//
+	// For a new saga, sagaID and metadata are empty and the values added into
+	// metadata are saved with the saga. Otherwise metadata contains the values
+	// saved at the saga creation.
+	//
+	// The returned function is called at the end of the run.
+	StartSaga(ctx context.Context, sagaID string, metadata map[string]string) (context.Context, func(outcome *Outcome, err error))
+
+	// StartStep is called before each Action or Compensation attempt, phase
+	// being PhaseAction or PhaseCompensation. The returned context is given
+	// to the Action and the returned function is called with the error of a
+	// failed attempt.
+	StartStep(ctx context.Context, sagaID string, subRequestID string, phase string) (context.Context, func(err error))
+}
+
+// WithTracer set the Tracer wrapping the saga runs.
+func (t *SEC) WithTracer(tracer Tracer) *SEC {
+	t.tracer = tracer
+
+	return t
+}
+
+// startSaga start the trace of a saga run.
+func (t *SEC) startSaga(ctx context.Context, sagaID string, metadata map[string]string) (context.Context, func(outcome *Outcome, err error)) {
+	if t.tracer == nil {
+		return ctx, func(*Outcome, error) {}
+	}
+
+	return t.tracer.StartSaga(ctx, sagaID, metadata)
+}
+
+// startStep start the trace of an Action or Compensation attempt.
+func (t *SEC) startStep(ctx context.Context, sagaID string, subRequestID string, phase string) (context.Context, func(result Result)) {
+	if t.tracer == nil {
+		return ctx, func(Result) {}
+	}
+
+	ctx, end := t.tracer.StartStep(ctx, sagaID, subRequestID, phase)
+
+	return ctx, func(result Result) {
+		if result.IsSuccess() {
+			end(nil)
+			return
+		}
+
+		end(resultErr(result))
+	}
+}
+
+// continueSaga run a saga created by a previous run.
+func (t *SEC) continueSaga(ctx context.Context, sagaID string) (*Outcome, error) {
+	if t.tracer == nil {
+		return t.runSaga(ctx, sagaID)
+	}
+
+	ctx, end := t.startSaga(ctx, sagaID, t.journal.GetSagaMetadata(sagaID))
+
+	outcome, err := t.runSaga(ctx, sagaID)
+	end(outcome, err)
+
+	return outcome, err
+}
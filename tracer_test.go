@@ -0,0 +1,144 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type contextKey string
+
+// fakeTracer save each call as a string and add the step name into the
+// context given to the Actions.
+type fakeTracer struct {
+	calls []string
+}
+
+func (t *fakeTracer) StartSaga(ctx context.Context, sagaID string, metadata map[string]string) (context.Context, func(outcome *Outcome, err error)) {
+	t.calls = append(t.calls, fmt.Sprintf("start saga %v", metadata))
+
+	if sagaID == "" {
+		metadata["trace"] = "some-trace-id"
+	}
+
+	return ctx, func(outcome *Outcome, err error) {
+		t.calls = append(t.calls, fmt.Sprintf("end saga %s %v", outcome.Status, err))
+	}
+}
+
+func (t *fakeTracer) StartStep(ctx context.Context, sagaID string, subRequestID string, phase string) (context.Context, func(err error)) {
+	t.calls = append(t.calls, fmt.Sprintf("start %s %s", phase, subRequestID))
+
+	return context.WithValue(ctx, contextKey("step"), phase+" "+subRequestID), func(err error) {
+		t.calls = append(t.calls, fmt.Sprintf("end %s %s %v", phase, subRequestID, err))
+	}
+}
+
+func Test_SEC_WithTracer_success(t *testing.T) {
+	memory := storage.NewMemory()
+	tracer := new(fakeTracer)
+
+	var stepCtx interface{}
+	scheduler := NewSagaExecutionCoordinator(memory).
+		WithTracer(tracer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			stepCtx = ctx.Value(contextKey("step"))
+			return Success(sagaCtx)
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"start saga map[]",
+		"start action step1",
+		"end action step1 <nil>",
+		"end saga committed <nil>",
+	}, tracer.calls)
+	assert.Equal(t, "action step1", stepCtx)
+
+	// The metadata filled by the tracer are saved with the saga.
+	eventLogs, err := memory.GetSagaEventLogs(context.Background(), sagaID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"trace": "some-trace-id"}, eventLogs[0].Metadata)
+}
+
+func Test_SEC_WithTracer_with_a_resumed_saga(t *testing.T) {
+	tracer := new(fakeTracer)
+
+	fixed := false
+	scheduler := NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithTracer(tracer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			if !fixed {
+				return Failure(errors.New("some-compensation-error"), sagaCtx)
+			}
+
+			return Success(sagaCtx)
+		}, WithCompensationRetry(RetryPolicy{MaxAttempts: 1}))
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.Error(t, err)
+
+	fixed = true
+	err = scheduler.ResumeSaga(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"start saga map[]",
+		"start action step1",
+		"end action step1 some-error",
+		"start compensation step1",
+		"end compensation step1 some-compensation-error",
+		"end saga stuck <nil>",
+		// The resumed run receive the metadata saved at the creation.
+		"start saga map[trace:some-trace-id]",
+		"start compensation step1",
+		"end compensation step1 <nil>",
+		"end saga compensated <nil>",
+	}, tracer.calls)
+}
+
+func Test_SEC_WithTracer_with_a_recovered_saga(t *testing.T) {
+	memory := storage.NewMemory()
+	tracer := new(fakeTracer)
+
+	sagaCtx := json.RawMessage(`{"key": "value"}`)
+	for _, event := range []model.EventLog{
+		{SagaID: "some-saga-id", Step: "_init", State: "done", Context: sagaCtx, Metadata: map[string]string{"trace": "some-trace-id"}},
+		{SagaID: "some-saga-id", Step: "step1", State: "running", Context: sagaCtx},
+	} {
+		require.NoError(t, memory.SaveEventLog(context.Background(), &event))
+	}
+
+	scheduler := NewSagaExecutionCoordinator(memory).
+		WithTracer(tracer).
+		AppendNewSubRequest("step1", nil, func(ctx context.Context, sagaCtx json.RawMessage) Result {
+			return Success(sagaCtx)
+		})
+
+	err := scheduler.Recover(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"start saga map[trace:some-trace-id]",
+		"start compensation step1",
+		"end compensation step1 <nil>",
+		"end saga compensated <nil>",
+	}, tracer.calls)
+}
@@ -0,0 +1,100 @@
+// Package tracing trace the sagas with OpenTelemetry.
+//
+// Each saga run is wrapped into a "saga" span with a child span per Action and
+// Compensation attempt. The trace context is saved with the saga so a saga
+// recovered after a crash or resumed after a manual intervention continues in
+// the same trace.
+package tracing
+
+import (
+	"context"
+
+	"github.com/Peltoche/gosaga"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/Peltoche/gosaga/tracing"
+
+// The keys of the attributes added to the spans.
+const (
+	AttrSagaID       = attribute.Key("gosaga.saga_id")
+	AttrSagaStatus   = attribute.Key("gosaga.saga_status")
+	AttrSubRequestID = attribute.Key("gosaga.sub_request_id")
+	AttrPhase        = attribute.Key("gosaga.phase")
+)
+
+// Tracer is a gosaga.Tracer using OpenTelemetry.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// NewTracer instantiate a new Tracer creating its spans with the given
+// provider. The global provider is used if provider is nil.
+func NewTracer(provider trace.TracerProvider) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+
+	return &Tracer{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// StartSaga implements gosaga.Tracer.
+//
+// The span of a new saga is a child of the span found into ctx, if any. The
+// span of a saga created by a previous run is a child of the span of the
+// first run.
+func (t *Tracer) StartSaga(ctx context.Context, sagaID string, metadata map[string]string) (context.Context, func(outcome *gosaga.Outcome, err error)) {
+	if sagaID != "" {
+		ctx = t.propagator.Extract(ctx, propagation.MapCarrier(metadata))
+	}
+
+	ctx, span := t.tracer.Start(ctx, "saga")
+
+	if sagaID == "" {
+		t.propagator.Inject(ctx, propagation.MapCarrier(metadata))
+	}
+
+	return ctx, func(outcome *gosaga.Outcome, err error) {
+		defer span.End()
+
+		if outcome != nil {
+			span.SetAttributes(AttrSagaID.String(outcome.SagaID), AttrSagaStatus.String(outcome.Status))
+		} else if sagaID != "" {
+			span.SetAttributes(AttrSagaID.String(sagaID))
+		}
+
+		switch {
+		case err != nil:
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		case outcome != nil && outcome.Err != nil:
+			span.SetStatus(codes.Error, outcome.Status)
+		}
+	}
+}
+
+// StartStep implements gosaga.Tracer.
+func (t *Tracer) StartStep(ctx context.Context, sagaID string, subRequestID string, phase string) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, phase+" "+subRequestID, trace.WithAttributes(
+		AttrSagaID.String(sagaID),
+		AttrSubRequestID.String(subRequestID),
+		AttrPhase.String(phase),
+	))
+
+	return ctx, func(err error) {
+		defer span.End()
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+}
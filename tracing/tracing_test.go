@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Peltoche/gosaga"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func newTestTracer(t *testing.T) (*Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	return NewTracer(provider), exporter
+}
+
+func attributes(span tracetest.SpanStub) map[attribute.Key]string {
+	res := map[attribute.Key]string{}
+	for _, attr := range span.Attributes {
+		res[attr.Key] = attr.Value.AsString()
+	}
+
+	return res
+}
+
+func Test_Tracer_with_a_committed_saga(t *testing.T) {
+	tracer, exporter := newTestTracer(t)
+
+	var actionSpan trace.SpanContext
+	scheduler := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithTracer(tracer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			actionSpan = trace.SpanContextFromContext(ctx)
+			return gosaga.Success(sagaCtx)
+		}, nil)
+
+	sagaID, err := scheduler.Submit(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+
+	_, err = scheduler.Wait(context.Background(), sagaID)
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+
+	step, saga := spans[0], spans[1]
+
+	assert.Equal(t, "saga", saga.Name)
+	assert.False(t, saga.Parent.IsValid())
+	assert.Equal(t, map[attribute.Key]string{
+		AttrSagaID:     sagaID,
+		AttrSagaStatus: "committed",
+	}, attributes(saga))
+	assert.Equal(t, codes.Unset, saga.Status.Code)
+
+	assert.Equal(t, "action step1", step.Name)
+	assert.Equal(t, saga.SpanContext.SpanID(), step.Parent.SpanID())
+	assert.Equal(t, saga.SpanContext.TraceID(), step.SpanContext.TraceID())
+	assert.Equal(t, map[attribute.Key]string{
+		AttrSagaID:       sagaID,
+		AttrSubRequestID: "step1",
+		AttrPhase:        gosaga.PhaseAction,
+	}, attributes(step))
+
+	// The action receive the context of its span.
+	assert.Equal(t, step.SpanContext, actionSpan)
+}
+
+func Test_Tracer_with_a_parent_span(t *testing.T) {
+	tracer, exporter := newTestTracer(t)
+
+	scheduler := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithTracer(tracer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Success(sagaCtx)
+		}, nil)
+
+	ctx, parent := tracer.tracer.Start(context.Background(), "parent")
+	err := scheduler.StartSaga(ctx, json.RawMessage(`{"key": "value"}`))
+	require.NoError(t, err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "saga", spans[1].Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[1].Parent.SpanID())
+}
+
+func Test_Tracer_with_a_resumed_saga_should_continue_the_trace(t *testing.T) {
+	tracer, exporter := newTestTracer(t)
+
+	fixed := false
+	scheduler := gosaga.NewSagaExecutionCoordinator(storage.NewMemory()).
+		WithTracer(tracer).
+		AppendNewSubRequest("step1", func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			return gosaga.Failure(errors.New("some-error"), sagaCtx)
+		}, func(ctx context.Context, sagaCtx json.RawMessage) gosaga.Result {
+			if !fixed {
+				return gosaga.Failure(errors.New("some-compensation-error"), sagaCtx)
+			}
+
+			return gosaga.Success(sagaCtx)
+		}, gosaga.WithCompensationRetry(gosaga.RetryPolicy{MaxAttempts: 1}))
+
+	err := scheduler.StartSaga(context.Background(), json.RawMessage(`{"key": "value"}`))
+	require.Error(t, err)
+
+	stuck := scheduler.ListStuckSagas()
+	require.Len(t, stuck, 1)
+
+	fixed = true
+	err = scheduler.ResumeSaga(context.Background(), stuck[0])
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 5)
+
+	names := []string{}
+	for _, span := range spans {
+		names = append(names, span.Name)
+		assert.Equal(t, spans[0].SpanContext.TraceID(), span.SpanContext.TraceID())
+	}
+	assert.Equal(t, []string{
+		"action step1",
+		"compensation step1",
+		"saga",
+		"compensation step1",
+		"saga",
+	}, names)
+
+	// The failed attempts are recorded.
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, "some-error", spans[0].Status.Description)
+	assert.Equal(t, codes.Error, spans[2].Status.Code)
+	assert.Equal(t, "stuck", attributes(spans[2])[AttrSagaStatus])
+
+	// The resumed run is a child of the first one.
+	assert.Equal(t, spans[2].SpanContext.SpanID(), spans[4].Parent.SpanID())
+	assert.Equal(t, "compensated", attributes(spans[4])[AttrSagaStatus])
+}
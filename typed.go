@@ -0,0 +1,277 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Peltoche/gosaga/internal/journal"
+)
+
+// Step is a typed Sub-Request. Its Action receive the value returned by the
+// previous Step, or the saga input for the first one, and its result is given
+// to the next Step.
+type Step[In, Out any] struct {
+	name         string
+	prev         string // The previous Step name, set by Then.
+	action       func(ctx context.Context, in In) (Out, error)
+	compensation func(ctx context.Context, in In, out *Out) error
+	opts         []SubRequestOption
+}
+
+// NewStep instantiate a new typed Step. The same options as
+// AppendNewSubRequest are supported.
+//
+// The Compensation receive the Action input and its output, or nil if the
+// Action failed or have been interrupted. It can be nil.
+func NewStep[In, Out any](
+	name string,
+	action func(ctx context.Context, in In) (Out, error),
+	compensation func(ctx context.Context, in In, out *Out) error,
+	opts ...SubRequestOption,
+) Step[In, Out] {
+	return Step[In, Out]{
+		name:         name,
+		action:       action,
+		compensation: compensation,
+		opts:         opts,
+	}
+}
+
+// Saga is a typed saga taking an In value and returning the Out value of its
+// last Step.
+//
+// The values are still saved as JSON into the journal so they must be JSON
+// serializable.
+type Saga[In, Out any] struct {
+	sec *SEC
+
+	// last is the name of the saga last Step, empty for a saga without any
+	// Step.
+	last string
+}
+
+// NewSaga instantiate a new typed saga without any Step. The Steps are added
+// with Then.
+func NewSaga[T any](storage journal.Storage) *Saga[T, T] {
+	return &Saga[T, T]{sec: NewSagaExecutionCoordinator(storage)}
+}
+
+// Then append the given Step to the saga, its input must be the output of the
+// saga last Step.
+//
+// The returned saga shares the SEC of the given one which must not be used
+// anymore.
+func Then[In, Mid, Out any](saga *Saga[In, Mid], step Step[Mid, Out]) *Saga[In, Out] {
+	step.prev = saga.last
+
+	var compensation Action
+	if step.compensation != nil {
+		compensation = step.compensate
+	}
+
+	saga.sec.AppendNewSubRequest(step.name, step.act, compensation, step.opts...)
+
+	return &Saga[In, Out]{sec: saga.sec, last: step.name}
+}
+
+// SEC return the SEC running the saga, in order to configure it or to call
+// Recover.
+func (t *Saga[In, Out]) SEC() *SEC {
+	return t.sec
+}
+
+// Start create a new saga with the given input and run it synchronously. It
+// returns the output of the last Step.
+//
+// A *SagaAbortedError is returned if the saga have been compensated or is
+// stuck.
+func (t *Saga[In, Out]) Start(ctx context.Context, in In) (Out, error) {
+	var out Out
+
+	sagaCtx, err := encodeEnvelope("_init", in)
+	if err != nil {
+		return out, err
+	}
+
+	outcome, err := t.sec.startNewSaga(ctx, "", "", 0, sagaCtx)
+	if err != nil {
+		return out, err
+	}
+
+	return decodeOutput[Out](outcome)
+}
+
+// Submit create a new saga with the given input and schedule it into the
+// worker pool. See SEC.Submit.
+func (t *Saga[In, Out]) Submit(ctx context.Context, in In) (string, error) {
+	sagaCtx, err := encodeEnvelope("_init", in)
+	if err != nil {
+		return "", err
+	}
+
+	return t.sec.Submit(ctx, sagaCtx)
+}
+
+// StartWithKey create a new saga identified by the given idempotency key and
+// run it as Start. If a saga with the same key already exists, its output is
+// returned. See SEC.StartSagaWithKey.
+func (t *Saga[In, Out]) StartWithKey(ctx context.Context, key string, in In) (Out, error) {
+	var out Out
+
+	sagaCtx, err := encodeEnvelope("_init", in)
+	if err != nil {
+		return out, err
+	}
+
+	outcome, err := t.sec.StartSagaWithKey(ctx, key, sagaCtx)
+	if err != nil {
+		return out, err
+	}
+
+	return decodeOutput[Out](outcome)
+}
+
+// SubmitWithKey create a new saga identified by the given idempotency key and
+// schedule it into the worker pool. See SEC.SubmitWithKey.
+func (t *Saga[In, Out]) SubmitWithKey(ctx context.Context, key string, in In) (string, error) {
+	sagaCtx, err := encodeEnvelope("_init", in)
+	if err != nil {
+		return "", err
+	}
+
+	return t.sec.SubmitWithKey(ctx, key, sagaCtx)
+}
+
+// Wait block until the end of the given saga and return the output of its
+// last Step. See SEC.Wait.
+func (t *Saga[In, Out]) Wait(ctx context.Context, sagaID string) (Out, error) {
+	outcome, err := t.sec.Wait(ctx, sagaID)
+	if err != nil {
+		var out Out
+		return out, err
+	}
+
+	return decodeOutput[Out](outcome)
+}
+
+// decodeOutput return the output of the last Step of a committed saga.
+func decodeOutput[Out any](outcome *Outcome) (Out, error) {
+	var out Out
+
+	var env envelope
+	err := json.Unmarshal(outcome.Context, &env)
+	if err != nil {
+		return out, fmt.Errorf("failed to decode the saga output: %s", err)
+	}
+
+	err = json.Unmarshal(env.Output, &out)
+	if err != nil {
+		return out, fmt.Errorf("failed to decode the saga output: %s", err)
+	}
+
+	return out, nil
+}
+
+// act is the untyped Action of the Step.
+func (t Step[In, Out]) act(ctx context.Context, sagaCtx json.RawMessage) Result {
+	var in In
+
+	prev, err := decodeEnvelope(sagaCtx)
+	if err == nil {
+		err = json.Unmarshal(prev.Output, &in)
+	}
+	if err != nil {
+		return Failure(fmt.Errorf("failed to decode the %q input: %w", t.name, err), sagaCtx)
+	}
+
+	out, err := t.action(ctx, in)
+	if err != nil {
+		return Failure(err, sagaCtx)
+	}
+
+	res, err := encodeEnvelope(t.name, out)
+	if err != nil {
+		return Failure(fmt.Errorf("failed to encode the %q output: %w", t.name, err), sagaCtx)
+	}
+
+	return Success(res)
+}
+
+// compensate is the untyped Compensation of the Step. Its input and its
+// output are read from the saga state, the context returned by the
+// Compensations of the next Steps is ignored. It returns the envelope of its
+// input.
+func (t Step[In, Out]) compensate(ctx context.Context, sagaCtx json.RawMessage) Result {
+	var (
+		in  In
+		out *Out
+	)
+
+	state, ok := SagaStateFromContext(ctx)
+	if !ok {
+		return Failure(fmt.Errorf("failed to decode the %q input: no saga state", t.name), sagaCtx)
+	}
+
+	// The Action output is saved only if it succeeded.
+	if rawOutput, ok := state.Outputs[t.name]; ok {
+		env, err := decodeEnvelope(rawOutput)
+		if err == nil {
+			out = new(Out)
+			err = json.Unmarshal(env.Output, out)
+		}
+		if err != nil {
+			return Failure(fmt.Errorf("failed to decode the %q output: %w", t.name, err), sagaCtx)
+		}
+	}
+
+	prevCtx := state.Input
+	if t.prev != "" {
+		prevCtx = state.Outputs[t.prev]
+	}
+
+	prev, err := decodeEnvelope(prevCtx)
+	if err == nil {
+		err = json.Unmarshal(prev.Output, &in)
+	}
+	if err != nil {
+		return Failure(fmt.Errorf("failed to decode the %q input: %w", t.name, err), sagaCtx)
+	}
+
+	err = t.compensation(ctx, in, out)
+	if err != nil {
+		return Failure(err, sagaCtx)
+	}
+
+	return Success(prevCtx)
+}
+
+// envelope is the JSON saved into the journal by the typed Steps. It contains
+// only the Step own output, the outputs of the previous Steps needed by the
+// Compensations are read from the saga state.
+type envelope struct {
+	Step   string          `json:"step"`
+	Output json.RawMessage `json:"output"`
+}
+
+// encodeEnvelope return the envelope of the given Step output.
+func encodeEnvelope(step string, output any) (json.RawMessage, error) {
+	rawOutput, err := json.Marshal(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope{Step: step, Output: rawOutput})
+}
+
+func decodeEnvelope(sagaCtx json.RawMessage) (envelope, error) {
+	var env envelope
+
+	err := json.Unmarshal(sagaCtx, &env)
+	if err == nil && env.Step == "" {
+		err = errors.New("not a typed saga context")
+	}
+
+	return env, err
+}
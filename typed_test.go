@@ -0,0 +1,218 @@
+package gosaga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Peltoche/gosaga/model"
+	"github.com/Peltoche/gosaga/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type order struct {
+	ID     string `json:"id"`
+	Amount int    `json:"amount"`
+}
+
+type payment struct {
+	OrderID   string `json:"orderId"`
+	PaymentID string `json:"paymentId"`
+}
+
+type shipment struct {
+	TrackingID string `json:"trackingId"`
+}
+
+func Test_Saga_Start_success(t *testing.T) {
+	saga := Then(
+		Then(NewSaga[order](storage.NewMemory()),
+			NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+				return payment{OrderID: in.ID, PaymentID: "payment-" + in.ID}, nil
+			}, nil)),
+		NewStep("ship", func(ctx context.Context, in payment) (shipment, error) {
+			return shipment{TrackingID: "tracking-" + in.PaymentID}, nil
+		}, nil))
+
+	out, err := saga.Start(context.Background(), order{ID: "order-1", Amount: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, shipment{TrackingID: "tracking-payment-order-1"}, out)
+}
+
+func Test_Saga_Start_should_save_only_the_step_outputs(t *testing.T) {
+	memory := storage.NewMemory()
+	saga := Then(
+		Then(NewSaga[order](memory),
+			NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+				return payment{OrderID: in.ID}, nil
+			}, nil)),
+		NewStep("ship", func(ctx context.Context, in payment) (shipment, error) {
+			return shipment{TrackingID: "tracking-1"}, nil
+		}, nil))
+
+	_, err := saga.Start(context.Background(), order{ID: "order-1"})
+	require.NoError(t, err)
+
+	sagas, _, err := memory.ListSagas(context.Background(), model.SagaQuery{})
+	require.NoError(t, err)
+	require.Len(t, sagas, 1)
+
+	eventLogs, err := memory.GetSagaEventLogs(context.Background(), sagas[0].ID)
+	require.NoError(t, err)
+
+	outputs := map[string]string{}
+	for _, eventLog := range eventLogs {
+		if eventLog.State == "done" {
+			outputs[eventLog.Step] = string(eventLog.Context)
+		}
+	}
+
+	assert.JSONEq(t, `{"step": "pay", "output": {"orderId": "order-1", "paymentId": ""}}`, outputs["pay"])
+	assert.JSONEq(t, `{"step": "ship", "output": {"trackingId": "tracking-1"}}`, outputs["ship"])
+}
+
+func Test_Saga_Start_should_not_keep_the_outcome(t *testing.T) {
+	saga := Then(NewSaga[order](storage.NewMemory()),
+		NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+			return payment{}, ctx.Err()
+		}, nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := saga.Start(ctx, order{ID: "order-1"})
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// The saga is run synchronously, nothing is left for Wait.
+	assert.Empty(t, saga.SEC().outcomes)
+}
+
+func Test_Saga_StartWithKey_success(t *testing.T) {
+	attempts := 0
+	saga := Then(NewSaga[order](storage.NewMemory()),
+		NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+			attempts++
+			return payment{OrderID: in.ID, PaymentID: "payment-" + in.ID}, nil
+		}, nil))
+
+	out, err := saga.StartWithKey(context.Background(), "some-key", order{ID: "order-1"})
+	require.NoError(t, err)
+	assert.Equal(t, payment{OrderID: "order-1", PaymentID: "payment-order-1"}, out)
+
+	// The existing saga output is returned.
+	out, err = saga.StartWithKey(context.Background(), "some-key", order{ID: "order-2"})
+	require.NoError(t, err)
+	assert.Equal(t, payment{OrderID: "order-1", PaymentID: "payment-order-1"}, out)
+	assert.Equal(t, 1, attempts)
+
+	sagaID, err := saga.SubmitWithKey(context.Background(), "some-key", order{ID: "order-2"})
+	assert.Equal(t, "some-key", sagaID)
+	assert.ErrorIs(t, err, ErrSagaAlreadyExists)
+}
+
+func Test_Saga_Start_with_a_failure_should_compensate_with_the_typed_values(t *testing.T) {
+	calls := []string{}
+
+	saga := Then(
+		Then(
+			Then(NewSaga[order](storage.NewMemory()),
+				NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+					return payment{OrderID: in.ID, PaymentID: "payment-1"}, nil
+				}, func(ctx context.Context, in order, out *payment) error {
+					assert.Equal(t, order{ID: "order-1", Amount: 10}, in)
+					assert.Equal(t, &payment{OrderID: "order-1", PaymentID: "payment-1"}, out)
+
+					calls = append(calls, "refund")
+					return nil
+				})),
+			// A Step without Compensation.
+			NewStep("log", func(ctx context.Context, in payment) (payment, error) {
+				return in, nil
+			}, nil)),
+		NewStep("ship", func(ctx context.Context, in payment) (shipment, error) {
+			return shipment{}, errors.New("some-error")
+		}, func(ctx context.Context, in payment, out *shipment) error {
+			assert.Equal(t, payment{OrderID: "order-1", PaymentID: "payment-1"}, in)
+			assert.Nil(t, out)
+
+			calls = append(calls, "cancel-shipment")
+			return nil
+		}))
+
+	out, err := saga.Start(context.Background(), order{ID: "order-1", Amount: 10})
+
+	assert.Equal(t, shipment{}, out)
+	assert.ErrorContains(t, err, "some-error")
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.Equal(t, "ship", abortedErr.SubRequestID)
+
+	assert.Equal(t, []string{"cancel-shipment", "refund"}, calls)
+}
+
+func Test_Saga_Start_with_a_compensation_failure_should_retry_with_the_same_values(t *testing.T) {
+	attempts := 0
+
+	saga := Then(
+		Then(NewSaga[order](storage.NewMemory()),
+			NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+				return payment{OrderID: in.ID}, nil
+			}, func(ctx context.Context, in order, out *payment) error {
+				attempts++
+				assert.Equal(t, &payment{OrderID: "order-1"}, out)
+
+				if attempts == 1 {
+					return errors.New("some-error")
+				}
+
+				return nil
+			}, WithCompensationRetry(RetryPolicy{MaxAttempts: 2}))),
+		NewStep("ship", func(ctx context.Context, in payment) (shipment, error) {
+			return shipment{}, errors.New("some-error")
+		}, nil))
+
+	_, err := saga.Start(context.Background(), order{ID: "order-1"})
+
+	var abortedErr *SagaAbortedError
+	require.ErrorAs(t, err, &abortedErr)
+	assert.False(t, abortedErr.Stuck)
+	assert.Equal(t, 2, attempts)
+}
+
+func Test_Step_act_with_an_invalid_input(t *testing.T) {
+	step := NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+		return payment{}, nil
+	}, nil)
+
+	result := step.act(context.Background(), json.RawMessage(`{"amount": 10}`))
+
+	assert.False(t, result.IsSuccess())
+	assert.EqualError(t, resultErr(result), `failed to decode the "pay" input: not a typed saga context`)
+}
+
+func Test_encodeEnvelope_should_contain_only_the_step_output(t *testing.T) {
+	sagaCtx, err := encodeEnvelope("step2", 2)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"step": "step2", "output": 2}`, string(sagaCtx))
+}
+
+func Test_Step_compensate_without_saga_state(t *testing.T) {
+	step := NewStep("pay", func(ctx context.Context, in order) (payment, error) {
+		return payment{}, nil
+	}, func(ctx context.Context, in order, out *payment) error {
+		return nil
+	})
+
+	result := step.compensate(context.Background(), json.RawMessage(`{"step": "_init", "output": {}}`))
+
+	assert.False(t, result.IsSuccess())
+	assert.EqualError(t, resultErr(result), `failed to decode the "pay" input: no saga state`)
+}